@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/rai-project/evaluation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agreementTopK     int
+	agreementEnsemble bool
+)
+
+var accuracyAgreementCmd = &cobra.Command{
+	Use:     "agreement",
+	Aliases: []string{"agree"},
+	Short:   "Compute the agreement between the top k predictions of the frameworks evaluated on the same model",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["accuracy"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var labels *evaluation.ClassificationLabels
+		if accuracyLabelsPath != "" {
+			var err error
+			labels, err = evaluation.ReadClassificationLabels(accuracyLabelsPath, accuracyLabelOffset)
+			if err != nil {
+				return err
+			}
+		} else if accuracyLabelOffset != 0 {
+			labels = evaluation.NewClassificationLabels(nil, accuracyLabelOffset)
+		}
+
+		run := func() error {
+			evals, err := getEvaluations()
+			if err != nil {
+				return err
+			}
+
+			agreements, ensembles, err := evals.AgreementInformationSummary(inputPredictionCollection, agreementTopK, labels)
+			if err != nil {
+				return err
+			}
+
+			if agreementEnsemble {
+				writer := NewWriter(evaluation.SummaryEnsembleInformation{})
+				defer writer.Close()
+				writer.Rows(ensembles)
+				return nil
+			}
+
+			writer := NewWriter(evaluation.SummaryAgreementInformation{})
+			defer writer.Close()
+
+			writer.Rows(agreements)
+
+			return nil
+		}
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	accuracyAgreementCmd.PersistentFlags().IntVar(&agreementTopK, "top_k", 5, "number of most probable labels compared")
+	accuracyAgreementCmd.PersistentFlags().BoolVar(&agreementEnsemble, "ensemble", false, "output the majority vote ensemble accuracy of models evaluated by three or more frameworks")
+	accuracyAgreementCmd.PersistentFlags().StringVar(&accuracyLabelsPath, "labels", "", "label file (one label per line) used to map the expected labels to class indices")
+	accuracyAgreementCmd.PersistentFlags().IntVar(&accuracyLabelOffset, "label_offset", 0, "offset added to the expected label index")
+
+	accuracyCmd.AddCommand(accuracyAgreementCmd)
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rai-project/evaluation"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	calibrationNumBins            int
+	calibrationCompareFrameworks  bool
+	calibrationReferenceFramework string
+)
+
+var accuracyCalibrationCmd = &cobra.Command{
+	Use:     "calibration",
+	Aliases: []string{"ece", "reliability"},
+	Short:   "Compute the calibration of the classification confidence from the input predictions stored in the database",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["accuracy"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		if plotPath == "" {
+			plotPath = evaluation.TempFile("", "calibration_plot_*."+plotFormat)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var labels *evaluation.ClassificationLabels
+		if accuracyLabelsPath != "" {
+			var err error
+			labels, err = evaluation.ReadClassificationLabels(accuracyLabelsPath, accuracyLabelOffset)
+			if err != nil {
+				return err
+			}
+		} else if accuracyLabelOffset != 0 {
+			labels = evaluation.NewClassificationLabels(nil, accuracyLabelOffset)
+		}
+
+		run := func() error {
+			evals, err := getEvaluations()
+			if err != nil {
+				return err
+			}
+
+			summary, err := evals.CalibrationInformationSummary(inputPredictionCollection, calibrationNumBins, labels)
+			if err != nil {
+				return err
+			}
+
+			if barPlot {
+				err := summary.WriteBarPlot(plotPath)
+				if err != nil {
+					return err
+				}
+				fmt.Println("Created plot in " + plotPath)
+				return nil
+			}
+
+			if openPlot {
+				return summary.OpenBarPlot()
+			}
+
+			if calibrationCompareFrameworks {
+				writer := NewWriter(evaluation.SummaryCalibrationComparisonInformation{})
+				defer writer.Close()
+				writer.Rows(summary.Compare(calibrationReferenceFramework))
+				return nil
+			}
+
+			writer := NewWriter(evaluation.SummaryCalibrationInformation{})
+			defer writer.Close()
+
+			writer.Rows(summary)
+
+			return nil
+		}
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	accuracyCalibrationCmd.PersistentFlags().IntVar(&calibrationNumBins, "num_bins", metrics.DefaultCalibrationBins, "number of confidence bins")
+	accuracyCalibrationCmd.PersistentFlags().StringVar(&accuracyLabelsPath, "labels", "", "label file (one label per line) used to map the expected labels to class indices")
+	accuracyCalibrationCmd.PersistentFlags().IntVar(&accuracyLabelOffset, "label_offset", 0, "offset added to the expected label index")
+	accuracyCalibrationCmd.PersistentFlags().BoolVar(&calibrationCompareFrameworks, "compare_frameworks", false, "output the calibration difference of each framework against the reference framework")
+	accuracyCalibrationCmd.PersistentFlags().StringVar(&calibrationReferenceFramework, "reference_framework", "", "framework to compare against (defaults to the first evaluation of the model)")
+
+	accuracyCmd.AddCommand(accuracyCalibrationCmd)
+}
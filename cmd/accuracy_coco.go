@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/rai-project/evaluation"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cocoAnnotationsPath string
+)
+
+var accuracyCOCOCmd = &cobra.Command{
+	Use:     "coco",
+	Aliases: []string{"map", "mean_average_precision"},
+	Short:   "Compute the COCO mean average precision from the bounding boxes stored in the database",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if cocoAnnotationsPath == "" {
+			return errors.New("the coco annotations file must be specified using --annotations")
+		}
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["accuracy"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		annotations, err := metrics.ReadCOCOAnnotations(cocoAnnotationsPath)
+		if err != nil {
+			return err
+		}
+
+		run := func() error {
+			evals, err := getEvaluations()
+			if err != nil {
+				return err
+			}
+
+			accs, err := evals.COCOAccuracyInformationSummary(inputPredictionCollection, annotations)
+			if err != nil {
+				return err
+			}
+
+			if accuracyPerClass {
+				writer := NewWriter(evaluation.SummaryObjectDetectionClassInformation{})
+				defer writer.Close()
+				for _, acc := range accs {
+					writer.Rows(acc.PerClass())
+				}
+				return nil
+			}
+
+			writer := NewWriter(evaluation.SummaryObjectDetectionAccuracyInformation{})
+			defer writer.Close()
+
+			for _, acc := range accs {
+				writer.Row(acc)
+			}
+
+			return nil
+		}
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	accuracyCOCOCmd.PersistentFlags().StringVar(&cocoAnnotationsPath, "annotations", "", "path to the coco instances annotation file (e.g. instances_val2017.json)")
+	accuracyCOCOCmd.PersistentFlags().BoolVar(&accuracyPerClass, "per_class", false, "output the average precision of each category")
+
+	accuracyCmd.AddCommand(accuracyCOCOCmd)
+}
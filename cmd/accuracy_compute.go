@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/rai-project/evaluation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	accuracyTopK            []int
+	accuracyLabelsPath      string
+	accuracyLabelOffset     int
+	accuracyPerClass        bool
+	accuracyConfusionMatrix bool
+)
+
+var accuracyComputeCmd = &cobra.Command{
+	Use:   "compute",
+	Short: "Compute the accuracy from the input predictions stored in the database",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if accuracyPerClass && accuracyConfusionMatrix {
+			return errors.New("only one of --per_class and --confusion_matrix can be set")
+		}
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["accuracy"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var labels *evaluation.ClassificationLabels
+		if accuracyLabelsPath != "" {
+			var err error
+			labels, err = evaluation.ReadClassificationLabels(accuracyLabelsPath, accuracyLabelOffset)
+			if err != nil {
+				return err
+			}
+		} else if accuracyLabelOffset != 0 {
+			labels = evaluation.NewClassificationLabels(nil, accuracyLabelOffset)
+		}
+
+		run := func() error {
+			evals, err := getEvaluations()
+			if err != nil {
+				return err
+			}
+
+			accs, err := evals.ClassificationAccuracyInformationSummary(inputPredictionCollection, accuracyTopK, labels)
+			if err != nil {
+				return err
+			}
+
+			if accuracyPerClass {
+				writer := NewWriter(evaluation.SummaryClassAccuracyInformation{})
+				defer writer.Close()
+				for _, acc := range accs {
+					writer.Rows(acc.PerClass())
+				}
+				return nil
+			}
+
+			if accuracyConfusionMatrix {
+				writer := NewWriter(evaluation.SummaryConfusionInformation{})
+				defer writer.Close()
+				for _, acc := range accs {
+					writer.Rows(acc.Confusion())
+				}
+				return nil
+			}
+
+			writer := NewWriter(evaluation.SummaryClassificationAccuracyInformation{TopK: accuracyTopK})
+			defer writer.Close()
+
+			for _, acc := range accs {
+				writer.Row(acc)
+			}
+
+			return nil
+		}
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	accuracyComputeCmd.PersistentFlags().IntSliceVar(&accuracyTopK, "top_k", []int{1, 5}, "the top k accuracies to compute")
+	accuracyComputeCmd.PersistentFlags().StringVar(&accuracyLabelsPath, "labels", "", "label file (one label per line) used to map the expected labels to class indices")
+	accuracyComputeCmd.PersistentFlags().IntVar(&accuracyLabelOffset, "label_offset", 0, "offset added to the expected label index (e.g. 1 for models with a background class)")
+	accuracyComputeCmd.PersistentFlags().BoolVar(&accuracyPerClass, "per_class", false, "output the top1 accuracy of each class")
+	accuracyComputeCmd.PersistentFlags().BoolVar(&accuracyConfusionMatrix, "confusion_matrix", false, "output the top1 confusion matrix")
+
+	accuracyCmd.AddCommand(accuracyComputeCmd)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/rai-project/evaluation"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	imageQualityOptions = evaluation.ImageQualityOptions{}
+	niqeCommand         string
+	maCommand           string
+)
+
+func registerNoReferenceQualityCommand(name, command string) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return
+	}
+	metrics.RegisterNoReferenceQualityFunction(name, metrics.CommandNoReferenceQualityFunction(args[0], args[1:]...))
+}
+
+var accuracyImageQualityCmd = &cobra.Command{
+	Use:     "image_quality",
+	Aliases: []string{"psnr", "ssim"},
+	Short:   "Compute the PSNR, SSIM, MS-SSIM and perceptual index of the images stored in the database",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if imageQualityOptions.ReferencesDirectory == "" {
+			return errors.New("the reference images directory must be specified using --references_dir")
+		}
+		registerNoReferenceQualityCommand(metrics.NIQEName, niqeCommand)
+		registerNoReferenceQualityCommand(metrics.MaName, maCommand)
+		if imageQualityOptions.PerceptualIndex &&
+			(metrics.GetNoReferenceQualityFunction(metrics.NIQEName) == nil || metrics.GetNoReferenceQualityFunction(metrics.MaName) == nil) {
+			return errors.New("the perceptual index requires both --niqe_command and --ma_command")
+		}
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["accuracy"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		run := func() error {
+			evals, err := getEvaluations()
+			if err != nil {
+				return err
+			}
+
+			summaries, err := evals.ImageQualityInformationSummary(inputPredictionCollection, imageQualityOptions)
+			if err != nil {
+				return err
+			}
+
+			writer := NewWriter(evaluation.SummaryImageQualityInformation{})
+			defer writer.Close()
+
+			for _, summary := range summaries {
+				writer.Row(summary)
+			}
+
+			return nil
+		}
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	accuracyImageQualityCmd.PersistentFlags().StringVar(&imageQualityOptions.ReferencesDirectory, "references_dir", "", "directory containing the reference (ground truth) images")
+	accuracyImageQualityCmd.PersistentFlags().Float64Var(&imageQualityOptions.DataRange, "data_range", metrics.DefaultImageDataRange, "range of the pixel values of the images")
+	accuracyImageQualityCmd.PersistentFlags().BoolVar(&imageQualityOptions.PerceptualIndex, "perceptual_index", false, "compute the PIRM perceptual index")
+	accuracyImageQualityCmd.PersistentFlags().StringVar(&niqeCommand, "niqe_command", "", "command which prints the NIQE score of the image path passed as last argument")
+	accuracyImageQualityCmd.PersistentFlags().StringVar(&maCommand, "ma_command", "", "command which prints the Ma score of the image path passed as last argument")
+
+	accuracyCmd.AddCommand(accuracyImageQualityCmd)
+}
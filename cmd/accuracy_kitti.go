@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/rai-project/evaluation"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	kittiOptions    = evaluation.KITTIOptions{}
+	kittiCOCOLabels bool
+)
+
+var accuracyKITTICmd = &cobra.Command{
+	Use:   "kitti",
+	Short: "Compute the KITTI 2D object detection average precision from the bounding boxes stored in the database",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if kittiOptions.LabelsDirectory == "" {
+			return errors.New("the kitti label directory must be specified using --labels_dir")
+		}
+		// the labels given using --label_mapping take precedence over the coco labels
+		mapping := map[string]string{}
+		if kittiCOCOLabels {
+			for label, class := range metrics.COCOToKITTILabels {
+				mapping[label] = class
+			}
+		}
+		for label, class := range kittiOptions.LabelMapping {
+			mapping[metrics.NormalizeCategory(label)] = metrics.NormalizeCategory(class)
+		}
+		kittiOptions.LabelMapping = mapping
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["accuracy"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		run := func() error {
+			evals, err := getEvaluations()
+			if err != nil {
+				return err
+			}
+
+			accs, err := evals.KITTIAccuracyInformationSummary(inputPredictionCollection, kittiOptions)
+			if err != nil {
+				return err
+			}
+
+			writer := NewWriter(evaluation.SummaryKITTIAccuracyInformation{})
+			defer writer.Close()
+
+			for _, acc := range accs {
+				writer.Row(acc)
+			}
+
+			return nil
+		}
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	accuracyKITTICmd.PersistentFlags().StringVar(&kittiOptions.LabelsDirectory, "labels_dir", "", "directory containing the kitti label files (e.g. training/label_2)")
+	accuracyKITTICmd.PersistentFlags().StringVar(&kittiOptions.ImagesDirectory, "images_dir", "", "directory containing the kitti images, used to scale normalized bounding boxes")
+	accuracyKITTICmd.PersistentFlags().IntVar(&kittiOptions.ImageWidth, "image_width", 1242, "image width used to scale normalized bounding boxes when the images are not available")
+	accuracyKITTICmd.PersistentFlags().IntVar(&kittiOptions.ImageHeight, "image_height", 375, "image height used to scale normalized bounding boxes when the images are not available")
+	accuracyKITTICmd.PersistentFlags().BoolVar(&kittiOptions.RecallPoints40, "recall_40", false, "use the 40 recall points average precision instead of the 11 recall points one")
+	accuracyKITTICmd.PersistentFlags().StringToStringVar(&kittiOptions.LabelMapping, "label_mapping", nil, "mapping of the predicted labels to the kitti object types (e.g. person=pedestrian,bicycle=cyclist)")
+	accuracyKITTICmd.PersistentFlags().BoolVar(&kittiCOCOLabels, "coco_labels", false, "map the labels of the models trained on coco to the kitti object types (e.g. person to pedestrian)")
+
+	accuracyCmd.AddCommand(accuracyKITTICmd)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/rai-project/evaluation"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/spf13/cobra"
+)
+
+var (
+	segmentationOptions = evaluation.SegmentationOptions{}
+)
+
+var accuracySegmentationCmd = &cobra.Command{
+	Use:     "segmentation",
+	Aliases: []string{"semantic_segmentation", "miou"},
+	Short:   "Compute the semantic segmentation accuracy from the segmentation masks stored in the database",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if segmentationOptions.MasksDirectory == "" {
+			return errors.New("the ground truth masks directory must be specified using --masks_dir")
+		}
+		if accuracyPerClass && accuracyConfusionMatrix {
+			return errors.New("only one of --per_class and --confusion_matrix can be set")
+		}
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["accuracy"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if accuracyLabelsPath != "" {
+			labels, err := evaluation.ReadClassificationLabels(accuracyLabelsPath, 0)
+			if err != nil {
+				return err
+			}
+			segmentationOptions.Labels = labels
+		}
+
+		run := func() error {
+			evals, err := getEvaluations()
+			if err != nil {
+				return err
+			}
+
+			accs, err := evals.SegmentationAccuracyInformationSummary(inputPredictionCollection, segmentationOptions)
+			if err != nil {
+				return err
+			}
+
+			if accuracyPerClass {
+				writer := NewWriter(evaluation.SummarySegmentationClassInformation{})
+				defer writer.Close()
+				for _, acc := range accs {
+					writer.Rows(acc.PerClass())
+				}
+				return nil
+			}
+
+			if accuracyConfusionMatrix {
+				writer := NewWriter(evaluation.SummaryConfusionInformation{})
+				defer writer.Close()
+				for _, acc := range accs {
+					writer.Rows(acc.Confusion())
+				}
+				return nil
+			}
+
+			writer := NewWriter(evaluation.SummarySegmentationAccuracyInformation{})
+			defer writer.Close()
+
+			for _, acc := range accs {
+				writer.Row(acc)
+			}
+
+			return nil
+		}
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	accuracySegmentationCmd.PersistentFlags().StringVar(&segmentationOptions.MasksDirectory, "masks_dir", "", "directory containing the ground truth PNG masks (e.g. VOC2012/SegmentationClass)")
+	accuracySegmentationCmd.PersistentFlags().IntVar(&segmentationOptions.NumClasses, "num_classes", 21, "number of classes of the dataset")
+	accuracySegmentationCmd.PersistentFlags().IntVar(&segmentationOptions.IgnoreLabel, "ignore_label", metrics.DefaultSegmentationIgnoreLabel, "label of the pixels to ignore in the ground truth masks")
+	accuracySegmentationCmd.PersistentFlags().StringVar(&accuracyLabelsPath, "labels", "", "label file (one label per line) used to name the classes")
+	accuracySegmentationCmd.PersistentFlags().BoolVar(&accuracyPerClass, "per_class", false, "output the iou of each class")
+	accuracySegmentationCmd.PersistentFlags().BoolVar(&accuracyConfusionMatrix, "confusion_matrix", false, "output the pixel confusion matrix")
+
+	accuracyCmd.AddCommand(accuracySegmentationCmd)
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
-	"math"
+	"fmt"
+	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +17,7 @@ import (
 	"github.com/rai-project/dlframework"
 	dlcmd "github.com/rai-project/dlframework/framework/cmd"
 	"github.com/rai-project/evaluation"
+	"github.com/rai-project/evaluation/metrics"
 	"github.com/rai-project/parallel/tunny"
 	"github.com/spf13/cobra"
 	"gopkg.in/mgo.v2/bson"
@@ -25,8 +29,34 @@ var (
 	targetEvaluationID     string
 	divergenceTollerance   float64
 	divergenceReporterName string
+	divergenceTopInputs    int
+	divergenceListInputs   bool
+	divergenceListUnpaired bool
+	divergenceNumBins      int
+	divergenceConcurrency  int
+	divergenceDryRun       bool
+	allCloseAbsTolerance   float64
+	allCloseRelTolerance   float64
+	allCloseListViolations bool
 )
 
+// allCloseMethod is the numeric comparison of the raw output tensors, it reports
+// several values for each input and is not a distribution divergence
+const (
+	allCloseMethod           = "AllClose"
+	allCloseNotCloseElements = "NotCloseElements"
+)
+
+// divergenceReport reports a value computed by a divergence method for an input pair. Diverged is the
+// pass/fail predicate of the method: the tolerance for the distribution divergences, and any element
+// outside of atol/rtol for allclose.
+type divergenceReport func(name string, pair *featurePair, value float64, diverged bool) error
+
+// reportDivergence reports a distribution divergence, which diverged if it is above the tolerance
+func reportDivergence(reporter divergenceReport, name string, pair *featurePair, divergence float64) error {
+	return reporter(name, pair, divergence, evaluation.DivergenceAboveTolerance(divergence, divergenceTollerance))
+}
+
 type featurePair struct {
 	sourceID       bson.ObjectId
 	targetID       bson.ObjectId
@@ -36,137 +66,231 @@ type featurePair struct {
 	targetFeatures dlframework.Features
 }
 
-func doComputeDivergence(
-	db database.Database,
+type divergenceJob struct {
+	source    evaluation.Evaluation
+	target    evaluation.Evaluation
+	alignment *evaluation.InputAlignment
+	methods   []string
+	values    *evaluation.DivergenceValues
+}
+
+// planDivergence pairs the input predictions of the source and target evaluations and returns
+// the methods that still need to be computed. A nil job is returned if there is nothing to compute.
+// The inputs are not paired on a dry run, which only counts the work units.
+func planDivergence(
 	evaluationCollection *evaluation.EvaluationCollection,
 	inputPredictionCollection *evaluation.InputPredictionCollection,
-	divergenceCollection *evaluation.DivergenceCollection,
+	workUnitCollection *evaluation.DivergenceWorkUnitCollection,
 	bsonSourceEvaluationID bson.ObjectId,
 	bsonTargetEvaluationID bson.ObjectId,
-	reporterName string,
-	divs ...func(pq *featurePair, reporter func(string, *featurePair, float64)),
-) error {
+	methods []string,
+) (*divergenceJob, error) {
 
 	var sourceEvaluation evaluation.Evaluation
 	err := evaluationCollection.FindOne(udb.Cond{"_id": bsonSourceEvaluationID}, &sourceEvaluation)
 	if err != nil {
-		return errors.Wrapf(err, "cannot find source evaluation with id = %v", bsonSourceEvaluationID.String())
+		return nil, errors.Wrapf(err, "cannot find source evaluation with id = %v", bsonSourceEvaluationID.String())
 	}
 
 	if len(sourceEvaluation.InputPredictionIDs) == 0 {
-		return errors.Errorf("empty source evaluation with id = %v", bsonSourceEvaluationID.String())
+		return nil, errors.Errorf("empty source evaluation with id = %v", bsonSourceEvaluationID.String())
 	}
 
 	var targetEvaluation evaluation.Evaluation
 	err = evaluationCollection.FindOne(udb.Cond{"_id": bsonTargetEvaluationID}, &targetEvaluation)
 	if err != nil {
-		return errors.Wrapf(err, "cannot find target evaluation with id = %v", bsonTargetEvaluationID.String())
+		return nil, errors.Wrapf(err, "cannot find target evaluation with id = %v", bsonTargetEvaluationID.String())
 	}
 
 	if strings.ToLower(sourceEvaluation.Model.Name) != strings.ToLower(targetEvaluation.Model.Name) {
-		println("skipping name")
-		return nil
+		log.WithField("source", bsonSourceEvaluationID.Hex()).
+			WithField("target", bsonTargetEvaluationID.Hex()).
+			Debug("skipping evaluations of different models")
+		return nil, nil
 	}
 
 	if len(targetEvaluation.InputPredictionIDs) == 0 {
-		return errors.Errorf("empty target evaluation with id = %v", bsonTargetEvaluationID.String())
+		return nil, errors.Errorf("empty target evaluation with id = %v", bsonTargetEvaluationID.String())
 	}
 
-	if len(sourceEvaluation.InputPredictionIDs) != len(targetEvaluation.InputPredictionIDs) {
-		return errors.Errorf("input prediction length mismatch %v != %v", len(sourceEvaluation.InputPredictionIDs), len(targetEvaluation.InputPredictionIDs))
+	pending := methods
+	if divergenceReporterName == "database" {
+		pending, err = evaluation.PendingDivergenceMethods(methods, func(method string) (bool, error) {
+			completed, err := workUnitCollection.IsCompleted(bsonSourceEvaluationID, bsonTargetEvaluationID, method)
+			if err != nil {
+				return false, errors.Wrapf(err, "cannot check the %v work unit", method)
+			}
+			if completed {
+				log.WithField("source", bsonSourceEvaluationID.Hex()).
+					WithField("target", bsonTargetEvaluationID.Hex()).
+					WithField("method", method).
+					Debug("skipping completed divergence work unit")
+			}
+			return completed, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil
 	}
 
-	numEvals := len(sourceEvaluation.InputPredictionIDs)
+	job := &divergenceJob{
+		source:  sourceEvaluation,
+		target:  targetEvaluation,
+		methods: pending,
+	}
+	if divergenceDryRun {
+		return job, nil
+	}
 
-	progress := dlcmd.NewProgress("checking prediction divergence step", numEvals)
-	//defer progress.FinishPrint("finished prediction divergence step")
-	defer progress.Finish()
+	alignment, err := inputPredictionCollection.AlignInputPredictions(sourceEvaluation, targetEvaluation)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot pair the source and target input predictions")
+	}
 
-	var wg sync.WaitGroup
-	wg.Add(numEvals)
+	if len(alignment.SourceOnly) != 0 || len(alignment.TargetOnly) != 0 || alignment.NumMissing != 0 {
+		log.WithField("source_only", len(alignment.SourceOnly)).
+			WithField("target_only", len(alignment.TargetOnly)).
+			WithField("missing", alignment.NumMissing).
+			Warn("the source and target evaluations only partially overlap")
+	}
 
-	numCPUs := runtime.NumCPU()
+	if len(alignment.Pairs) == 0 {
+		return nil, errors.Errorf("no common inputs between source evaluation %v and target evaluation %v", bsonSourceEvaluationID.String(), bsonTargetEvaluationID.String())
+	}
 
-	pool, _ := tunny.CreatePool(numCPUs, func(o interface{}) interface{} {
-		defer progress.Increment()
-		defer wg.Done()
-		ii := o.(int)
-		sourcePredictionID := sourceEvaluation.InputPredictionIDs[ii]
-		targetPredictionID := targetEvaluation.InputPredictionIDs[ii]
+	job.alignment = alignment
+	job.values = evaluation.NewDivergenceValues(sourceEvaluation, targetEvaluation, alignment)
+	return job, nil
+}
 
-		var sourcePrediction evaluation.InputPrediction
-		err := inputPredictionCollection.FindOne(sourcePredictionID, &sourcePrediction)
-		if err != nil {
-			//log.WithError(err).Errorf("cannot find source prediction with id = %v", sourcePredictionID)
+func divergenceReporter(
+	reporterName string,
+	values *evaluation.DivergenceValues,
+	divergenceCollection *evaluation.DivergenceCollection,
+) divergenceReport {
+	switch reporterName {
+	case "summary":
+		return func(name string, pair *featurePair, divergence float64, diverged bool) error {
+			values.Add(name, pair.sourceInputID, pair.targetInputID, divergence, diverged)
 			return nil
 		}
-
-		var targetPrediction evaluation.InputPrediction
-		err = inputPredictionCollection.FindOne(targetPredictionID, &targetPrediction)
-		if err != nil {
-			//log.WithError(err).Errorf("cannot find target prediction with id = %v", targetPredictionID)
+	case "print", "Print":
+		return func(name string, pair *featurePair, divergence float64, diverged bool) error {
+			if !diverged {
+				return nil
+			}
+			fmt.Fprintln(os.Stdout, "source_input_id=", pair.sourceInputID, "target_input_id=", pair.targetInputID, name, "divergence=", divergence)
 			return nil
 		}
-
-		sourceFeatures := sourcePrediction.Features
-		targetFeatures := targetPrediction.Features
-
-		reporter := func(name string, pair *featurePair, divergence float64) {}
-		switch reporterName {
-		case "print", "Print":
-			reporter = func(name string, pair *featurePair, divergence float64) {
-				println("source_input_id= ", pair.sourceInputID, "target_input_id= ", pair.targetInputID, name, " divergence=", divergence)
-			}
-		case "database":
-			reporter = func(name string, pair *featurePair, divergence float64) {
-				println("inserting into database")
-				divergenceCollection.Insert(evaluation.Divergence{
-					ID:                           bson.NewObjectId(),
-					CreatedAt:                    time.Now(),
-					Method:                       name,
-					Value:                        divergence,
-					SourcePredictionID:           pair.sourceID,
-					TargetPredictionID:           pair.targetID,
-					SourceInputPredictionInputID: pair.sourceInputID,
-					TargetInputPredictionInputID: pair.targetInputID,
-					SourceFeatures:               pair.sourceFeatures,
-					TargetFeatures:               pair.targetFeatures,
-				})
+	case "database":
+		// every input is recorded, so that the inputs which did not diverge are known to be computed
+		return func(name string, pair *featurePair, divergence float64, diverged bool) error {
+			err := divergenceCollection.Upsert(evaluation.Divergence{
+				ID:                           bson.NewObjectId(),
+				CreatedAt:                    time.Now(),
+				Method:                       name,
+				Value:                        divergence,
+				SourcePredictionID:           pair.sourceID,
+				TargetPredictionID:           pair.targetID,
+				SourceInputPredictionInputID: pair.sourceInputID,
+				TargetInputPredictionInputID: pair.targetInputID,
+				SourceFeatures:               pair.sourceFeatures,
+				TargetFeatures:               pair.targetFeatures,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "failed to upsert the %v divergence", name)
 			}
+			return nil
 		}
+	}
+	return func(name string, pair *featurePair, divergence float64, diverged bool) error { return nil }
+}
 
-		for _, div := range divs {
-			div(
-				&featurePair{
-					sourceID:       sourcePredictionID,
-					targetID:       targetPredictionID,
-					sourceInputID:  sourcePrediction.InputID,
-					targetInputID:  targetPrediction.InputID,
-					sourceFeatures: sourceFeatures,
-					targetFeatures: targetFeatures,
-				},
-				reporter,
-			)
+// doComputeDivergence computes the pending methods of the job on the input prediction pairs using the pool.
+// It returns the number of pairs that failed for each method, either because their input predictions could
+// not be read or because the method or its report failed.
+func doComputeDivergence(
+	pool *tunny.WorkPool,
+	increment func(),
+	inputPredictionCollection *evaluation.InputPredictionCollection,
+	divergenceCollection *evaluation.DivergenceCollection,
+	job *divergenceJob,
+) map[string]int {
+	reporter := divergenceReporter(divergenceReporterName, job.values, divergenceCollection)
+
+	var mu sync.Mutex
+	numFailed := map[string]int{}
+	fail := func(methods ...string) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, method := range methods {
+			numFailed[method]++
 		}
+	}
 
-		return nil
-	}).Open()
-	defer pool.Close()
+	var wg sync.WaitGroup
+	wg.Add(len(job.alignment.Pairs))
 
-	for ii := range sourceEvaluation.InputPredictionIDs {
-		pool.SendWorkAsync(ii, nil)
+	for _, pair := range job.alignment.Pairs {
+		pair := pair
+		pool.SendWorkAsync(func() {
+			defer increment()
+			defer wg.Done()
+
+			var sourcePrediction evaluation.InputPrediction
+			err := inputPredictionCollection.FindOne(pair.SourceID, &sourcePrediction)
+			if err != nil {
+				log.WithError(err).Errorf("cannot find source prediction with id = %v", pair.SourceID.Hex())
+				fail(job.methods...)
+				return
+			}
+
+			var targetPrediction evaluation.InputPrediction
+			err = inputPredictionCollection.FindOne(pair.TargetID, &targetPrediction)
+			if err != nil {
+				log.WithError(err).Errorf("cannot find target prediction with id = %v", pair.TargetID.Hex())
+				fail(job.methods...)
+				return
+			}
+
+			for _, method := range job.methods {
+				err := divergenceDispatch[method](
+					&featurePair{
+						sourceID:       pair.SourceID,
+						targetID:       pair.TargetID,
+						sourceInputID:  sourcePrediction.InputID,
+						targetInputID:  targetPrediction.InputID,
+						sourceFeatures: sourcePrediction.Features,
+						targetFeatures: targetPrediction.Features,
+					},
+					reporter,
+				)
+				if err != nil {
+					log.WithError(err).
+						WithField("source_input_id", sourcePrediction.InputID).
+						WithField("target_input_id", targetPrediction.InputID).
+						Error("failed to compute divergence")
+					fail(method)
+				}
+			}
+		}, nil)
 	}
 	wg.Wait()
-
-	return nil
+	return numFailed
 }
 
-func computeDivergence(c *cobra.Command, args []string, divs ...func(pq *featurePair, reporter func(string, *featurePair, float64))) error {
+func computeDivergence(c *cobra.Command, args []string, methods ...string) error {
 	opts := []database.Option{}
 	if len(databaseEndpoints) != 0 {
 		opts = append(opts, database.Endpoints(databaseEndpoints))
 	}
 	db, err := mongodb.NewDatabase(databaseName, opts...)
+	if err != nil {
+		return err
+	}
 	defer db.Close()
 
 	evaluationCollection, err := evaluation.NewEvaluationCollection(db)
@@ -184,6 +308,11 @@ func computeDivergence(c *cobra.Command, args []string, divs ...func(pq *feature
 		return err
 	}
 
+	workUnitCollection, err := evaluation.NewDivergenceWorkUnitCollection(db)
+	if err != nil {
+		return err
+	}
+
 	sources := []bson.ObjectId{}
 	if sourceEvaluationID == "all" {
 		srcs := []evaluation.Evaluation{}
@@ -212,31 +341,154 @@ func computeDivergence(c *cobra.Command, args []string, divs ...func(pq *feature
 		targets = append(targets, bson.ObjectIdHex(targetEvaluationID))
 	}
 
+	jobs := []*divergenceJob{}
+	numInputs, numWorkUnits := 0, 0
 	for _, src := range sources {
 		for _, trgt := range targets {
 			if src == trgt {
-				println("skipping equal ", src.String(), " == ", trgt.String())
 				continue
 			}
-			println("not equal ", src.String(), " == ", trgt.String())
-			err = doComputeDivergence(
-				db,
+			job, err := planDivergence(
 				evaluationCollection,
 				inputPredictionCollection,
-				divergenceCollection,
+				workUnitCollection,
 				src,
 				trgt,
-				divergenceReporterName,
-				divs...,
+				methods,
 			)
-			log.WithError(err).Error("failed to compute divergence")
+			if err != nil {
+				log.WithError(err).Error("failed to compute divergence")
+				continue
+			}
+			if job == nil {
+				continue
+			}
+			jobs = append(jobs, job)
+			numWorkUnits += len(job.methods)
+			if job.alignment != nil {
+				numInputs += len(job.alignment.Pairs)
+			}
 		}
 	}
 
+	if divergenceDryRun {
+		for _, job := range jobs {
+			fmt.Printf("%s (%s %s) -> %s (%s %s): methods = %s\n",
+				job.source.ID.Hex(), job.source.Framework.Name, job.source.Framework.Version,
+				job.target.ID.Hex(), job.target.Framework.Name, job.target.Framework.Version,
+				strings.Join(job.methods, ","),
+			)
+		}
+		fmt.Printf("%d evaluation pairs and %d work units to compute\n", len(jobs), numWorkUnits)
+		return nil
+	}
+
+	progress := dlcmd.NewProgress("computing prediction divergence", numInputs)
+	pool, _ := tunny.CreatePool(divergenceConcurrency, func(o interface{}) interface{} {
+		o.(func())()
+		return nil
+	}).Open()
+
+	for _, job := range jobs {
+		numFailed := doComputeDivergence(pool, func() { progress.Increment() }, inputPredictionCollection, divergenceCollection, job)
+		for _, method := range job.methods {
+			if numFailed[method] == 0 {
+				continue
+			}
+			log.WithField("source", job.source.ID.Hex()).
+				WithField("target", job.target.ID.Hex()).
+				WithField("method", method).
+				WithField("num_failed", numFailed[method]).
+				Error("failed to compute the divergence of some inputs")
+		}
+		if divergenceReporterName != "database" {
+			continue
+		}
+		units := evaluation.CompletedDivergenceWorkUnits(job.source.ID, job.target.ID, job.methods, len(job.alignment.Pairs), numFailed)
+		for _, unit := range units {
+			err := workUnitCollection.Complete(unit)
+			if err != nil {
+				log.WithError(err).WithField("method", unit.Method).Error("failed to record the divergence work unit")
+			}
+		}
+	}
+	pool.Close()
+	progress.Finish()
+
+	if divergenceReporterName != "summary" {
+		return nil
+	}
+
+	summaries := evaluation.SummaryDivergenceInformations{}
+	inputs := evaluation.SummaryDivergenceInputInformations{}
+	unpaired := evaluation.SummaryDivergenceUnpairedInputInformations{}
+	violations := evaluation.SummaryDivergenceInputInformations{}
+	for _, job := range jobs {
+		violations = append(violations, job.values.DivergedInputs(allCloseNotCloseElements)...)
+		summaries = append(summaries, job.values.Summary(divergenceTollerance, divergenceNumBins)...)
+		inputs = append(inputs, job.values.TopInputs(divergenceTopInputs)...)
+		unpaired = append(unpaired, job.values.UnpairedInputs()...)
+	}
+
+	if barPlot {
+		for _, summary := range summaries {
+			path := divergencePlotPath(summary)
+			err := summary.WriteBarPlot(path)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Created plot in " + path)
+		}
+		return nil
+	}
+
+	if openPlot {
+		for _, summary := range summaries {
+			err := summary.OpenBarPlot()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if allCloseListViolations {
+		writer := NewWriter(evaluation.SummaryDivergenceInputInformation{})
+		defer writer.Close()
+		writer.Rows(violations)
+		return nil
+	}
+
+	if divergenceListUnpaired {
+		writer := NewWriter(evaluation.SummaryDivergenceUnpairedInputInformation{})
+		defer writer.Close()
+		writer.Rows(unpaired)
+		return nil
+	}
+
+	if divergenceListInputs {
+		writer := NewWriter(evaluation.SummaryDivergenceInputInformation{})
+		defer writer.Close()
+		writer.Rows(inputs)
+		return nil
+	}
+
+	writer := NewWriter(evaluation.SummaryDivergenceInformation{})
+	defer writer.Close()
+
+	writer.Rows(summaries)
+
 	return nil
 }
 
-func divergencePreRun(c *cobra.Command, args []string) {
+// divergencePlotPath adds the method and the evaluation ids to the plot path since
+// a histogram is created for each method of each pair of evaluations
+func divergencePlotPath(summary evaluation.SummaryDivergenceInformation) string {
+	ext := filepath.Ext(plotPath)
+	return fmt.Sprintf("%s_%s_%s_%s%s", strings.TrimSuffix(plotPath, ext), summary.Method, summary.ID.Hex(), summary.TargetID, ext)
+}
+
+func divergencePreRun(c *cobra.Command, args []string) error {
 	if databaseName == "" {
 		databaseName = config.App.Name
 	}
@@ -248,86 +500,120 @@ func divergencePreRun(c *cobra.Command, args []string) {
 	if databaseAddress != "" {
 		databaseEndpoints = []string{databaseAddress}
 	}
+
+	if plotPath == "" {
+		plotPath = evaluation.TempFile("", "divergence_plot_*."+plotFormat)
+	}
+
+	if divergenceConcurrency < 1 {
+		divergenceConcurrency = 1
+	}
+
+	if divergenceReporterName != "summary" && (divergenceListInputs || divergenceListUnpaired || allCloseListViolations) {
+		return errors.New("the --list_inputs, --list_unpaired and --list_violations flags require --reporter=summary")
+	}
+	return nil
 }
 
 var (
-	divergenceDispatch = map[string]func(pair *featurePair, reporter func(string, *featurePair, float64)){
-		"Bhattacharyya": func(pair *featurePair, reporter func(string, *featurePair, float64)) {
+	divergenceDispatch = map[string]func(pair *featurePair, reporter divergenceReport) error{
+		"Bhattacharyya": func(pair *featurePair, reporter divergenceReport) error {
 			divergence, err := pair.sourceFeatures.Bhattacharyya(pair.targetFeatures)
 			if err != nil {
-				log.WithError(err).Error("cannot perform Bhattacharyya")
-				return
-			}
-			if math.Abs(divergence) >= divergenceTollerance && divergence != 0 {
-				reporter("Bhattacharyya", pair, divergence)
+				return errors.Wrap(err, "cannot perform Bhattacharyya")
 			}
+			return reportDivergence(reporter, "Bhattacharyya", pair, divergence)
 		},
 
-		"Hellinger": func(pair *featurePair, reporter func(string, *featurePair, float64)) {
+		"Hellinger": func(pair *featurePair, reporter divergenceReport) error {
 			divergence, err := pair.sourceFeatures.Hellinger(pair.targetFeatures)
 			if err != nil {
-				log.WithError(err).Error("cannot perform Hellinger")
-				return
-			}
-			if math.Abs(divergence) >= divergenceTollerance && divergence != 0 {
-				reporter("Hellinger", pair, divergence)
+				return errors.Wrap(err, "cannot perform Hellinger")
 			}
+			return reportDivergence(reporter, "Hellinger", pair, divergence)
 		},
 
-		"Correlation": func(pair *featurePair, reporter func(string, *featurePair, float64)) {
+		"Correlation": func(pair *featurePair, reporter divergenceReport) error {
 			divergence, err := pair.sourceFeatures.Correlation(pair.targetFeatures)
 			if err != nil {
-				log.WithError(err).Error("cannot perform Correlation")
-				return
-			}
-			if math.Abs(divergence) >= divergenceTollerance && divergence != 0 {
-				reporter("Correlation", pair, divergence)
+				return errors.Wrap(err, "cannot perform Correlation")
 			}
+			return reportDivergence(reporter, "Correlation", pair, divergence)
 		},
 
-		"JensenShannon": func(pair *featurePair, reporter func(string, *featurePair, float64)) {
+		"JensenShannon": func(pair *featurePair, reporter divergenceReport) error {
 			divergence, err := pair.sourceFeatures.JensenShannon(pair.targetFeatures)
 			if err != nil {
-				log.WithError(err).Error("cannot perform JensenShannon")
-				return
-			}
-			if math.Abs(divergence) >= divergenceTollerance && divergence != 0 {
-				reporter("JensenShannon", pair, divergence)
+				return errors.Wrap(err, "cannot perform JensenShannon")
 			}
+			return reportDivergence(reporter, "JensenShannon", pair, divergence)
 		},
 
-		"Covariance": func(pair *featurePair, reporter func(string, *featurePair, float64)) {
+		"Covariance": func(pair *featurePair, reporter divergenceReport) error {
 			divergence, err := pair.sourceFeatures.Covariance(pair.targetFeatures)
 			if err != nil {
-				log.WithError(err).Error("cannot perform Covariance")
-				return
+				return errors.Wrap(err, "cannot perform Covariance")
+			}
+			return reportDivergence(reporter, "Covariance", pair, divergence)
+		},
+
+		allCloseMethod: func(pair *featurePair, reporter divergenceReport) error {
+			comparison, err := metrics.CompareFeatureTensors(&pair.sourceFeatures, &pair.targetFeatures, allCloseAbsTolerance, allCloseRelTolerance)
+			if err != nil {
+				return errors.Wrap(err, "cannot perform AllClose")
 			}
-			if math.Abs(divergence) >= divergenceTollerance && divergence != 0 {
-				reporter("Covariance", pair, divergence)
+			// all the values of the input are reported as diverged if an element is not close
+			diverged := !comparison.AllClose()
+			values := []struct {
+				name  string
+				value float64
+			}{
+				{"MaxAbsoluteError", comparison.MaxAbsoluteError},
+				{"MaxRelativeError", comparison.MaxRelativeError},
+				{"MaxULPDistance", float64(comparison.MaxULPDistance)},
+				{"FractionWithinTolerance", comparison.FractionWithinTolerance()},
+				{allCloseNotCloseElements, float64(comparison.NumNotClose)},
+				{"NaNElements", float64(comparison.NumNaN)},
 			}
+			for _, v := range values {
+				if err := reporter(v.name, pair, v.value, diverged); err != nil {
+					return err
+				}
+			}
+			return nil
 		},
 
-		"KullbackLeibler": func(pair *featurePair, reporter func(string, *featurePair, float64)) {
+		"KullbackLeibler": func(pair *featurePair, reporter divergenceReport) error {
 			divergence, err := pair.sourceFeatures.KullbackLeiblerDivergence(pair.targetFeatures)
 			if err != nil {
-				log.WithError(err).Error("cannot perform KullbackLeiblerDivergence")
-				return
-			}
-			if math.Abs(divergence) >= divergenceTollerance && divergence != 0 {
-				reporter("KullbackLeiblerDivergence", pair, divergence)
+				return errors.Wrap(err, "cannot perform KullbackLeiblerDivergence")
 			}
+			return reportDivergence(reporter, "KullbackLeiblerDivergence", pair, divergence)
 		},
 	}
 )
 
+// divergenceMethods returns the distribution divergence methods
+func divergenceMethods() []string {
+	methods := []string{}
+	for method := range divergenceDispatch {
+		if method == allCloseMethod {
+			continue
+		}
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
 var databaseKLDivergenceCmd = &cobra.Command{
 	Use:     "kldivergence",
 	Aliases: []string{"kl", "KullbackLeibler"},
 	Short:   "Perform Kullback-Leibler divergence on two evaluation ids",
 	Long:    `for example : go run mxnet.go database kldivergence --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706`,
-	PreRun:  divergencePreRun,
+	PreRunE: divergencePreRun,
 	RunE: func(c *cobra.Command, args []string) error {
-		return computeDivergence(c, args, divergenceDispatch["KullbackLeibler"])
+		return computeDivergence(c, args, "KullbackLeibler")
 	},
 }
 
@@ -336,9 +622,9 @@ var databaseJSDivergenceCmd = &cobra.Command{
 	Aliases: []string{"js", "JensenShannon"},
 	Short:   "Perform JensenShannon divergence on two evaluation ids",
 	Long:    `for example : go run mxnet.go database jensenshannon --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706`,
-	PreRun:  divergencePreRun,
+	PreRunE: divergencePreRun,
 	RunE: func(c *cobra.Command, args []string) error {
-		return computeDivergence(c, args, divergenceDispatch["JensenShannon"])
+		return computeDivergence(c, args, "JensenShannon")
 	},
 }
 
@@ -347,9 +633,9 @@ var databaseCovDivergenceCmd = &cobra.Command{
 	Aliases: []string{"cov", "Covariance"},
 	Short:   "Perform Covariance divergence on two evaluation ids",
 	Long:    `for example : go run mxnet.go database covariance --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706`,
-	PreRun:  divergencePreRun,
+	PreRunE: divergencePreRun,
 	RunE: func(c *cobra.Command, args []string) error {
-		return computeDivergence(c, args, divergenceDispatch["Covariance"])
+		return computeDivergence(c, args, "Covariance")
 	},
 }
 
@@ -358,9 +644,9 @@ var databaseCorrDivergenceCmd = &cobra.Command{
 	Aliases: []string{"cor", "corr", "Correlation"},
 	Short:   "Perform Correlation divergence on two evaluation ids",
 	Long:    `for example : go run mxnet.go database correlation --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706`,
-	PreRun:  divergencePreRun,
+	PreRunE: divergencePreRun,
 	RunE: func(c *cobra.Command, args []string) error {
-		return computeDivergence(c, args, divergenceDispatch["Correlation"])
+		return computeDivergence(c, args, "Correlation")
 	},
 }
 
@@ -369,9 +655,9 @@ var databaseHellDivergenceCmd = &cobra.Command{
 	Aliases: []string{"hel", "hell", "Hellinger"},
 	Short:   "Perform Correlation divergence on two evaluation ids",
 	Long:    `for example : go run mxnet.go database hellinger --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706`,
-	PreRun:  divergencePreRun,
+	PreRunE: divergencePreRun,
 	RunE: func(c *cobra.Command, args []string) error {
-		return computeDivergence(c, args, divergenceDispatch["Hellinger"])
+		return computeDivergence(c, args, "Hellinger")
 	},
 }
 
@@ -380,23 +666,30 @@ var databaseBhattDivergenceCmd = &cobra.Command{
 	Aliases: []string{"bhat", "bhatt", "Bhattacharyya"},
 	Short:   "Perform Correlation bhattacharyya on two evaluation ids",
 	Long:    `for example : go run mxnet.go database bhattacharyya --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706`,
-	PreRun:  divergencePreRun,
+	PreRunE: divergencePreRun,
 	RunE: func(c *cobra.Command, args []string) error {
-		return computeDivergence(c, args, divergenceDispatch["Bhattacharyya"])
+		return computeDivergence(c, args, "Bhattacharyya")
 	},
 }
 
 var databaseDivergenceCmd = &cobra.Command{
-	Use:    "divergence",
-	Short:  "Perform Kullback-Leibler, JensenShannon, Covariance, Correlation, Hellinger, and Bhattacharyya divergence on two evaluation ids",
-	Long:   `for example : go run mxnet.go database divergence --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706`,
-	PreRun: divergencePreRun,
+	Use:     "divergence",
+	Short:   "Perform Kullback-Leibler, JensenShannon, Covariance, Correlation, Hellinger, and Bhattacharyya divergence on two evaluation ids",
+	Long:    `for example : go run mxnet.go database divergence --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706`,
+	PreRunE: divergencePreRun,
 	RunE: func(c *cobra.Command, args []string) error {
-		return computeDivergence(c, args, func(pair *featurePair, reporter func(string, *featurePair, float64)) {
-			for _, f := range divergenceDispatch {
-				f(pair, reporter)
-			}
-		})
+		return computeDivergence(c, args, divergenceMethods()...)
+	},
+}
+
+var databaseAllCloseCmd = &cobra.Command{
+	Use:     "allclose",
+	Aliases: []string{"numeric", "AllClose"},
+	Short:   "Compare the raw output tensors of two evaluation ids element wise using absolute and relative tolerances (in the spirit of numpy.allclose)",
+	Long:    `for example : go run mxnet.go database allclose --database_address=minsky1-1.csl.illinois.edu --database_name=carml --source=5a01fc48ca60cc797e63603c --target=5a0203f8ca60ccd42aa2a706 --atol=1e-5 --rtol=1e-3 --reporter=summary --list_violations`,
+	PreRunE: divergencePreRun,
+	RunE: func(c *cobra.Command, args []string) error {
+		return computeDivergence(c, args, allCloseMethod)
 	},
 }
 
@@ -408,13 +701,23 @@ var divergenceCmds = []*cobra.Command{
 	databaseHellDivergenceCmd,
 	databaseBhattDivergenceCmd,
 	databaseDivergenceCmd,
+	databaseAllCloseCmd,
 }
 
 func init() {
 	for _, cmd := range divergenceCmds {
 		cmd.PersistentFlags().StringVar(&sourceEvaluationID, "source", "", "source id for the evaluation")
 		cmd.PersistentFlags().StringVar(&targetEvaluationID, "target", "", "target id for the evaluation")
-		cmd.PersistentFlags().Float64Var(&divergenceTollerance, "tollerance", 0.01, "tolerance to use while printing divergence information")
-		cmd.PersistentFlags().StringVar(&divergenceReporterName, "reporter", "print", "method to use to report divergence")
+		cmd.PersistentFlags().Float64Var(&divergenceTollerance, "tollerance", 0.01, "tolerance above which a distribution divergence is printed and counted")
+		cmd.PersistentFlags().StringVar(&divergenceReporterName, "reporter", "print", "method to use to report divergence (summary, print or database)")
+		cmd.PersistentFlags().IntVar(&divergenceTopInputs, "top_inputs", 10, "number of most divergent inputs to list for each method")
+		cmd.PersistentFlags().BoolVar(&divergenceListInputs, "list_inputs", false, "output the most divergent inputs instead of the divergence statistics")
+		cmd.PersistentFlags().BoolVar(&divergenceListUnpaired, "list_unpaired", false, "output the inputs that are only present in one of the evaluations instead of the divergence statistics")
+		cmd.PersistentFlags().IntVar(&divergenceConcurrency, "concurrency", runtime.NumCPU(), "number of input pairs to compare concurrently")
+		cmd.PersistentFlags().BoolVar(&divergenceDryRun, "dry_run", false, "list the evaluation pairs and methods that would be computed without computing them")
+		cmd.PersistentFlags().IntVar(&divergenceNumBins, "num_bins", evaluation.DefaultDivergenceHistogramBins, "number of bins of the divergence histogram")
 	}
+	databaseAllCloseCmd.PersistentFlags().Float64Var(&allCloseAbsTolerance, "atol", metrics.DefaultAbsoluteTolerance, "absolute tolerance of the element wise comparison")
+	databaseAllCloseCmd.PersistentFlags().Float64Var(&allCloseRelTolerance, "rtol", metrics.DefaultRelativeTolerance, "relative tolerance of the element wise comparison")
+	databaseAllCloseCmd.PersistentFlags().BoolVar(&allCloseListViolations, "list_violations", false, "output the inputs with at least one element outside of the tolerance")
 }
@@ -20,5 +20,6 @@ var (
 		gpuKernelCmd,
 		eventflowCmd,
 		accuracyCmd,
+		zooCmd,
 	}
 )
@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"github.com/rai-project/evaluation"
 	"github.com/spf13/cobra"
 )
 
 var (
-	topKernels int
+	topKernels        int
+	kernelMetricsPath string
+	kernelMetrics     evaluation.GPUKernelMetrics
 )
 
 var gpuKernelCmd = &cobra.Command{
@@ -21,6 +24,10 @@ var gpuKernelCmd = &cobra.Command{
 func init() {
 	gpuKernelCmd.PersistentFlags().StringVar(&kernelNameFilterString, "kernel_names", "", "filter out certain kernel (input must be mangled and is comma seperated)")
 	gpuKernelCmd.PersistentFlags().IntVar(&topKernels, "top_kernels", -1, "consider only the top k kernel ranked by duration")
+	gpuKernelCmd.PersistentFlags().MarkDeprecated("top_kernels", "use --top and --sort_by instead")
+	gpuKernelCmd.PersistentFlags().BoolVar(&timelinePlot, "timeline_plot", false, "generates a timeline of the layers and gpu kernels run during one predict step, with a row for each thread and cuda stream")
+	gpuKernelCmd.PersistentFlags().IntVar(&iteration, "iteration", 0, "the index of the predict step shown in the timeline plot")
+	gpuKernelCmd.PersistentFlags().StringVar(&kernelMetricsPath, "kernel_metrics", "", "csv file exported by ncu or nvprof (--csv) to use for the kernel metrics")
 
 	gpuKernelCmd.AddCommand(gpuKernelInfoCmd)
 	gpuKernelCmd.AddCommand(gpuKernelNameAggreInfoCmd)
@@ -33,3 +40,11 @@ func init() {
 	gpuKernelCmd.AddCommand(gpuKernelLayerAggreDramWriteCmd)
 	gpuKernelCmd.AddCommand(gpuKernelLayerAggreAchievedOccupancyCmd)
 }
+
+// loadKernelMetrics reads the --kernel_metrics file, and returns nil if it is not set
+func loadKernelMetrics() (evaluation.GPUKernelMetrics, error) {
+	if kernelMetricsPath == "" {
+		return nil, nil
+	}
+	return evaluation.ReadGPUKernelMetricsFile(kernelMetricsPath)
+}
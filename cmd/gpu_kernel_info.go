@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/rai-project/tracer"
 	"github.com/spf13/cobra"
 )
 
@@ -46,19 +47,17 @@ var gpuKernelInfoCmd = &cobra.Command{
 				return err
 			}
 
-			summary0, err := evals.SummaryGPUKernelLayerInformations(performanceCollection)
+			if timelinePlot {
+				return writeTimelinePlot(evals, tracer.SYSTEM_LIBRARY_TRACE)
+			}
+
+			summary0, err := evals.SummaryGPUKernelLayerInformations(performanceCollection, kernelMetrics)
 			if err != nil {
 				return err
 			}
 
-			if sortOutput || topLayers != -1 {
+			if sortOutput {
 				sort.Sort(summary0)
-				if topLayers != -1 {
-					if topLayers >= len(summary0) {
-						topLayers = len(summary0)
-					}
-					summary0 = summary0[:topLayers]
-				}
 				for ii := range summary0 {
 					kernelInfo := summary0[ii]
 					sort.Sort(kernelInfo)
@@ -43,7 +43,7 @@ var gpuKernelLayerAggreFlopsCmd = &cobra.Command{
 				return err
 			}
 
-			summary0, err := evals.SummaryGPUKernelLayerAggreInformations(performanceCollection)
+			summary0, err := evals.SummaryGPUKernelLayerAggreInformations(performanceCollection, kernelMetrics)
 			if err != nil {
 				return err
 			}
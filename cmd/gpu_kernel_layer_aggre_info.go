@@ -43,23 +43,18 @@ var gpuKernelLayerAggreInfoCmd = &cobra.Command{
 				return err
 			}
 
-			summary0, err := evals.SummaryGPUKernelLayerAggreInformations(performanceCollection)
+			summary0, err := evals.SummaryGPUKernelLayerAggreInformations(performanceCollection, kernelMetrics)
 			if err != nil {
 				return err
 			}
 
+			// the kernels are ranked by duration for the deprecated --top_kernels
 			if sortOutput || topKernels != -1 {
 				sort.Sort(summary0)
-				if topKernels != -1 {
-					if topKernels >= len(summary0) {
-						topKernels = len(summary0)
-					}
-					summary0 = summary0[:topKernels]
-				}
 			}
 
 			if plotAll {
-				plotPath = outputFileName + "_flops.html"
+				plotPath = plotFileName("_flops")
 				summary1 := evaluation.SummaryGPUKernelLayerFlopsInformations(summary0)
 				err := summary1.WriteBarPlot(plotPath)
 				if err != nil {
@@ -67,7 +62,7 @@ var gpuKernelLayerAggreInfoCmd = &cobra.Command{
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_dram_read.html"
+				plotPath = plotFileName("_dram_read")
 				summary2 := evaluation.SummaryGPUKernelLayerDramReadInformations(summary0)
 				err = summary2.WriteBarPlot(plotPath)
 				if err != nil {
@@ -75,7 +70,7 @@ var gpuKernelLayerAggreInfoCmd = &cobra.Command{
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_dram_write.html"
+				plotPath = plotFileName("_dram_write")
 				summary3 := evaluation.SummaryGPUKernelLayerDramWriteInformations(summary0)
 				err = summary3.WriteBarPlot(plotPath)
 				if err != nil {
@@ -83,7 +78,7 @@ var gpuKernelLayerAggreInfoCmd = &cobra.Command{
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_achieved_occupancy.html"
+				plotPath = plotFileName("_achieved_occupancy")
 				summary4 := evaluation.SummaryGPUKernelLayerAchievedOccupancyInformations(summary0)
 				err = summary4.WriteBarPlot(plotPath)
 				if err != nil {
@@ -91,7 +86,7 @@ var gpuKernelLayerAggreInfoCmd = &cobra.Command{
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_gpu_cpu.html"
+				plotPath = plotFileName("_gpu_cpu")
 				summary5 := evaluation.SummaryGPUKernelLayerGPUCPUInformations(summary0)
 				err = summary5.WriteBarPlot(plotPath)
 				if err != nil {
@@ -42,19 +42,14 @@ var gpuKernelNameAggreInfoCmd = &cobra.Command{
 				return err
 			}
 
-			gpuKernelInfos, err := evals.SummaryGPUKernelNameAggreInformations(performanceCollection)
+			gpuKernelInfos, err := evals.SummaryGPUKernelNameAggreInformations(performanceCollection, kernelMetrics)
 			if err != nil {
 				return err
 			}
 
+			// the kernels are ranked by duration for the deprecated --top_kernels
 			if sortOutput || topKernels != -1 {
 				sort.Sort(gpuKernelInfos)
-				if topKernels != -1 {
-					if topKernels >= len(gpuKernelInfos) {
-						topKernels = len(gpuKernelInfos)
-					}
-					gpuKernelInfos = gpuKernelInfos[:topKernels]
-				}
 			}
 
 			var writer *Writer
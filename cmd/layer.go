@@ -5,7 +5,9 @@ import (
 )
 
 var (
-	topLayers int
+	topLayers    int
+	timelinePlot bool
+	iteration    int
 )
 
 var layerCmd = &cobra.Command{
@@ -18,9 +20,13 @@ var layerCmd = &cobra.Command{
 
 func init() {
 	layerCmd.PersistentFlags().IntVar(&topLayers, "top_layers", -1, "consider only the top k layers ranked by duration")
+	layerCmd.PersistentFlags().MarkDeprecated("top_layers", "use --top and --sort_by instead")
+	layerCmd.PersistentFlags().BoolVar(&timelinePlot, "timeline_plot", false, "generates a timeline of the layers run during one predict step, with a row for each thread")
+	layerCmd.PersistentFlags().IntVar(&iteration, "iteration", 0, "the index of the predict step shown in the timeline plot")
 
 	layerCmd.AddCommand(layerInfoCmd)
 	layerCmd.AddCommand(layerLatencyCmd)
+	layerCmd.AddCommand(layerBatchCmd)
 	layerCmd.AddCommand(layerAllocatedMemoryCmd)
 	layerCmd.AddCommand(layerAggreInfoCmd)
 	layerCmd.AddCommand(layerAggreLatencyCmd)
@@ -33,7 +33,7 @@ var layerAggreInfoCmd = &cobra.Command{
 			os.RemoveAll(outputFileName)
 		}
 		if plotPath == "" {
-			plotPath = evaluation.TempFile("", "layer_plot_*.html")
+			plotPath = evaluation.TempFile("", "layer_plot_*."+plotFormat)
 		}
 		return nil
 	},
@@ -56,7 +56,7 @@ var layerAggreInfoCmd = &cobra.Command{
 			}
 
 			if plotAll {
-				plotPath = outputFileName + "_occurence.html"
+				plotPath = plotFileName("_occurence")
 				summary1 := evaluation.SummaryLayerAggreOccurrenceInformations(summary0)
 				err := summary1.WritePiePlot(plotPath)
 				if err != nil {
@@ -64,7 +64,7 @@ var layerAggreInfoCmd = &cobra.Command{
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_latency.html"
+				plotPath = plotFileName("_latency")
 				summary2 := evaluation.SummaryLayerAggreDurationInformations(summary0)
 				err = summary2.WritePiePlot(plotPath)
 				if err != nil {
@@ -72,7 +72,7 @@ var layerAggreInfoCmd = &cobra.Command{
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_allocated_memory.html"
+				plotPath = plotFileName("_allocated_memory")
 				summary3 := evaluation.SummaryLayerAggreAllocatedMemoryInformations(summary0)
 				err = summary3.WritePiePlot(plotPath)
 				if err != nil {
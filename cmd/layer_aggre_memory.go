@@ -29,7 +29,7 @@ var layerAggreMemoryCmd = &cobra.Command{
 			os.RemoveAll(outputFileName)
 		}
 		if plotPath == "" {
-			plotPath = evaluation.TempFile("", "layer_duration_plot_*.html")
+			plotPath = evaluation.TempFile("", "layer_duration_plot_*."+plotFormat)
 		}
 		return nil
 	},
@@ -29,7 +29,7 @@ var layerAggreOcurrenceCmd = &cobra.Command{
 			os.RemoveAll(outputFileName)
 		}
 		if plotPath == "" {
-			plotPath = evaluation.TempFile("", "layer_occurrence_plot_*.html")
+			plotPath = evaluation.TempFile("", "layer_occurrence_plot_*."+plotFormat)
 		}
 		return nil
 	},
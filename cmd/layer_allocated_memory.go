@@ -29,7 +29,7 @@ var layerAllocatedMemoryCmd = &cobra.Command{
 			os.RemoveAll(outputFileName)
 		}
 		if plotPath == "" {
-			plotPath = evaluation.TempFile("", "layer_allocated_memory_plot_*.html")
+			plotPath = evaluation.TempFile("", "layer_allocated_memory_plot_*."+plotFormat)
 		}
 		return nil
 	},
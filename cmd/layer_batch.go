@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rai-project/evaluation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	perSample bool
+)
+
+var layerBatchCmd = &cobra.Command{
+	Use: "batch",
+	Aliases: []string{
+		"batch_size",
+		"batch_sizes",
+	},
+	Short: "Get model layer latency across batch sizes from framework traces in a database",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["layer"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		if plotPath == "" {
+			plotPath = evaluation.TempFile("", "layer_batch_plot_*."+plotFormat)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		run := func() error {
+			evals, err := getEvaluations()
+			if err != nil {
+				return err
+			}
+
+			summary, err := evals.SummaryLayerBatchInformations(performanceCollection)
+			if err != nil {
+				return err
+			}
+
+			var plotter evaluation.HeatMapPlotter = summary
+			var rowers Rowers = summary
+			if perSample {
+				plotter = summary.PerSample()
+				rowers = summary.PerSample()
+			}
+
+			if openPlot {
+				return plotter.OpenHeatMapPlot()
+			}
+
+			if heatMapPlot {
+				err := plotter.WriteHeatMapPlot(plotPath)
+				if err != nil {
+					return err
+				}
+				fmt.Println("Created plot in " + plotPath)
+				return nil
+			}
+
+			writer := NewWriter(rowers)
+			defer writer.Close()
+
+			writer.Rows(rowers)
+			return nil
+		}
+
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	layerBatchCmd.PersistentFlags().BoolVar(&perSample, "per_sample", false, "divide the layer latencies by the batch size")
+}
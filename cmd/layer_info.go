@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/rai-project/evaluation"
+	"github.com/rai-project/tracer"
 	"github.com/spf13/cobra"
 )
 
@@ -32,7 +33,7 @@ var layerInfoCmd = &cobra.Command{
 			os.RemoveAll(outputFileName)
 		}
 		if plotPath == "" {
-			plotPath = evaluation.TempFile("", "layer_plot_*.html")
+			plotPath = evaluation.TempFile("", "layer_plot_*."+plotFormat)
 		}
 		return nil
 	},
@@ -43,13 +44,17 @@ var layerInfoCmd = &cobra.Command{
 				return err
 			}
 
+			if timelinePlot {
+				return writeTimelinePlot(evals, tracer.FRAMEWORK_TRACE)
+			}
+
 			summary0, err := evals.SummaryLayerInformations(performanceCollection)
 			if err != nil {
 				return err
 			}
 
 			if plotAll {
-				plotPath = outputFileName + "_latency_bar.html"
+				plotPath = plotFileName("_latency_bar")
 				summary1 := evaluation.SummaryLayerLatencyInformations(summary0)
 				err := summary1.WriteBarPlot(plotPath)
 				if err != nil {
@@ -57,14 +62,14 @@ var layerInfoCmd = &cobra.Command{
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_latency_box.html"
+				plotPath = plotFileName("_latency_box")
 				err = summary1.WriteBoxPlot(plotPath)
 				if err != nil {
 					return err
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_allocated_memory.html"
+				plotPath = plotFileName("_allocated_memory")
 				summary2 := evaluation.SummaryLayerAllocatedMemoryInformations(summary0)
 				err = summary2.WriteBarPlot(plotPath)
 				if err != nil {
@@ -73,15 +78,6 @@ var layerInfoCmd = &cobra.Command{
 				fmt.Println("Created plot in " + plotPath)
 			}
 
-			if topLayers != -1 {
-				if topLayers != -1 {
-					if topLayers >= len(summary0) {
-						topLayers = len(summary0)
-					}
-					summary0 = summary0[:topLayers]
-				}
-			}
-
 			if listRuns {
 				writer := NewWriter(evaluation.SummaryLayerInformation{})
 				defer writer.Close()
@@ -105,5 +101,4 @@ var layerInfoCmd = &cobra.Command{
 
 func init() {
 	layerInfoCmd.PersistentFlags().BoolVar(&listRuns, "list_runs", false, "list evaluations")
-	layerInfoCmd.PersistentFlags().IntVar(&topLayers, "top_layers", -1, "consider only the top k layers")
 }
@@ -29,7 +29,7 @@ var layerLatencyCmd = &cobra.Command{
 			os.RemoveAll(outputFileName)
 		}
 		if plotPath == "" {
-			plotPath = evaluation.TempFile("", "layer_latency_plot_*.html")
+			plotPath = evaluation.TempFile("", "layer_latency_plot_*."+plotFormat)
 		}
 		return nil
 	},
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/spf13/cobra"
+)
+
+type metricInformation struct {
+	metrics.Metric
+}
+
+func (metricInformation) Header(opts ...writer.Option) []string {
+	return []string{
+		"name",
+		"feature_types",
+		"accumulator",
+		"description",
+	}
+}
+
+func (m metricInformation) Row(opts ...writer.Option) []string {
+	accumulator := "mean"
+	if m.NewAccumulator != nil {
+		accumulator = "dataset"
+	}
+	return []string{
+		m.Name,
+		strings.Join(m.FeatureTypeNames(), ","),
+		accumulator,
+		m.Description,
+	}
+}
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Metrics operations",
+}
+
+var metricsListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List the registered metrics and the feature types they accept",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		writer := NewWriter(metricInformation{})
+		defer writer.Close()
+
+		for _, m := range metrics.RegisteredMetrics() {
+			writer.Row(metricInformation{m})
+		}
+		return nil
+	},
+}
+
+func init() {
+	metricsCmd.AddCommand(metricsListCmd)
+}
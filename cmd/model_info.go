@@ -45,7 +45,7 @@ var modelInfoCmd = &cobra.Command{
 			sort.Sort(summary0)
 
 			if plotAll {
-				plotPath = outputFileName + "_latency.html"
+				plotPath = plotFileName("_latency")
 				summary1 := evaluation.SummaryModelLatencyInformations(summary0)
 				err := summary1.WriteBarPlot(plotPath)
 				if err != nil {
@@ -53,7 +53,7 @@ var modelInfoCmd = &cobra.Command{
 				}
 				fmt.Println("Created plot in " + plotPath)
 
-				plotPath = outputFileName + "_throughtput.html"
+				plotPath = plotFileName("_throughtput")
 				summary2 := evaluation.SummaryModelThroughputInformations(summary0)
 				err = summary2.WriteBarPlot(plotPath)
 				if err != nil {
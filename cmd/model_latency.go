@@ -37,7 +37,12 @@ var modelLatencyCmd = &cobra.Command{
 				return err
 			}
 
-			summary0, err := evals.SummaryModelInformations(performanceCollection)
+			summarize := evals.SummaryModelInformations
+			if linePlot {
+				// each environment is a series of the line plot, so it is summarized separately
+				summarize = evals.SummaryModelSeriesInformations
+			}
+			summary0, err := summarize(performanceCollection)
 			if err != nil {
 				return err
 			}
@@ -46,6 +51,21 @@ var modelLatencyCmd = &cobra.Command{
 
 			summary := evaluation.SummaryModelLatencyInformations(summary0)
 
+			if linePlot {
+				if openPlot {
+					return summary.OpenLinePlot()
+				}
+				if plotPath == "" {
+					plotPath = plotFileName("_latency_line")
+				}
+				err := summary.WriteLinePlot(plotPath)
+				if err != nil {
+					return err
+				}
+				fmt.Println("Created plot in " + plotPath)
+				return nil
+			}
+
 			if openPlot {
 				return summary.OpenBarPlot()
 			}
@@ -66,6 +86,11 @@ var modelLatencyCmd = &cobra.Command{
 			}
 			return nil
 		}
+		if linePlot && modelName == "all" {
+			// the line plot compares all the models in one plot
+			modelName, modelVersion = "", ""
+			return run()
+		}
 		return forallmodels(run)
 	},
 }
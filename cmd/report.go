@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rai-project/database"
+	mongodb "github.com/rai-project/database/mongodb"
+	"github.com/rai-project/evaluation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFormat         string
+	reportTemplatePath   string
+	reportTitle          string
+	reportTopLayers      int
+	reportTopKernels     int
+	reportPrintTemplate  bool
+	reportLayerDatabase  string
+	reportKernelDatabase string
+)
+
+// reportEvaluations finds the selected evaluations in a trace database other than the one opened by rootSetup
+func reportEvaluations(name string) (evaluation.Evaluations, *evaluation.PerformanceCollection, func(), error) {
+	opts := []database.Option{}
+	if len(databaseEndpoints) != 0 {
+		opts = append(opts, database.Endpoints(databaseEndpoints))
+	}
+
+	reportDB, err := mongodb.NewDatabase(name, opts...)
+	if err != nil {
+		return nil, nil, nil, errors.New("cannot connect to the database server")
+	}
+
+	evalCol, err := evaluation.NewEvaluationCollection(reportDB)
+	if err != nil {
+		reportDB.Close()
+		return nil, nil, nil, err
+	}
+	perfCol, err := evaluation.NewPerformanceCollection(reportDB)
+	if err != nil {
+		evalCol.Close()
+		reportDB.Close()
+		return nil, nil, nil, err
+	}
+	closer := func() {
+		perfCol.Close()
+		evalCol.Close()
+		reportDB.Close()
+	}
+
+	evals, err := getEvaluationsFrom(evalCol)
+	if err != nil {
+		closer()
+		return nil, nil, nil, err
+	}
+	return evals, perfCol, closer, nil
+}
+
+func reportModelSection(report *evaluation.Report) error {
+	evals, err := getEvaluations()
+	if err != nil {
+		return err
+	}
+	models, err := evals.SummaryModelInformations(performanceCollection)
+	if err != nil {
+		return err
+	}
+	return report.AddModelInformations(models)
+}
+
+func reportLayerSection(report *evaluation.Report) error {
+	evals, perfCol, closer, err := reportEvaluations(reportLayerDatabase)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	layers, err := evals.SummaryLayerInformations(perfCol)
+	if err != nil {
+		return err
+	}
+	aggre, err := evals.SummaryLayerAggreInformations(perfCol)
+	if err != nil {
+		log.WithError(err).Error("failed to get the layer aggregated information summary")
+	}
+	return report.AddLayerInformations(layers, aggre, reportTopLayers)
+}
+
+func reportGPUKernelSection(report *evaluation.Report) error {
+	evals, perfCol, closer, err := reportEvaluations(reportKernelDatabase)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	layers, err := evals.SummaryGPUKernelLayerAggreInformations(perfCol, kernelMetrics)
+	if err != nil {
+		return err
+	}
+	kernels, err := evals.SummaryGPUKernelNameAggreInformations(perfCol, kernelMetrics)
+	if err != nil {
+		log.WithError(err).Error("failed to get the gpu kernel name aggregated information summary")
+	}
+	return report.AddGPUKernelInformations(layers, kernels, reportTopKernels)
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Generates a markdown or self contained html report combining the model, layer and gpu kernel summaries",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if reportFormat == "" {
+			reportFormat = "md"
+			if ext := strings.TrimPrefix(filepath.Ext(outputFileName), "."); ext == "html" || ext == "htm" {
+				reportFormat = "html"
+			}
+		}
+		if _, err := evaluation.ReportTemplate(reportFormat); err != nil {
+			return err
+		}
+		if reportPrintTemplate {
+			return nil
+		}
+
+		// a database name given on the command line is used for all the sections
+		if reportLayerDatabase == "" {
+			reportLayerDatabase = databaseName
+			if databaseName == "" {
+				reportLayerDatabase = defaultDatabaseName["layer"]
+			}
+		}
+		if reportKernelDatabase == "" {
+			reportKernelDatabase = databaseName
+			if databaseName == "" {
+				reportKernelDatabase = defaultDatabaseName["cuda_kernel"]
+			}
+		}
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["model"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		if modelName == "all" {
+			outputFileExtension = reportFormat
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if reportPrintTemplate {
+			tmpl, err := evaluation.ReportTemplate(reportFormat)
+			if err != nil {
+				return err
+			}
+			fmt.Print(tmpl)
+			return nil
+		}
+
+		tmpl := ""
+		if reportTemplatePath != "" {
+			var err error
+			tmpl, err = evaluation.ReadReportTemplate(reportTemplatePath)
+			if err != nil {
+				return err
+			}
+		}
+
+		run := func() error {
+			title := reportTitle
+			if title == "" {
+				title = strings.TrimSpace(modelName + " " + modelVersion + " Evaluation Report")
+			}
+			report := evaluation.NewReport(title)
+
+			sections := []struct {
+				name string
+				add  func(*evaluation.Report) error
+			}{
+				{"model", reportModelSection},
+				{"layer", reportLayerSection},
+				{"gpu_kernel", reportGPUKernelSection},
+			}
+			for _, section := range sections {
+				if err := section.add(report); err != nil {
+					log.WithError(err).
+						WithField("section", section.name).
+						Error("skipping report section")
+				}
+			}
+
+			var output io.Writer = os.Stdout
+			if outputFileName != "" {
+				path := outputFileName
+				if filepath.Ext(path) == "" {
+					path += "." + reportFormat
+				}
+				os.MkdirAll(filepath.Dir(path), os.ModePerm)
+				f, err := os.Create(path)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				output = f
+				defer fmt.Println("Created report in " + path)
+			}
+			return report.Write(output, reportFormat, tmpl)
+		}
+
+		return forallmodels(run)
+	},
+}
+
+func init() {
+	reportCmd.PersistentFlags().StringVar(&reportFormat, "report_format", "", "the format of the report (md or html). Defaults to html for a .html output file and md otherwise")
+	reportCmd.PersistentFlags().StringVar(&reportTemplatePath, "report_template", "", "the template file overriding the default report template")
+	reportCmd.PersistentFlags().BoolVar(&reportPrintTemplate, "print_template", false, "print the default template of the report format, as a starting point for --report_template")
+	reportCmd.PersistentFlags().StringVar(&reportTitle, "title", "", "the title of the report")
+	reportCmd.PersistentFlags().IntVar(&reportTopLayers, "report_top_layers", evaluation.DefaultReportTopLayers, "the number of layers ranked by duration listed in the report")
+	reportCmd.PersistentFlags().IntVar(&reportTopKernels, "report_top_kernels", evaluation.DefaultReportTopKernels, "the number of gpu kernels ranked by duration listed in the report")
+	reportCmd.PersistentFlags().StringVar(&reportLayerDatabase, "layer_database_name", "", "the framework trace database of the layer section. Defaults to the --database_name or "+defaultFrameworkTraceDatabaseName)
+	reportCmd.PersistentFlags().StringVar(&reportKernelDatabase, "gpu_kernel_database_name", "", "the system library trace database of the gpu kernel section. Defaults to the --database_name or "+defaultSystemLibraryTraceDatabaseName)
+}
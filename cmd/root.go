@@ -15,6 +15,7 @@ import (
 	mongodb "github.com/rai-project/database/mongodb"
 	frameworkCmd "github.com/rai-project/dlframework/framework/cmd"
 	"github.com/rai-project/evaluation"
+	"github.com/rai-project/evaluation/writer"
 	_ "github.com/rai-project/logger/hooks"
 	_ "github.com/rai-project/tracer/all"
 	"github.com/spf13/cobra"
@@ -50,12 +51,32 @@ var (
 
 	sourcePath = sourcepath.MustAbsoluteDir()
 
-	sortOutput bool
-	barPlot    bool
-	boxPlot    bool
-	openPlot   bool
-	plotPath   string
-	plotAll    bool
+	parquetRowGroupSize int64
+	outputColumns       string
+	timeUnit            string
+	bytesUnit           string
+	precision           int
+	sortBy              string
+	topRows             int
+	latexCaption        string
+	latexLabel          string
+	latexGroupBy        string
+	latexBest           string
+	templatePath        string
+	plotFormat          string
+	plotAssets          string
+	plotAssetsDir       string
+	seriesBy            string
+	logScale            bool
+
+	sortOutput  bool
+	barPlot     bool
+	boxPlot     bool
+	linePlot    bool
+	heatMapPlot bool
+	openPlot    bool
+	plotPath    string
+	plotAll     bool
 )
 
 func rootSetup() error {
@@ -106,6 +127,11 @@ func rootSetup() error {
 		return err
 	}
 
+	kernelMetrics, err = loadKernelMetrics()
+	if err != nil {
+		return err
+	}
+
 	if outputFormat == "" && outputFileName != "" {
 		outputFormat = filepath.Ext(outputFileName)
 	}
@@ -128,7 +154,38 @@ var EvaluationCmd = &cobra.Command{
 	Short: "Get various information about the evaluation",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Running " + cmd.Name())
-		return nil
+		if _, err := writer.ParseSortKeys(sortBy); err != nil {
+			return err
+		}
+		if _, err := writer.ParseBestKeys(latexBest); err != nil {
+			return err
+		}
+		if plotFormat != "html" && plotFormat != "svg" && plotFormat != "png" {
+			return errors.New("invalid plot format " + plotFormat + ", expecting html, svg or png")
+		}
+		mode, err := evaluation.ParsePlotAssetMode(plotAssets)
+		if err != nil {
+			return err
+		}
+		evaluation.DefaultPlotAssetMode = mode
+		if plotAssetsDir != "" {
+			evaluation.DefaultAssetDir = plotAssetsDir
+		}
+		seriesKeys, err := evaluation.ParseSeriesKeys(seriesBy)
+		if err != nil {
+			return err
+		}
+		evaluation.DefaultLineSeriesBy = seriesKeys
+		evaluation.DefaultLineLogScale = logScale
+		if writer.NewOptions(writer.Format(outputFormat)).HasFormat("template") {
+			if templatePath == "" {
+				return errors.New("the template format requires a --template file")
+			}
+			if _, err := writer.ReadTemplate(templatePath); err != nil {
+				return err
+			}
+		}
+		return writer.NewOptions(writer.TimeUnit(timeUnit), writer.BytesUnit(bytesUnit)).ValidateUnits()
 	},
 	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
 		safeClose := func(cls ...io.Closer) {
@@ -171,17 +228,38 @@ func init() {
 	EvaluationCmd.PersistentFlags().BoolVar(&appendOutput, "append", false, "append the output")
 	EvaluationCmd.PersistentFlags().StringVarP(&outputFormat, "format", "f", "table", "print format to use")
 	EvaluationCmd.PersistentFlags().BoolVar(&sortOutput, "sort_output", false, "sort output summary information")
+	EvaluationCmd.PersistentFlags().StringVar(&sortBy, "sort_by", "", "comma separated <column>[:asc|desc] keys ordering the output rows, e.g. layer_duration:desc,layer_index")
+	EvaluationCmd.PersistentFlags().IntVar(&topRows, "top", 0, "output only the first n rows once sorted, all the rows are output if not positive")
+	EvaluationCmd.PersistentFlags().StringVar(&outputColumns, "columns", "", "comma separated header keys (the header labels without their units) selecting and ordering the output columns")
+	EvaluationCmd.PersistentFlags().StringVar(&timeUnit, "time_unit", "", "the unit of the durations in the output (ns, us, ms or s)")
+	EvaluationCmd.PersistentFlags().StringVar(&bytesUnit, "bytes_unit", "", "the unit of the byte counts in the output (B, KiB, MiB or GiB)")
+	EvaluationCmd.PersistentFlags().IntVar(&precision, "precision", -1, "the number of decimals of the floating point values in the output, all the decimals are kept if negative")
+	EvaluationCmd.PersistentFlags().StringVar(&templatePath, "template", "", "the text/template file executed over the summaries by the template format")
+	EvaluationCmd.PersistentFlags().StringVar(&latexCaption, "latex_caption", "", "the caption of the latex table, the table is wrapped in a table float if there is a caption or a label")
+	EvaluationCmd.PersistentFlags().StringVar(&latexLabel, "latex_label", "", "the label of the latex table")
+	EvaluationCmd.PersistentFlags().StringVar(&latexGroupBy, "latex_group_by", "", "the column grouping the rows of the latex table, the groups are separated by a midrule")
+	EvaluationCmd.PersistentFlags().StringVar(&latexBest, "latex_best", "", "comma separated <column>[:min|max] keys of the columns whose best value is in bold in the latex table, within each group")
+	EvaluationCmd.PersistentFlags().Int64Var(&parquetRowGroupSize, "parquet_row_group_size", 128, "the size in MB of the row groups of the parquet output")
 
 	EvaluationCmd.AddCommand(AllCmds...)
 	EvaluationCmd.AddCommand(allCmd)
 	EvaluationCmd.AddCommand(databaseCmd)
+	EvaluationCmd.AddCommand(metricsCmd)
+	EvaluationCmd.AddCommand(reportCmd)
 
 	EvaluationCmd.PersistentFlags().BoolVar(&barPlot, "bar_plot", false, "generates a bar plot of the layers")
 	EvaluationCmd.PersistentFlags().BoolVar(&boxPlot, "box_plot", false, "generates a box plot of the layers")
 	EvaluationCmd.PersistentFlags().BoolVar(&piePlot, "pie_plot", false, "generates a pie plot of the layers")
+	EvaluationCmd.PersistentFlags().BoolVar(&linePlot, "line_plot", false, "generates a line plot of the model throughput or latency against the batch size, with a line for each series")
+	EvaluationCmd.PersistentFlags().StringVar(&seriesBy, "series_by", "model", "comma separated keys naming the series of the line plot (model, framework, gpu, host or arch)")
+	EvaluationCmd.PersistentFlags().BoolVar(&logScale, "log_scale", true, "use log scale axes in the line plot")
+	EvaluationCmd.PersistentFlags().BoolVar(&heatMapPlot, "heatmap_plot", false, "generates a heatmap of the layer latencies across the batch sizes")
 	EvaluationCmd.PersistentFlags().BoolVar(&openPlot, "open_plot", false, "opens the plot of the layers")
 	EvaluationCmd.PersistentFlags().StringVar(&plotPath, "plot_path", "", "output file for the layer plot")
 	EvaluationCmd.PersistentFlags().BoolVar(&plotAll, "plot_all", false, "generates all the plots")
+	EvaluationCmd.PersistentFlags().StringVar(&plotFormat, "plot_format", "html", "the format of the generated plots (html, svg or png). The svg and png images are rendered without javascript")
+	EvaluationCmd.PersistentFlags().StringVar(&plotAssets, "plot_assets", "remote", "how the html plots load the echarts assets: remote loads them from the asset host, embed inlines them in the html and copy copies them to an assets directory next to the html")
+	EvaluationCmd.PersistentFlags().StringVar(&plotAssetsDir, "plot_assets_dir", "", "the directory holding a copy of the plot assets of the asset host, which are used instead of the assets vendored in the binary")
 
 	pp.WithLineInfo = true
 }
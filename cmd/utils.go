@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"go/build"
 	"os"
 	"path/filepath"
@@ -13,11 +14,50 @@ import (
 	"github.com/Unknwon/com"
 	framework "github.com/rai-project/dlframework/framework/cmd"
 	"github.com/rai-project/evaluation"
+	"github.com/rai-project/tracer"
 	udb "upper.io/db.v3"
 )
 
+// plotFileName is the path of a plot generated by --plot_all, in the --plot_format
+func plotFileName(suffix string) string {
+	return outputFileName + suffix + "." + plotFormat
+}
+
+// writeTimelinePlot writes or opens the timeline of the predict step at the iteration
+func writeTimelinePlot(evals evaluation.Evaluations, traceLevel tracer.Level) error {
+	summary, err := evals.SummaryTimelineInformations(performanceCollection, traceLevel, iteration)
+	if err != nil {
+		return err
+	}
+	return writeTimelineSummary(summary)
+}
+
+// writeTimelineSummary writes the timeline to the --plot_path, or to a temporary file if it is not set
+func writeTimelineSummary(summary evaluation.SummaryTimelineInformations) error {
+	if openPlot {
+		return summary.OpenTimelinePlot()
+	}
+	if plotPath == "" {
+		plotPath = evaluation.TempFile("", "timeline_plot_*.html")
+	}
+	err := summary.WriteTimelinePlot(plotPath)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Created plot in " + plotPath)
+	return nil
+}
+
 func getEvaluations() (evaluation.Evaluations, error) {
+	return getEvaluationsFrom(evaluationCollection)
+}
+
+func getEvaluationsFrom(evaluationCollection *evaluation.EvaluationCollection) (evaluation.Evaluations, error) {
+	return getModelEvaluationsFrom(evaluationCollection, modelName, modelVersion)
+}
 
+// getModelEvaluationsFrom is getEvaluationsFrom with the model given instead of the --model_name and --model_version flags
+func getModelEvaluationsFrom(evaluationCollection *evaluation.EvaluationCollection, modelName, modelVersion string) (evaluation.Evaluations, error) {
 	filter := udb.Cond{}
 	if modelName != "" {
 		filter["model.name"] = modelName
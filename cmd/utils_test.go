@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rai-project/evaluation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTimelineSummary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "timeline")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(path string) { plotPath = path }(plotPath)
+
+	summary := evaluation.SummaryTimelineInformations{
+		{Row: "thread 1", Kind: "layer", Name: "conv1", Type: "Conv2D", Start: 10, Duration: 5},
+	}
+	summary[0].ModelName = "ResNet50"
+
+	plotPath = filepath.Join(dir, "timeline.html")
+	require.NoError(t, writeTimelineSummary(summary))
+	bts, err := ioutil.ReadFile(plotPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(bts), `"name":"conv1"`)
+
+	// the timeline is only rendered as html
+	plotPath = filepath.Join(dir, "timeline.png")
+	assert.Error(t, writeTimelineSummary(summary))
+
+	plotPath = ""
+	require.NoError(t, writeTimelineSummary(summary))
+	assert.Contains(t, filepath.Base(plotPath), "timeline_plot_")
+	os.Remove(plotPath)
+}
@@ -7,10 +7,14 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Unknwon/com"
 	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
 	"github.com/rai-project/evaluation/writer"
 )
 
@@ -19,17 +23,29 @@ type Writer struct {
 	outputFileNames map[string]string
 	tbl             *tablewriter.Table
 	csv             *csv.Writer
+	latex           *writer.LatexTable
+	template        *writer.Template
 	jsonRows        []interface{}
+	ndjson          *writer.NDJSONEncoder
+	ndjsonFile      *writer.AtomicFile
+	parquet         *writer.ParquetWriter
+	parquetFailed   bool
+	entries         []writerEntry
 	opts            writer.Options
 }
 
-type Rower interface {
+type Headerer interface {
 	Header(...writer.Option) []string
+}
+
+type Rower interface {
+	Headerer
 	Row(...writer.Option) []string
 }
 
+// Rowers are the summaries which are written as several rows, such as the slices of summaries
 type Rowers interface {
-	Rower
+	Headerer
 	Rows(...writer.Option) [][]string
 }
 
@@ -41,8 +57,39 @@ func getOutput(outputFileName string) io.Writer {
 	return output
 }
 
-func NewWriter(rower Rower, opts ...writer.Option) *Writer {
-	baseOpts := []writer.Option{writer.Format(outputFormat)}
+func NewWriter(rower Headerer, opts ...writer.Option) *Writer {
+	baseOpts := []writer.Option{
+		writer.Format(outputFormat),
+		writer.ParquetRowGroupSize(parquetRowGroupSize * 1024 * 1024),
+		writer.TimeUnit(timeUnit),
+		writer.BytesUnit(bytesUnit),
+		writer.Precision(precision),
+	}
+	if outputColumns != "" {
+		baseOpts = append(baseOpts, writer.Columns(strings.Split(outputColumns, ",")))
+	}
+	if sortBy != "" {
+		keys, err := writer.ParseSortKeys(sortBy)
+		if err != nil {
+			log.WithError(err).Error("failed to parse the sort keys")
+		} else {
+			baseOpts = append(baseOpts, writer.SortBy(keys))
+		}
+	}
+	baseOpts = append(baseOpts, writer.Top(outputTop()))
+	if latexBest != "" {
+		keys, err := writer.ParseBestKeys(latexBest)
+		if err != nil {
+			log.WithError(err).Error("failed to parse the latex best columns")
+		} else {
+			baseOpts = append(baseOpts, writer.LatexBest(keys))
+		}
+	}
+	baseOpts = append(baseOpts,
+		writer.LatexCaption(latexCaption),
+		writer.LatexLabel(latexLabel),
+		writer.LatexGroupBy(latexGroupBy),
+	)
 	wr := &Writer{
 		outputs:         make(map[string]io.Writer),
 		outputFileNames: make(map[string]string),
@@ -64,70 +111,292 @@ func NewWriter(rower Rower, opts ...writer.Option) *Writer {
 		wr.outputFileNames["csv"] = outputFileName + ".csv"
 		wr.csv = csv.NewWriter(output)
 	}
+	if wr.hasFormat("latex") {
+		output := getOutput(outputFileName)
+		wr.outputs["latex"] = output
+		wr.outputFileNames["latex"] = outputFileName + ".tex"
+		wr.latex = writer.NewLatexTable(wr.opts)
+	}
+	if wr.hasFormat("template") {
+		tmpl, err := writer.ReadTemplate(templatePath)
+		if err != nil {
+			log.WithError(err).Error("failed to read the output template")
+		} else {
+			output := getOutput(outputFileName)
+			wr.outputs["template"] = output
+			wr.outputFileNames["template"] = outputFileName + "." + writer.TemplateExtension(templatePath)
+			wr.template = tmpl
+		}
+	}
 	if wr.hasFormat("json") {
 		output := getOutput(outputFileName)
 		wr.outputs["json"] = output
 		wr.outputFileNames["json"] = outputFileName + ".json"
 		wr.jsonRows = []interface{}{}
 	}
+	if wr.hasFormat("ndjson") {
+		// rows are streamed as they are produced rather than buffered
+		var output io.Writer = os.Stdout
+		if outputFileName != "" {
+			ndjsonFileName := outputFileName + ".ndjson"
+			f, err := writer.CreateAtomicFile(ndjsonFileName, appendOutput)
+			if err != nil {
+				log.WithError(err).
+					WithField("file", ndjsonFileName).
+					Error("failed to create output file")
+			} else {
+				wr.ndjsonFile = f
+				output = f
+			}
+		}
+		wr.ndjson = writer.NewNDJSONEncoder(output)
+	}
+	if wr.hasFormat("parquet") && appendOutput {
+		log.Warn("parquet files cannot be appended to, the output file is overwritten")
+	}
 	if rower != nil && (!noHeader || appendOutput) {
 		wr.Header(rower)
 	}
 	return wr
 }
 
-func (w *Writer) Header(rower Rower) error {
+// header is the header of the tabular output with the selected columns and converted units
+func (w *Writer) header(rower Headerer) []string {
+	return w.opts.TransformHeader(rower.Header(writer.FromOptions(w.opts)))
+}
+
+// writerEntry is an output row. The rower or the element is the typed value of the row, if any,
+// and the header is the untransformed header of the row.
+type writerEntry struct {
+	rower   Rower
+	element interface{}
+	header  []string
+	row     []string
+}
+
+func (w *Writer) Header(rower Headerer) error {
+	header := rower.Header(writer.FromOptions(w.opts))
+	if missing := w.opts.MissingColumns(header); len(missing) != 0 {
+		log.WithField("columns", strings.Join(missing, ",")).Warn("the selected columns are not in the output")
+	}
+	if missing := w.opts.MissingSortColumns(header); len(missing) != 0 {
+		log.WithField("columns", strings.Join(missing, ",")).Warn("the sort columns are not in the output")
+	}
 	if w.hasFormat("table") {
-		w.tbl.SetHeader(rower.Header(writer.FromOptions(w.opts)))
+		w.tbl.SetHeader(w.header(rower))
 	}
 	if w.hasFormat("csv") {
-		w.csv.Write(rower.Header(writer.FromOptions(w.opts)))
+		w.csv.Write(w.header(rower))
+	}
+	if w.hasFormat("latex") {
+		w.latex.SetHeader(w.header(rower))
+	}
+	if w.template != nil {
+		w.template.SetHeader(w.header(rower))
 	}
 	return nil
 }
 
 func (w *Writer) Row(rower Rower) error {
+	return w.add(writerEntry{
+		rower:  rower,
+		header: rower.Header(writer.FromOptions(w.opts)),
+		row:    rower.Row(writer.FromOptions(w.opts)),
+	})
+}
+
+func (w *Writer) Rows(rower Rowers) error {
+	header := rower.Header(writer.FromOptions(w.opts))
+	rows := rower.Rows(writer.FromOptions(w.opts))
+
+	// the elements are written to parquet rather than the string rows so the columns keep their types
+	var elements []interface{}
+	if v := reflect.ValueOf(rower); v.Kind() == reflect.Slice {
+		elements = make([]interface{}, v.Len())
+		for ii := range elements {
+			elements[ii] = v.Index(ii).Interface()
+		}
+	}
+	paired := elements != nil && len(elements) == len(rows)
+	if w.hasFormat("parquet") && !paired {
+		// the elements do not match the rows, so they are written as is
+		if elements == nil {
+			log.WithField("type", reflect.TypeOf(rower).String()).Error("only slices of summaries can be written as parquet")
+		}
+		if elements != nil && w.opts.SortsRows() {
+			return errors.Errorf("the rows of %v do not match its elements, so they cannot be sorted or truncated as parquet",
+				reflect.TypeOf(rower).String())
+		}
+		for _, elem := range elements {
+			if err := w.parquetRow(elem); err != nil {
+				return err
+			}
+		}
+	}
+
+	for ii, row := range rows {
+		entry := writerEntry{header: header, row: row}
+		if paired {
+			entry.element = elements[ii]
+		}
+		if err := w.add(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add writes the row, or buffers it until the flush if the rows are sorted or truncated
+func (w *Writer) add(entry writerEntry) error {
+	if w.opts.SortsRows() {
+		w.entries = append(w.entries, entry)
+		return nil
+	}
+	return w.write(entry)
+}
+
+func (w *Writer) write(entry writerEntry) error {
+	row := w.opts.TransformRow(entry.header, entry.row)
+
 	if w.hasFormat("table") {
-		w.tbl.Append(rower.Row(writer.FromOptions(w.opts)))
+		w.tbl.Append(row)
 	}
 
 	if w.hasFormat("csv") {
-		w.csv.Write(rower.Row(writer.FromOptions(w.opts)))
+		w.csv.Write(row)
+	}
+
+	if w.hasFormat("latex") {
+		w.latex.Append(row)
+	}
+
+	if w.template != nil {
+		// the templates are executed over the typed summaries rather than the string rows
+		var v interface{} = entry.rower
+		if entry.rower == nil {
+			v = entry.element
+		}
+		if v == nil {
+			v = rowObject(w.opts.TransformHeader(entry.header), row)
+		}
+		w.template.Append(v, row)
 	}
 
 	if w.hasFormat("json") {
-		w.jsonRows = append(w.jsonRows, rower)
+		if entry.rower != nil {
+			w.jsonRows = append(w.jsonRows, entry.rower)
+		} else {
+			w.jsonRows = append(w.jsonRows, row)
+		}
+	}
+
+	if w.hasFormat("ndjson") {
+		// the typed summaries are encoded so the values keep their json types
+		var v interface{} = entry.rower
+		if entry.rower == nil {
+			v = entry.element
+		}
+		if v == nil {
+			v = rowObject(w.opts.TransformHeader(entry.header), row)
+		}
+		if err := w.ndjson.Encode(v); err != nil {
+			log.WithError(err).Error("failed to encode row")
+			return err
+		}
+	}
+
+	if w.hasFormat("parquet") {
+		if entry.rower != nil {
+			return w.parquetRow(entry.rower)
+		}
+		if entry.element != nil {
+			return w.parquetRow(entry.element)
+		}
 	}
 	return nil
 }
 
-func (w *Writer) Rows(rower Rowers) error {
-	if w.hasFormat("table") {
-		for _, r := range rower.Rows(writer.FromOptions(w.opts)) {
-			w.tbl.Append(r)
-		}
+// writeEntries sorts the buffered rows, keeps the top rows and writes them
+func (w *Writer) writeEntries() {
+	entries := w.entries
+	w.entries = nil
+	if len(w.opts.SortBy) != 0 {
+		sort.SliceStable(entries, func(ii, jj int) bool {
+			return w.opts.LessRows(entries[ii].header, entries[ii].row, entries[jj].row)
+		})
 	}
-	if w.hasFormat("csv") {
-		for _, r := range rower.Rows(writer.FromOptions(w.opts)) {
-			w.csv.Write(r)
+	if w.opts.Top > 0 && w.opts.Top < len(entries) {
+		entries = entries[:w.opts.Top]
+	}
+	for _, entry := range entries {
+		if err := w.write(entry); err != nil {
+			return
 		}
 	}
+}
 
-	if w.hasFormat("json") {
-		for _, r := range rower.Rows(writer.FromOptions(w.opts)) {
-			w.jsonRows = append(w.jsonRows, r)
+// parquetRow writes the row with the schema derived from the type of the first row
+func (w *Writer) parquetRow(row interface{}) error {
+	if w.parquetFailed {
+		return nil
+	}
+	if w.parquet == nil {
+		if outputFileName == "" {
+			w.parquetFailed = true
+			log.Error("the parquet format requires an output file name")
+			return nil
 		}
+		parquetFileName := outputFileName + ".parquet"
+		pw, err := writer.NewParquetWriter(parquetFileName, row, w.opts.ParquetRowGroupSize)
+		if err != nil {
+			w.parquetFailed = true
+			log.WithError(err).
+				WithField("file", parquetFileName).
+				Error("failed to create parquet output file")
+			return err
+		}
+		w.parquet = pw
+	}
+	if err := w.parquet.Write(row); err != nil {
+		log.WithError(err).Error("failed to write parquet row")
+		return err
 	}
 	return nil
 }
 
+// rowObject keys the row values by the header labels
+func rowObject(header []string, row []string) map[string]string {
+	res := make(map[string]string, len(row))
+	for ii, v := range row {
+		key := strconv.Itoa(ii)
+		if ii < len(header) {
+			key = header[ii]
+		}
+		res[key] = v
+	}
+	return res
+}
+
 func (w *Writer) Flush() {
+	if len(w.entries) != 0 {
+		w.writeEntries()
+	}
 	if w.hasFormat("table") {
 		w.tbl.Render()
 	}
 	if w.hasFormat("csv") {
 		w.csv.Flush()
 	}
+	if w.hasFormat("latex") {
+		if err := w.latex.Render(w.outputs["latex"]); err != nil {
+			log.WithError(err).Error("failed to render the latex table")
+		}
+	}
+	if w.template != nil {
+		if err := w.template.Execute(w.outputs["template"]); err != nil {
+			log.WithError(err).Error("failed to execute the output template")
+		}
+	}
 	if w.hasFormat("json") {
 		data := []interface{}{}
 		outputFileName := w.outputFileNames["json"]
@@ -159,10 +428,29 @@ func (w *Writer) Flush() {
 
 		w.outputs["json"].Write(b)
 	}
+	if w.hasFormat("ndjson") {
+		if err := w.ndjson.Flush(); err != nil {
+			log.WithError(err).Error("failed to flush rows")
+		}
+	}
 }
 
 func (w *Writer) Close() {
 	w.Flush()
+	if w.ndjsonFile != nil {
+		if err := w.ndjsonFile.Commit(); err != nil {
+			log.WithError(err).
+				WithField("file", w.ndjsonFile.Name()).
+				Error("failed to write output file")
+		}
+		w.ndjsonFile = nil
+	}
+	if w.parquet != nil {
+		if err := w.parquet.Close(); err != nil {
+			log.WithError(err).Error("failed to write parquet output file")
+		}
+		w.parquet = nil
+	}
 	if outputFileName != "" {
 		for format, output := range w.outputs {
 			com.WriteFile(w.outputFileNames[format], output.(*bytes.Buffer).Bytes())
@@ -171,12 +459,17 @@ func (w *Writer) Close() {
 	}
 }
 
-func (w *Writer) hasFormat(name string) bool {
-	name = strings.ToLower(name)
-	for _, f := range w.opts.Formats {
-		if f == name {
-			return true
+// outputTop is the number of rows kept by the writer. The --top_layers and --top_kernels flags
+// are deprecated aliases of --top.
+func outputTop() int {
+	for _, n := range []int{topRows, topLayers, topKernels} {
+		if n > 0 {
+			return n
 		}
 	}
-	return false
+	return 0
+}
+
+func (w *Writer) hasFormat(name string) bool {
+	return w.opts.HasFormat(name)
 }
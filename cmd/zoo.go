@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/rai-project/database"
+	mongodb "github.com/rai-project/database/mongodb"
+	framework "github.com/rai-project/dlframework/framework/cmd"
+	"github.com/rai-project/evaluation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	accuracyDatabaseName  string
+	paretoObjective       string
+	paretoAccuracyMetric  string
+	paretoPerHardware     bool
+	paretoScatterPlot     bool
+	accuracyDB            database.Database
+	accuracyEvaluationCol *evaluation.EvaluationCollection
+	accuracyModelCol      *evaluation.ModelAccuracyCollection
+)
+
+var zooCmd = &cobra.Command{
+	Use:   "zoo",
+	Short: "Get model zoo wide analysis by combining the accuracy and performance databases",
+}
+
+// accuracySetup connects to the accuracy database, which is separate from the database
+// containing the model traces
+func accuracySetup() error {
+	opts := []database.Option{}
+	if len(databaseEndpoints) != 0 {
+		opts = append(opts, database.Endpoints(databaseEndpoints))
+	}
+
+	var err error
+	accuracyDB, err = mongodb.NewDatabase(accuracyDatabaseName, opts...)
+	if err != nil {
+		return errors.New("cannot connect to the accuracy database server")
+	}
+
+	accuracyEvaluationCol, err = evaluation.NewEvaluationCollection(accuracyDB)
+	if err != nil {
+		return err
+	}
+
+	accuracyModelCol, err = evaluation.NewModelAccuracyCollection(accuracyDB)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func paretoInformationSummary(modelName, modelVersion string) (evaluation.SummaryParetoInformations, error) {
+	evals, err := getModelEvaluationsFrom(evaluationCollection, modelName, modelVersion)
+	if err != nil {
+		return nil, err
+	}
+	models, err := evals.SummaryModelInformations(performanceCollection)
+	if err != nil {
+		return nil, err
+	}
+
+	accEvals, err := getModelEvaluationsFrom(accuracyEvaluationCol, modelName, modelVersion)
+	if err != nil {
+		return nil, err
+	}
+	accs, err := accEvals.PredictAccuracyInformationSummary(accuracyModelCol)
+	if err != nil {
+		return nil, err
+	}
+	accs, err = accs.Group()
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluation.JoinParetoInformations(models, accs, paretoAccuracyMetric), nil
+}
+
+var zooParetoCmd = &cobra.Command{
+	Use:     "pareto",
+	Aliases: []string{"pareto_frontier"},
+	Short:   "Get the accuracy versus latency or throughput pareto frontier of the models",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if paretoObjective != string(evaluation.ParetoLatency) && paretoObjective != string(evaluation.ParetoThroughput) {
+			return errors.New("the objective must be either latency or throughput")
+		}
+		if !cmd.Flags().Changed("model_name") {
+			modelName = "all"
+		}
+		if databaseName == "" {
+			databaseName = defaultDatabaseName["model"]
+		}
+		err := rootSetup()
+		if err != nil {
+			return err
+		}
+		err = accuracySetup()
+		if err != nil {
+			return err
+		}
+		if overwrite && isExists(outputFileName) {
+			os.RemoveAll(outputFileName)
+		}
+		if plotPath == "" {
+			plotPath = evaluation.TempFile("", "pareto_plot_*.html")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		defer func() {
+			if accuracyEvaluationCol != nil {
+				accuracyEvaluationCol.Close()
+			}
+			if accuracyModelCol != nil {
+				accuracyModelCol.Close()
+			}
+			if accuracyDB != nil {
+				accuracyDB.Close()
+			}
+		}()
+
+		summary := evaluation.SummaryParetoInformations{}
+		if modelName == "all" {
+			for _, model := range framework.DefaultEvaulationModels {
+				name, version := framework.ParseModelName(model)
+				s, err := paretoInformationSummary(name, version)
+				if err != nil {
+					log.WithError(err).WithField("model", model).Debug("skipping model")
+					continue
+				}
+				summary = append(summary, s...)
+			}
+		} else {
+			s, err := paretoInformationSummary(modelName, modelVersion)
+			if err != nil {
+				return err
+			}
+			summary = s
+		}
+		if len(summary) == 0 {
+			return errors.New("no model has both accuracy and performance information")
+		}
+
+		objective := evaluation.ParetoObjective(paretoObjective)
+		summary = summary.ParetoFrontier(objective, paretoPerHardware)
+		sort.Sort(summary)
+
+		if paretoScatterPlot || openPlot {
+			plotter := summary.ScatterPlotter(objective)
+			if openPlot {
+				return plotter.OpenScatterPlot()
+			}
+			err := plotter.WriteScatterPlot(plotPath)
+			if err != nil {
+				return err
+			}
+			fmt.Println("Created plot in " + plotPath)
+		}
+
+		writer := NewWriter(evaluation.SummaryParetoInformation{})
+		defer writer.Close()
+
+		writer.Rows(summary)
+
+		return nil
+	},
+}
+
+func init() {
+	zooParetoCmd.PersistentFlags().StringVar(&accuracyDatabaseName, "accuracy_database_name", defaultAccuracyDatabaseName, "the name of the database containing the model accuracies")
+	zooParetoCmd.PersistentFlags().StringVar(&paretoObjective, "objective", string(evaluation.ParetoLatency), "the performance objective traded off against the accuracy (latency or throughput)")
+	zooParetoCmd.PersistentFlags().StringVar(&paretoAccuracyMetric, "accuracy_metric", "top1", "the accuracy metric (top1 or top5)")
+	zooParetoCmd.PersistentFlags().BoolVar(&paretoPerHardware, "per_hardware", true, "compute the pareto frontier separately for each hardware and batch size")
+	zooParetoCmd.PersistentFlags().BoolVar(&paretoScatterPlot, "scatter_plot", false, "generates a scatter plot of the accuracy against the objective with the pareto frontier highlighted")
+
+	zooCmd.AddCommand(zooParetoCmd)
+}
@@ -7,6 +7,8 @@ const (
 var (
 	DefaultShowTitle          = true
 	DefaultAssetHost          = `https://s3.amazonaws.com/store.carml.org/model_analysis_2019/assets/`
+	DefaultAssetDir           = ""
+	DefaultPlotAssetMode      = PlotAssetsRemote
 	DefaultTitleFontSize      = 18
 	DefaultSeriesFontSize     = 14
 	DefaultLegendFontSize     = 14
@@ -18,4 +20,13 @@ var (
 	DefaultBoxPlotHeight      = int(float64(DefaultBoxPlotWidth) / DefaultBoxPlotAspectRatio)
 	DefaultPiePlotWidth       = 900
 	DefaultPiePlotHeight      = 500
+	DefaultHeatMapPlotWidth   = 900
+	DefaultHeatMapPlotHeight  = 900
+	DefaultTimelinePlotWidth  = 1200
+	// the height of the timeline plot grows with its rows
+	DefaultTimelinePlotHeight    = 200
+	DefaultTimelinePlotRowHeight = 40
+	DefaultRooflineSamples       = 64
+	DefaultLineSeriesBy          = []string{"model"}
+	DefaultLineLogScale          = true
 )
@@ -1,12 +1,15 @@
 package evaluation
 
 import (
+	"errors"
 	"time"
 
 	"github.com/rai-project/database"
 	"github.com/rai-project/database/mongodb"
 	"github.com/rai-project/dlframework"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
+	db "upper.io/db.v3"
 )
 
 //easybson:json
@@ -43,6 +46,158 @@ func NewDivergenceCollection(db database.Database) (*DivergenceCollection, error
 	}, nil
 }
 
+// divergenceUpsert returns the selector and the update of the divergence. The divergence is keyed
+// by the source and target predictions, the input and the method, and its id is only set when
+// it is inserted so that recomputing a divergence keeps its id.
+func divergenceUpsert(d Divergence) (bson.M, bson.M, error) {
+	if d.ID == "" {
+		d.ID = bson.NewObjectId()
+	}
+	buf, err := bson.Marshal(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := bson.M{}
+	if err := bson.Unmarshal(buf, &fields); err != nil {
+		return nil, nil, err
+	}
+	delete(fields, "id")
+	selector := bson.M{
+		"source_prediction_id":             d.SourcePredictionID,
+		"target_prediction_id":             d.TargetPredictionID,
+		"source_input_prediction_input_id": d.SourceInputPredictionInputID,
+		"method":                           d.Method,
+	}
+	update := bson.M{
+		"$set":         fields,
+		"$setOnInsert": bson.M{"id": d.ID},
+	}
+	return selector, update, nil
+}
+
+// Upsert inserts the divergence or updates the divergence previously computed
+// with the same method for the same source and target predictions of the input
+func (m *DivergenceCollection) Upsert(d Divergence) error {
+	session, ok := m.Session.Driver().(*mgo.Session)
+	if !ok {
+		return errors.New("expecting a mongo database session")
+	}
+	selector, update, err := divergenceUpsert(d)
+	if err != nil {
+		return err
+	}
+	_, err = session.DB(m.Session.Name()).C(m.Name()).Upsert(selector, update)
+	return err
+}
+
 func (m *DivergenceCollection) Close() error {
 	return nil
 }
+
+//easybson:json
+type DivergenceWorkUnit struct {
+	ID                 bson.ObjectId `bson:"_id,omitempty" json:"id,omitempty"`
+	CreatedAt          time.Time     `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	SourceEvaluationID bson.ObjectId `bson:"source_evaluation_id,omitempty" json:"source_evaluation_id,omitempty"`
+	TargetEvaluationID bson.ObjectId `bson:"target_evaluation_id,omitempty" json:"target_evaluation_id,omitempty"`
+	Method             string        `bson:"method,omitempty" json:"method,omitempty"`
+	NumInputs          int           `bson:"num_inputs,omitempty" json:"num_inputs,omitempty"`
+}
+
+func (DivergenceWorkUnit) TableName() string {
+	return "divergence_work_unit"
+}
+
+type DivergenceWorkUnitCollection struct {
+	*mongodb.MongoTable
+}
+
+func NewDivergenceWorkUnitCollection(db database.Database) (*DivergenceWorkUnitCollection, error) {
+	tbl, err := mongodb.NewTable(db, DivergenceWorkUnit{}.TableName())
+	if err != nil {
+		return nil, err
+	}
+	tbl.Create(nil)
+
+	return &DivergenceWorkUnitCollection{
+		MongoTable: tbl.(*mongodb.MongoTable),
+	}, nil
+}
+
+// PendingDivergenceMethods returns the methods which have not been completed
+func PendingDivergenceMethods(methods []string, isCompleted func(method string) (bool, error)) ([]string, error) {
+	pending := []string{}
+	for _, method := range methods {
+		completed, err := isCompleted(method)
+		if err != nil {
+			return nil, err
+		}
+		if completed {
+			continue
+		}
+		pending = append(pending, method)
+	}
+	return pending, nil
+}
+
+// CompletedDivergenceWorkUnits returns the work units of the methods computed between the two evaluations.
+// The work unit of a method is not completed if some of its inputs failed, so that it is computed again
+// on the next run.
+func CompletedDivergenceWorkUnits(source, target bson.ObjectId, methods []string, numInputs int, numFailed map[string]int) []DivergenceWorkUnit {
+	res := []DivergenceWorkUnit{}
+	for _, method := range methods {
+		if numFailed[method] != 0 {
+			continue
+		}
+		res = append(res, DivergenceWorkUnit{
+			SourceEvaluationID: source,
+			TargetEvaluationID: target,
+			Method:             method,
+			NumInputs:          numInputs,
+		})
+	}
+	return res
+}
+
+// IsCompleted returns true if the divergence method has been computed between the two evaluations
+func (m *DivergenceWorkUnitCollection) IsCompleted(source, target bson.ObjectId, method string) (bool, error) {
+	collection := m.Session.Collection(m.Name())
+	cnt, err := collection.Find(db.Cond{
+		"source_evaluation_id": source,
+		"target_evaluation_id": target,
+		"method":               method,
+	}).Count()
+	if err != nil {
+		return false, err
+	}
+	return cnt != 0, nil
+}
+
+// Complete records the work unit, a work unit that was already completed is replaced
+func (m *DivergenceWorkUnitCollection) Complete(unit DivergenceWorkUnit) error {
+	session, ok := m.Session.Driver().(*mgo.Session)
+	if !ok {
+		return errors.New("expecting a mongo database session")
+	}
+	if unit.CreatedAt.IsZero() {
+		unit.CreatedAt = time.Now()
+	}
+	_, err := session.DB(m.Session.Name()).C(m.Name()).Upsert(
+		bson.M{
+			"source_evaluation_id": unit.SourceEvaluationID,
+			"target_evaluation_id": unit.TargetEvaluationID,
+			"method":               unit.Method,
+		},
+		bson.M{
+			"$set": bson.M{
+				"created_at": unit.CreatedAt,
+				"num_inputs": unit.NumInputs,
+			},
+		},
+	)
+	return err
+}
+
+func (m *DivergenceWorkUnitCollection) Close() error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package evaluation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestPendingDivergenceMethods(t *testing.T) {
+	completed := map[string]bool{"Hellinger": true}
+	isCompleted := func(method string) (bool, error) {
+		return completed[method], nil
+	}
+
+	pending, err := PendingDivergenceMethods([]string{"Bhattacharyya", "Hellinger", "JensenShannon"}, isCompleted)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bhattacharyya", "JensenShannon"}, pending)
+
+	pending, err = PendingDivergenceMethods([]string{"Hellinger"}, isCompleted)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = PendingDivergenceMethods([]string{"Hellinger"}, func(string) (bool, error) {
+		return false, errors.New("connection refused")
+	})
+	assert.Error(t, err)
+}
+
+func TestCompletedDivergenceWorkUnits(t *testing.T) {
+	source, target := bson.NewObjectId(), bson.NewObjectId()
+	methods := []string{"Hellinger", "JensenShannon"}
+
+	units := CompletedDivergenceWorkUnits(source, target, methods, 10, nil)
+	require.Len(t, units, 2)
+	for ii, unit := range units {
+		assert.Equal(t, source, unit.SourceEvaluationID)
+		assert.Equal(t, target, unit.TargetEvaluationID)
+		assert.Equal(t, methods[ii], unit.Method)
+		assert.Equal(t, 10, unit.NumInputs)
+	}
+
+	// only the methods without failed inputs are completed
+	units = CompletedDivergenceWorkUnits(source, target, methods, 10, map[string]int{"Hellinger": 1})
+	require.Len(t, units, 1)
+	assert.Equal(t, "JensenShannon", units[0].Method)
+	assert.Empty(t, CompletedDivergenceWorkUnits(source, target, methods, 10, map[string]int{"Hellinger": 1, "JensenShannon": 2}))
+}
+
+func TestDivergenceUpsert(t *testing.T) {
+	d := Divergence{
+		ID:                           bson.NewObjectId(),
+		Method:                       "Hellinger",
+		Value:                        0.5,
+		SourcePredictionID:           bson.NewObjectId(),
+		TargetPredictionID:           bson.NewObjectId(),
+		SourceInputPredictionInputID: "input.jpg",
+		TargetInputPredictionInputID: "input.jpg",
+	}
+
+	selector, update, err := divergenceUpsert(d)
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{
+		"source_prediction_id":             d.SourcePredictionID,
+		"target_prediction_id":             d.TargetPredictionID,
+		"source_input_prediction_input_id": "input.jpg",
+		"method":                           "Hellinger",
+	}, selector)
+
+	assert.Equal(t, bson.M{"id": d.ID}, update["$setOnInsert"])
+	set, ok := update["$set"].(bson.M)
+	require.True(t, ok)
+	assert.NotContains(t, set, "id")
+	assert.Equal(t, 0.5, set["value"])
+	assert.Equal(t, "Hellinger", set["method"])
+
+	// a divergence without an id gets one on insert
+	d.ID = ""
+	_, update, err = divergenceUpsert(d)
+	require.NoError(t, err)
+	assert.True(t, update["$setOnInsert"].(bson.M)["id"].(bson.ObjectId).Valid())
+}
@@ -2,7 +2,9 @@ package evaluation
 
 import (
 	"errors"
+	"strings"
 
+	"github.com/spf13/cast"
 	model "github.com/uber/jaeger/model/json"
 	"upper.io/db.v3"
 )
@@ -29,6 +31,39 @@ func (es Evaluations) GetSpansFromPerformanceCollection(perfCol *PerformanceColl
 	return spans, nil
 }
 
+// GroupByEnvironmentAndBatchSize groups the evaluations of the same model, framework, machine and
+// batch size, in the order of their first evaluation, so that a selection of evaluations across
+// models, frameworks or systems is summarized separately for each of them
+func (es Evaluations) GroupByEnvironmentAndBatchSize() []Evaluations {
+	ret := []Evaluations{}
+	groups := make(map[string]int)
+	for _, e := range es {
+		gpuName := ""
+		if e.GPUInformation != nil {
+			gpuName = e.GPUInformation.ProductName
+		}
+		key := strings.Join([]string{
+			e.Model.Name,
+			e.Model.Version,
+			e.Framework.Name,
+			e.Framework.Version,
+			e.Hostname,
+			e.MachineArchitecture,
+			cast.ToString(e.UsingGPU),
+			gpuName,
+			cast.ToString(e.BatchSize),
+		}, "/")
+		idx, ok := groups[key]
+		if !ok {
+			idx = len(ret)
+			groups[key] = idx
+			ret = append(ret, Evaluations{})
+		}
+		ret[idx] = append(ret[idx], e)
+	}
+	return ret
+}
+
 func (es Evaluations) GroupByBatchSize() map[int]Evaluations {
 	ret := make(map[int]Evaluations)
 	for _, e := range es {
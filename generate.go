@@ -1,4 +1,5 @@
 //go:generate go get -v github.com/mailru/easyjson/...
 //go:generate easyjson -snake_case -disallow_unknown_fields -pkg .
+//go:generate go run plotter_assets_gen.go
 
 package evaluation
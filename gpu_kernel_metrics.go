@@ -0,0 +1,331 @@
+package evaluation
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// GPUKernelMetrics holds the metrics imported from an external profiler run
+// (ncu or nvprof with --csv). The map is keyed by the kernel name as reported
+// by the profiler and every entry is the list of per launch metrics in launch order.
+// The metadata uses the same keys as the CUPTI span logs (flop_count_sp, dram_read_bytes,
+// dram_write_bytes and achieved_occupancy).
+type GPUKernelMetrics map[string][]Metadata
+
+// profiler metric names which map directly onto a log key
+var gpuKernelMetricNames = map[string]string{
+	"flop_count_sp":         "flop_count_sp",
+	"dram_read_bytes":       "dram_read_bytes",
+	"dram_write_bytes":      "dram_write_bytes",
+	"achieved_occupancy":    "achieved_occupancy",
+	"dram__bytes_read.sum":  "dram_read_bytes",
+	"dram__bytes_write.sum": "dram_write_bytes",
+	"sm__warps_active.avg.pct_of_peak_sustained_active": "achieved_occupancy",
+}
+
+// nsight compute does not have a flop_count_sp metric, the flops are computed from the
+// instruction counts (a fused multiply add counts as two flops)
+var gpuKernelFlopMetricWeights = map[string]float64{
+	"smsp__sass_thread_inst_executed_op_fadd_pred_on.sum": 1,
+	"smsp__sass_thread_inst_executed_op_fmul_pred_on.sum": 1,
+	"smsp__sass_thread_inst_executed_op_ffma_pred_on.sum": 2,
+}
+
+var gpuKernelMetricUnits = map[string]float64{
+	"":        1,
+	"byte":    1,
+	"b":       1,
+	"kbyte":   1e3,
+	"kb":      1e3,
+	"mbyte":   1e6,
+	"mb":      1e6,
+	"gbyte":   1e9,
+	"gb":      1e9,
+	"kib":     1 << 10,
+	"mib":     1 << 20,
+	"gib":     1 << 30,
+	"%":       0.01,
+	"percent": 0.01,
+}
+
+// ReadGPUKernelMetricsFile reads a ncu or nvprof csv export from the file path
+func ReadGPUKernelMetricsFile(path string) (GPUKernelMetrics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open %s", path)
+	}
+	defer f.Close()
+	return ReadGPUKernelMetrics(f)
+}
+
+// ReadGPUKernelMetrics reads a csv export produced by either `ncu --csv` (one row per metric),
+// `nvprof --csv --print-gpu-trace --metrics` (one row per launch with a column per metric)
+// or `nvprof --csv --metrics` (one row per metric summarized over all the invocations).
+func ReadGPUKernelMetrics(r io.Reader) (GPUKernelMetrics, error) {
+	// the profilers prefix their own messages with == (e.g. ==PROF== or ==1234==)
+	buf := new(bytes.Buffer)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "==") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to read the gpu kernel metrics")
+	}
+
+	reader := csv.NewReader(buf)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the gpu kernel metrics csv")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no gpu kernel metrics found in the csv")
+	}
+
+	header := map[string]int{}
+	for ii, name := range records[0] {
+		header[strings.TrimSpace(name)] = ii
+	}
+	column := func(names ...string) int {
+		for _, name := range names {
+			if idx, ok := header[name]; ok {
+				return idx
+			}
+		}
+		return -1
+	}
+
+	kernelIdx := column("Kernel Name", "Kernel", "Name")
+	if kernelIdx == -1 {
+		return nil, errors.New("unable to find the kernel name column in the gpu kernel metrics csv")
+	}
+
+	metricNameIdx := column("Metric Name")
+	if metricNameIdx == -1 {
+		return readWideGPUKernelMetrics(records, kernelIdx), nil
+	}
+
+	launchIdx := column("ID")
+	valueIdx := column("Metric Value", "Avg")
+	unitIdx := column("Metric Unit")
+	if valueIdx == -1 {
+		return nil, errors.New("unable to find the metric value column in the gpu kernel metrics csv")
+	}
+
+	metrics := GPUKernelMetrics{}
+	current := map[string]Metadata{}
+	order := []string{}
+	for ii, record := range records[1:] {
+		if len(record) <= kernelIdx || len(record) <= metricNameIdx || len(record) <= valueIdx {
+			continue
+		}
+		kernelName := strings.TrimSpace(record[kernelIdx])
+		if kernelName == "" {
+			continue
+		}
+		launch := cast.ToString(ii)
+		if launchIdx != -1 && len(record) > launchIdx {
+			launch = record[launchIdx]
+		}
+		key := kernelName + "/" + launch
+		if launchIdx == -1 {
+			// nvprof summaries have one row per (kernel, metric)
+			key = kernelName
+		}
+		md, ok := current[key]
+		if !ok {
+			md = Metadata{}
+			current[key] = md
+			order = append(order, key)
+		}
+		unit := ""
+		if unitIdx != -1 && len(record) > unitIdx {
+			unit = record[unitIdx]
+		}
+		addGPUKernelMetric(md, strings.TrimSpace(record[metricNameIdx]), record[valueIdx], unit)
+		md["kernel_name"] = kernelName
+	}
+
+	for _, key := range order {
+		md := current[key]
+		kernelName := cast.ToString(md["kernel_name"])
+		delete(md, "kernel_name")
+		if len(md) == 0 {
+			continue
+		}
+		metrics[kernelName] = append(metrics[kernelName], md)
+	}
+
+	return metrics, nil
+}
+
+func readWideGPUKernelMetrics(records [][]string, kernelIdx int) GPUKernelMetrics {
+	metrics := GPUKernelMetrics{}
+	header := records[0]
+	for _, record := range records[1:] {
+		if len(record) <= kernelIdx {
+			continue
+		}
+		kernelName := strings.TrimSpace(record[kernelIdx])
+		if kernelName == "" {
+			// the units row
+			continue
+		}
+		md := Metadata{}
+		for ii, name := range header {
+			if ii == kernelIdx || ii >= len(record) {
+				continue
+			}
+			addGPUKernelMetric(md, strings.TrimSpace(name), record[ii], "")
+		}
+		if len(md) == 0 {
+			continue
+		}
+		metrics[kernelName] = append(metrics[kernelName], md)
+	}
+	return metrics
+}
+
+func addGPUKernelMetric(md Metadata, name, value, unit string) {
+	val, err := cast.ToFloat64E(strings.Replace(strings.TrimSpace(value), ",", "", -1))
+	if err != nil {
+		return
+	}
+	scale, ok := gpuKernelMetricUnits[strings.ToLower(strings.TrimSpace(unit))]
+	if !ok {
+		scale = 1
+	}
+	val = val * scale
+
+	if weight, ok := gpuKernelFlopMetricWeights[name]; ok {
+		md["flop_count_sp"] = cast.ToFloat64(md["flop_count_sp"]) + weight*val
+		return
+	}
+	key, ok := gpuKernelMetricNames[name]
+	if !ok {
+		return
+	}
+	if key == "achieved_occupancy" && strings.HasPrefix(name, "sm__") && unit == "" {
+		// nsight compute reports the occupancy as a percentage
+		val = val / 100
+	}
+	md[key] = val
+}
+
+// gpuKernelMetricsNameKeys returns the names a profiler might use for a kernel.
+// nvprof and ncu print either the mangled or the demangled name and ncu can also
+// print only the function name without the template and argument list
+func gpuKernelMetricsNameKeys(info SummaryGPUKernelInformation) []string {
+	keys := []string{info.MangledName, info.Name}
+	name := info.Name
+	if idx := strings.Index(name, "("); idx != -1 {
+		name = name[:idx]
+	}
+	name = strings.TrimPrefix(name, "void ")
+	keys = append(keys, name)
+	if idx := strings.Index(name, "<"); idx != -1 {
+		keys = append(keys, name[:idx])
+	}
+	return keys
+}
+
+// ImportGPUKernelMetrics returns a copy of the summaries where the logs of the matching kernels
+// have their imported metrics replaced by the profiler ones. The CUPTI values of the metrics which
+// the profiler did not capture are kept. Kernels are matched by name and launch order. If the
+// profiler captured more launches than the summary has (e.g. multiple iterations) then the
+// launches wrap around, and if it captured fewer then they are reused.
+func (infos SummaryGPUKernelLayerInformations) ImportGPUKernelMetrics(metrics GPUKernelMetrics) SummaryGPUKernelLayerInformations {
+	if len(metrics) == 0 {
+		return infos
+	}
+
+	res := make(SummaryGPUKernelLayerInformations, len(infos))
+	for ii, layer := range infos {
+		res[ii] = layer
+		res[ii].SummaryGPUKernelInformations = append([]SummaryGPUKernelInformation{}, layer.SummaryGPUKernelInformations...)
+	}
+
+	type launch struct {
+		layerIdx  int
+		kernelIdx int
+	}
+	launches := map[string][]launch{}
+	launchOrder := []string{}
+	for ii, layer := range res {
+		for jj, kernel := range layer.SummaryGPUKernelInformations {
+			var name string
+			for _, key := range gpuKernelMetricsNameKeys(kernel) {
+				if _, ok := metrics[key]; ok && key != "" {
+					name = key
+					break
+				}
+			}
+			if name == "" {
+				continue
+			}
+			if _, ok := launches[name]; !ok {
+				launchOrder = append(launchOrder, name)
+			}
+			launches[name] = append(launches[name], launch{layerIdx: ii, kernelIdx: jj})
+		}
+	}
+
+	for _, name := range launchOrder {
+		kernelLaunches := launches[name]
+		kernelMetrics := metrics[name]
+		numLaunches := len(kernelLaunches)
+		numMetrics := len(kernelMetrics)
+		for ii, l := range kernelLaunches {
+			imported := []Metadata{}
+			if numMetrics < numLaunches {
+				imported = append(imported, kernelMetrics[ii%numMetrics])
+			} else {
+				for jj := ii; jj < numMetrics; jj += numLaunches {
+					imported = append(imported, kernelMetrics[jj])
+				}
+			}
+			kernel := &res[l.layerIdx].SummaryGPUKernelInformations[l.kernelIdx]
+			kernel.Logs = replaceGPUKernelLogs(kernel.Logs, imported)
+		}
+	}
+
+	return res
+}
+
+// replaceGPUKernelLogs removes the imported metrics from the logs, so the means are not
+// computed over a mix of the CUPTI and the profiler samples, and appends the imported logs
+func replaceGPUKernelLogs(logs []Metadata, imported []Metadata) []Metadata {
+	keys := map[string]bool{}
+	for _, md := range imported {
+		for key := range md {
+			keys[key] = true
+		}
+	}
+	res := []Metadata{}
+	for _, md := range logs {
+		kept := Metadata{}
+		for key, val := range md {
+			if !keys[key] {
+				kept[key] = val
+			}
+		}
+		if len(kept) != 0 {
+			res = append(res, kept)
+		}
+	}
+	return append(res, imported...)
+}
@@ -0,0 +1,116 @@
+package evaluation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ncuMetricsFixture = `==PROF== Connected to process 1234
+==PROF== Profiling "sgemm_kernel": 0%....50%....100% - 10 passes
+"ID","Process ID","Process Name","Host Name","Kernel Name","Context","Stream","Section Name","Metric Name","Metric Unit","Metric Value"
+"0","1234","python","host","sgemm_kernel","1","7","Command line profiler metrics","dram__bytes_read.sum","Kbyte","2.50"
+"0","1234","python","host","sgemm_kernel","1","7","Command line profiler metrics","dram__bytes_write.sum","byte","512"
+"0","1234","python","host","sgemm_kernel","1","7","Command line profiler metrics","sm__warps_active.avg.pct_of_peak_sustained_active","%","50"
+"0","1234","python","host","sgemm_kernel","1","7","Command line profiler metrics","smsp__sass_thread_inst_executed_op_fadd_pred_on.sum","inst","10"
+"0","1234","python","host","sgemm_kernel","1","7","Command line profiler metrics","smsp__sass_thread_inst_executed_op_ffma_pred_on.sum","inst","1,000"
+"1","1234","python","host","sgemm_kernel","1","7","Command line profiler metrics","dram__bytes_read.sum","Mbyte","1"
+"2","1234","python","host","relu_kernel","1","7","Command line profiler metrics","dram__bytes_read.sum","byte","64"
+`
+
+const nvprofTraceFixture = `==1234== NVPROF is profiling process 1234, command: python
+==1234== Profiling result:
+"Device","Context","Stream","Kernel","flop_count_sp","dram_read_bytes","dram_write_bytes","achieved_occupancy"
+"","","","","","","",""
+"Tesla V100 (0)","1","7","sgemm_kernel","2048","1024","256","0.5"
+"Tesla V100 (0)","1","7","sgemm_kernel","4096","2048","512","0.25"
+`
+
+const nvprofSummaryFixture = `==1234== Profiling result:
+"Device","Kernel","Invocations","Metric Name","Metric Description","Min","Max","Avg"
+"Tesla V100 (0)","sgemm_kernel",2,"flop_count_sp","Floating Point Operations(Single Precision)",2048,4096,3072
+"Tesla V100 (0)","sgemm_kernel",2,"achieved_occupancy","Achieved Occupancy",0.25,0.5,0.375
+`
+
+func TestReadGPUKernelMetricsNcu(t *testing.T) {
+	metrics, err := ReadGPUKernelMetrics(strings.NewReader(ncuMetricsFixture))
+	require.NoError(t, err)
+	require.Len(t, metrics["sgemm_kernel"], 2)
+	require.Len(t, metrics["relu_kernel"], 1)
+
+	first := metrics["sgemm_kernel"][0]
+	assert.Equal(t, 2500.0, first["dram_read_bytes"])
+	assert.Equal(t, 512.0, first["dram_write_bytes"])
+	assert.Equal(t, 0.5, first["achieved_occupancy"])
+	assert.Equal(t, 2010.0, first["flop_count_sp"])
+
+	assert.Equal(t, Metadata{"dram_read_bytes": 1e6}, metrics["sgemm_kernel"][1])
+	assert.Equal(t, Metadata{"dram_read_bytes": 64.0}, metrics["relu_kernel"][0])
+}
+
+func TestReadGPUKernelMetricsNvprof(t *testing.T) {
+	metrics, err := ReadGPUKernelMetrics(strings.NewReader(nvprofTraceFixture))
+	require.NoError(t, err)
+	assert.Equal(t, []Metadata{
+		{"flop_count_sp": 2048.0, "dram_read_bytes": 1024.0, "dram_write_bytes": 256.0, "achieved_occupancy": 0.5},
+		{"flop_count_sp": 4096.0, "dram_read_bytes": 2048.0, "dram_write_bytes": 512.0, "achieved_occupancy": 0.25},
+	}, []Metadata(metrics["sgemm_kernel"]))
+
+	metrics, err = ReadGPUKernelMetrics(strings.NewReader(nvprofSummaryFixture))
+	require.NoError(t, err)
+	assert.Equal(t, []Metadata{
+		{"flop_count_sp": 3072.0, "achieved_occupancy": 0.375},
+	}, []Metadata(metrics["sgemm_kernel"]))
+}
+
+func TestReadGPUKernelMetricsMissingColumns(t *testing.T) {
+	cases := map[string]string{
+		"empty":          "==PROF== nothing was profiled\n",
+		"no kernel name": "\"ID\",\"Metric Name\",\"Metric Value\"\n\"0\",\"dram__bytes_read.sum\",\"1\"\n",
+		"no value":       "\"ID\",\"Kernel Name\",\"Metric Name\"\n\"0\",\"sgemm_kernel\",\"dram__bytes_read.sum\"\n",
+	}
+	for name, fixture := range cases {
+		_, err := ReadGPUKernelMetrics(strings.NewReader(fixture))
+		assert.Error(t, err, name)
+	}
+
+	// a row shorter than the header is skipped
+	metrics, err := ReadGPUKernelMetrics(strings.NewReader(
+		"\"ID\",\"Kernel Name\",\"Metric Name\",\"Metric Value\"\n\"0\",\"sgemm_kernel\"\n\"1\",\"sgemm_kernel\",\"dram__bytes_read.sum\",\"8\"\n",
+	))
+	require.NoError(t, err)
+	assert.Equal(t, []Metadata{{"dram_read_bytes": 8.0}}, []Metadata(metrics["sgemm_kernel"]))
+}
+
+func TestImportGPUKernelMetrics(t *testing.T) {
+	infos := SummaryGPUKernelLayerInformations{
+		{
+			SummaryGPUKernelInformations: SummaryGPUKernelInformations{
+				{
+					Name: "sgemm_kernel",
+					Logs: []Metadata{
+						{"flop_count_sp": 1.0, "dram_read_bytes": 1.0, "achieved_occupancy": 0.1},
+					},
+				},
+			},
+		},
+	}
+	metrics := GPUKernelMetrics{
+		"sgemm_kernel": {
+			{"flop_count_sp": 100.0, "dram_read_bytes": 200.0},
+		},
+	}
+
+	imported := infos.ImportGPUKernelMetrics(metrics)
+	assert.Equal(t, []Metadata{
+		{"achieved_occupancy": 0.1},
+		{"flop_count_sp": 100.0, "dram_read_bytes": 200.0},
+	}, imported[0].SummaryGPUKernelInformations[0].Logs)
+	assert.Equal(t, 100.0, GetMeanLogValue(imported[0].SummaryGPUKernelInformations[0], "flop_count_sp", 0))
+
+	// the summaries passed in are not modified
+	assert.Len(t, infos[0].SummaryGPUKernelInformations[0].Logs, 1)
+	assert.Equal(t, 1.0, infos[0].SummaryGPUKernelInformations[0].Logs[0]["flop_count_sp"])
+}
@@ -0,0 +1,264 @@
+package metrics
+
+import (
+	"math"
+
+	"github.com/rai-project/dlframework"
+)
+
+// ClassificationTopKIndices returns the class indices of the k most probable classification features
+func ClassificationTopKIndices(features *dlframework.Features, k int) ([]int, error) {
+	fs, err := classificationFeatures(features)
+	if err != nil {
+		return nil, err
+	}
+	if k > len(fs) || k <= 0 {
+		k = len(fs)
+	}
+	res := make([]int, k)
+	for ii, feature := range fs[:k] {
+		res[ii] = int(feature.Feature.(*dlframework.Feature_Classification).Classification.Index)
+	}
+	return res, nil
+}
+
+// TopKOverlap is the Jaccard index of the labels of two top k lists
+func TopKOverlap(a, b []int) float64 {
+	set := map[int]bool{}
+	for _, v := range a {
+		set[v] = true
+	}
+	intersection := 0
+	union := len(set)
+	seen := map[int]bool{}
+	for _, v := range b {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if set[v] {
+			intersection++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(intersection) / float64(union)
+}
+
+// TopKRankCorrelation is the Spearman rank correlation of two top k lists computed over the union
+// of their labels. Labels which are missing from one of the lists are ranked after its last label.
+func TopKRankCorrelation(a, b []int) float64 {
+	k := len(a)
+	if len(b) > k {
+		k = len(b)
+	}
+	rank := func(list []int) map[int]float64 {
+		res := map[int]float64{}
+		for ii, v := range list {
+			if _, ok := res[v]; !ok {
+				res[v] = float64(ii + 1)
+			}
+		}
+		return res
+	}
+	rankA, rankB := rank(a), rank(b)
+	union := []int{}
+	for _, list := range [][]int{a, b} {
+		for _, v := range list {
+			if _, ok := rankA[v]; !ok {
+				rankA[v] = float64(k + 1)
+			}
+			if _, ok := rankB[v]; !ok {
+				rankB[v] = float64(k + 1)
+			}
+		}
+	}
+	for v := range rankA {
+		union = append(union, v)
+	}
+
+	x := make([]float64, len(union))
+	y := make([]float64, len(union))
+	for ii, v := range union {
+		x[ii] = rankA[v]
+		y[ii] = rankB[v]
+	}
+	meanX, meanY := Mean(x), Mean(y)
+	cov, varX, varY := 0.0, 0.0, 0.0
+	for ii := range x {
+		dx, dy := x[ii]-meanX, y[ii]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		// a single label in both lists
+		if TopKOverlap(a, b) == 1 {
+			return 1
+		}
+		return 0
+	}
+	return cov / math.Sqrt(varX*varY)
+}
+
+// MajorityVote returns the label predicted the most and its number of votes.
+// Ties are broken in favour of the label that received its first vote first.
+func MajorityVote(votes []int) (label int, count int, tie bool) {
+	counts := map[int]int{}
+	for _, v := range votes {
+		counts[v]++
+	}
+	label = -1
+	for _, v := range votes {
+		switch {
+		case counts[v] > count:
+			label, count, tie = v, counts[v], false
+		case counts[v] == count && v != label:
+			tie = true
+		}
+	}
+	return label, count, tie
+}
+
+// Agreement accumulates the agreement between the top k predictions of two classifiers
+type Agreement struct {
+	K int
+
+	numInputs                int64
+	top1Agreements           int64
+	overlap                  float64
+	rankCorrelation          float64
+	numLabeled               int64
+	firstCorrect             int64
+	secondCorrect            int64
+	bothCorrect              int64
+	bothWrongSameAnswer      int64
+	bothWrongDifferentAnswer int64
+}
+
+// NewAgreement creates an agreement over the k most probable labels
+func NewAgreement(k int) *Agreement {
+	if k <= 0 {
+		k = 5
+	}
+	return &Agreement{K: k}
+}
+
+func (a *Agreement) Reset() {
+	*a = Agreement{K: a.K}
+}
+
+// Add adds the top k labels predicted by both classifiers for an input.
+// The expected label index is negative if it is unknown.
+func (a *Agreement) Add(first, second []int, expected int) {
+	if len(first) == 0 || len(second) == 0 {
+		return
+	}
+	a.numInputs++
+	if first[0] == second[0] {
+		a.top1Agreements++
+	}
+	a.overlap += TopKOverlap(first, second)
+	a.rankCorrelation += TopKRankCorrelation(first, second)
+
+	if expected < 0 {
+		return
+	}
+	a.numLabeled++
+	firstCorrect := first[0] == expected
+	secondCorrect := second[0] == expected
+	if firstCorrect {
+		a.firstCorrect++
+	}
+	if secondCorrect {
+		a.secondCorrect++
+	}
+	switch {
+	case firstCorrect && secondCorrect:
+		a.bothCorrect++
+	case !firstCorrect && !secondCorrect && first[0] == second[0]:
+		a.bothWrongSameAnswer++
+	case !firstCorrect && !secondCorrect:
+		a.bothWrongDifferentAnswer++
+	}
+}
+
+// AddFeatures adds the classification features predicted by both classifiers for an input
+func (a *Agreement) AddFeatures(first, second *dlframework.Features, expected int) error {
+	firstLabels, err := ClassificationTopKIndices(first, a.K)
+	if err != nil {
+		return err
+	}
+	secondLabels, err := ClassificationTopKIndices(second, a.K)
+	if err != nil {
+		return err
+	}
+	a.Add(firstLabels, secondLabels, expected)
+	return nil
+}
+
+// NumInputs is the number of inputs added
+func (a *Agreement) NumInputs() int64 {
+	return a.numInputs
+}
+
+// Top1Agreement is the fraction of inputs where both classifiers predict the same top1 label
+func (a *Agreement) Top1Agreement() float64 {
+	return ratio(a.top1Agreements, a.numInputs)
+}
+
+// TopKOverlap is the mean Jaccard index of the top k labels
+func (a *Agreement) TopKOverlap() float64 {
+	if a.numInputs == 0 {
+		return 0
+	}
+	return a.overlap / float64(a.numInputs)
+}
+
+// TopKRankCorrelation is the mean rank correlation of the top k labels
+func (a *Agreement) TopKRankCorrelation() float64 {
+	if a.numInputs == 0 {
+		return 0
+	}
+	return a.rankCorrelation / float64(a.numInputs)
+}
+
+// NumLabeled is the number of inputs added with an expected label
+func (a *Agreement) NumLabeled() int64 {
+	return a.numLabeled
+}
+
+// FirstAccuracy is the top1 accuracy of the first classifier on the labeled inputs
+func (a *Agreement) FirstAccuracy() float64 {
+	return ratio(a.firstCorrect, a.numLabeled)
+}
+
+// SecondAccuracy is the top1 accuracy of the second classifier on the labeled inputs
+func (a *Agreement) SecondAccuracy() float64 {
+	return ratio(a.secondCorrect, a.numLabeled)
+}
+
+// NumBothCorrect is the number of labeled inputs where both top1 labels are correct
+func (a *Agreement) NumBothCorrect() int64 {
+	return a.bothCorrect
+}
+
+// NumBothWrongSameAnswer is the number of labeled inputs where both classifiers predict the same wrong top1 label
+func (a *Agreement) NumBothWrongSameAnswer() int64 {
+	return a.bothWrongSameAnswer
+}
+
+// NumBothWrongDifferentAnswer is the number of labeled inputs where the classifiers predict different wrong top1 labels
+func (a *Agreement) NumBothWrongDifferentAnswer() int64 {
+	return a.bothWrongDifferentAnswer
+}
+
+func ratio(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
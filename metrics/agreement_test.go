@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopKAgreement(t *testing.T) {
+	assert.Equal(t, 1.0, TopKOverlap([]int{1, 2, 3}, []int{3, 2, 1}))
+	assert.InDelta(t, 0.5, TopKOverlap([]int{1, 2, 3}, []int{2, 3, 4}), 1e-9)
+	assert.Equal(t, 0.0, TopKOverlap([]int{1}, []int{2}))
+
+	assert.InDelta(t, 1.0, TopKRankCorrelation([]int{1, 2, 3}, []int{1, 2, 3}), 1e-9)
+	assert.InDelta(t, -1.0, TopKRankCorrelation([]int{1, 2, 3}, []int{3, 2, 1}), 1e-9)
+	assert.InDelta(t, 1.0, TopKRankCorrelation([]int{7}, []int{7}), 1e-9)
+	assert.InDelta(t, -1.0, TopKRankCorrelation([]int{7}, []int{8}), 1e-9)
+
+	label, count, tie := MajorityVote([]int{3, 1, 3})
+	assert.Equal(t, 3, label)
+	assert.Equal(t, 2, count)
+	assert.False(t, tie)
+	label, _, tie = MajorityVote([]int{2, 1})
+	assert.Equal(t, 2, label)
+	assert.True(t, tie)
+}
+
+func TestAgreement(t *testing.T) {
+	classification := func(probabilities ...float32) *dlframework.Features {
+		features := dlframework.Features{}
+		for ii, p := range probabilities {
+			features = append(features, &dlframework.Feature{
+				Feature:     &dlframework.Feature_Classification{Classification: &dlframework.Classification{Index: int32(ii)}},
+				Probability: p,
+			})
+		}
+		return &features
+	}
+
+	labels, err := ClassificationTopKIndices(classification(0.1, 0.6, 0.3), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, labels)
+
+	a := NewAgreement(2)
+	assert.NoError(t, a.AddFeatures(classification(0.1, 0.6, 0.3), classification(0.2, 0.5, 0.3), 1))
+	assert.NoError(t, a.AddFeatures(classification(0.6, 0.1, 0.3), classification(0.1, 0.3, 0.6), 1))
+	assert.NoError(t, a.AddFeatures(classification(0.6, 0.1, 0.3), classification(0.7, 0.1, 0.2), 2))
+	assert.NoError(t, a.AddFeatures(classification(0.6, 0.1, 0.3), classification(0.6, 0.3, 0.1), -1))
+
+	assert.Equal(t, int64(4), a.NumInputs())
+	assert.Equal(t, int64(3), a.NumLabeled())
+	assert.InDelta(t, 0.75, a.Top1Agreement(), 1e-9)
+	assert.InDelta(t, (1+1.0/3+1+1.0/3)/4, a.TopKOverlap(), 1e-9)
+	assert.InDelta(t, 1.0/3, a.FirstAccuracy(), 1e-9)
+	assert.InDelta(t, 1.0/3, a.SecondAccuracy(), 1e-9)
+	assert.Equal(t, int64(1), a.NumBothCorrect())
+	assert.Equal(t, int64(1), a.NumBothWrongSameAnswer())
+	assert.Equal(t, int64(1), a.NumBothWrongDifferentAnswer())
+
+	_, err = ClassificationTopKIndices(&dlframework.Features{}, 1)
+	assert.Error(t, err)
+}
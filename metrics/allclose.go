@@ -0,0 +1,275 @@
+package metrics
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rai-project/dlframework"
+)
+
+// https://docs.scipy.org/doc/numpy/reference/generated/numpy.allclose.html
+// https://randomascii.wordpress.com/2012/02/25/comparing-floating-point-numbers-2012-edition/
+
+var (
+	// DefaultAbsoluteTolerance is the numpy.allclose default absolute tolerance
+	DefaultAbsoluteTolerance = 1e-8
+	// DefaultRelativeTolerance is the numpy.allclose default relative tolerance
+	DefaultRelativeTolerance = 1e-5
+)
+
+// TensorFromFeatures returns the raw tensor data carried by the features. The data is taken from the first
+// raw feature, then from the first raw image feature, otherwise the classification probabilities ordered by
+// class index are used.
+func TensorFromFeatures(features *dlframework.Features) ([]float64, error) {
+	tensor, _, err := TensorFromFeaturesWithElementType(features)
+	return tensor, err
+}
+
+// TensorFromFeaturesWithElementType returns the raw tensor data carried by the features along with the
+// element type of the data (float32, float64, int32, int64 or uint8).
+func TensorFromFeaturesWithElementType(features *dlframework.Features) ([]float64, string, error) {
+	if features == nil || len(*features) == 0 {
+		return nil, "", errors.New("expecting at least one feature")
+	}
+	for _, feature := range *features {
+		if feature == nil {
+			continue
+		}
+		if raw, ok := feature.Feature.(*dlframework.Feature_Raw); ok {
+			return decodeRawTensor(raw.Raw.Data, raw.Raw.Format)
+		}
+	}
+	for _, feature := range *features {
+		if feature == nil {
+			continue
+		}
+		if raw, ok := feature.Feature.(*dlframework.Feature_RawImage); ok {
+			if len(raw.RawImage.FloatList) != 0 {
+				res := make([]float64, len(raw.RawImage.FloatList))
+				for ii, v := range raw.RawImage.FloatList {
+					res[ii] = float64(v)
+				}
+				return res, "float32", nil
+			}
+			res := make([]float64, len(raw.RawImage.CharList))
+			for ii, v := range raw.RawImage.CharList {
+				res[ii] = float64(v)
+			}
+			return res, "uint8", nil
+		}
+	}
+
+	probabilities, err := ClassificationProbabilities(features)
+	if err != nil {
+		return nil, "", errors.New("the features do not carry raw tensor data")
+	}
+	size := 0
+	for idx := range probabilities {
+		if idx < 0 {
+			return nil, "", errors.Errorf("invalid classification index %v", idx)
+		}
+		if idx+1 > size {
+			size = idx + 1
+		}
+	}
+	res := make([]float64, size)
+	for idx, p := range probabilities {
+		res[idx] = p
+	}
+	// the probabilities are float32 in the features
+	return res, "float32", nil
+}
+
+// decodeRawTensor decodes little endian tensor data and returns the element type. The format defaults to float32.
+func decodeRawTensor(data []byte, format string) ([]float64, string, error) {
+	var size int
+	var elementType string
+	var decode func([]byte) float64
+	switch strings.ToLower(format) {
+	case "", "float32", "float":
+		size, elementType, decode = 4, "float32", func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	case "float64", "double":
+		size, elementType, decode = 8, "float64", func(b []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(b)) }
+	case "int32":
+		size, elementType, decode = 4, "int32", func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) }
+	case "int64":
+		size, elementType, decode = 8, "int64", func(b []byte) float64 { return float64(int64(binary.LittleEndian.Uint64(b))) }
+	case "uint8", "char", "byte":
+		size, elementType, decode = 1, "uint8", func(b []byte) float64 { return float64(b[0]) }
+	default:
+		return nil, "", errors.Errorf("unsupported raw tensor format %v", format)
+	}
+	if len(data)%size != 0 {
+		return nil, "", errors.Errorf("the raw tensor size %v is not a multiple of the %v element size", len(data), format)
+	}
+	res := make([]float64, len(data)/size)
+	for ii := range res {
+		res[ii] = decode(data[ii*size : (ii+1)*size])
+	}
+	return res, elementType, nil
+}
+
+// orderedDistance is the distance between two ordered integer representations. The difference is
+// computed modulo 2^64 so that it does not overflow.
+func orderedDistance(a, b int64) uint64 {
+	if a < b {
+		a, b = b, a
+	}
+	return uint64(a) - uint64(b)
+}
+
+// ULPDistance is the number of representable float32 values between a and b.
+// It is not ok if a or b is NaN.
+func ULPDistance(a, b float32) (uint64, bool) {
+	if math.IsNaN(float64(a)) || math.IsNaN(float64(b)) {
+		return 0, false
+	}
+	ordered := func(f float32) int64 {
+		bits := int32(math.Float32bits(f))
+		if bits < 0 {
+			// negative floats are stored as sign and magnitude
+			return int64(math.MinInt32) - int64(bits)
+		}
+		return int64(bits)
+	}
+	return orderedDistance(ordered(a), ordered(b)), true
+}
+
+// ULPDistance64 is the number of representable float64 values between a and b.
+// It is not ok if a or b is NaN.
+func ULPDistance64(a, b float64) (uint64, bool) {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return 0, false
+	}
+	ordered := func(f float64) int64 {
+		bits := int64(math.Float64bits(f))
+		if bits < 0 {
+			return math.MinInt64 - bits
+		}
+		return bits
+	}
+	return orderedDistance(ordered(a), ordered(b)), true
+}
+
+// elementULPDistance is the ulp distance between a and b as values of the element type.
+// The distance between integers is their difference.
+func elementULPDistance(a, b float64, elementType string) (uint64, bool) {
+	switch elementType {
+	case "float64":
+		return ULPDistance64(a, b)
+	case "int32", "int64", "uint8":
+		if math.IsNaN(a) || math.IsNaN(b) {
+			return 0, false
+		}
+		return uint64(math.Abs(a - b)), true
+	default:
+		return ULPDistance(float32(a), float32(b))
+	}
+}
+
+// IsClose returns true if |actual - expected| <= atol + rtol * |expected| (NaNs are never close)
+func IsClose(actual, expected, atol, rtol float64) bool {
+	if math.IsNaN(actual) || math.IsNaN(expected) {
+		return false
+	}
+	if actual == expected {
+		return true
+	}
+	return math.Abs(actual-expected) <= atol+rtol*math.Abs(expected)
+}
+
+// NumericComparison is the element wise comparison of an actual tensor against an expected tensor
+type NumericComparison struct {
+	AbsoluteTolerance   float64 `json:"absolute_tolerance,omitempty"`
+	RelativeTolerance   float64 `json:"relative_tolerance,omitempty"`
+	NumElements         int     `json:"num_elements,omitempty"`
+	NumNotClose         int     `json:"num_not_close,omitempty"`
+	NumNaN              int     `json:"num_nan,omitempty"`
+	MaxAbsoluteError    float64 `json:"max_absolute_error,omitempty"`
+	MaxRelativeError    float64 `json:"max_relative_error,omitempty"`
+	MaxULPDistance      uint64  `json:"max_ulp_distance,omitempty"`
+	ArgMaxAbsoluteError int     `json:"arg_max_absolute_error,omitempty"`
+}
+
+// FractionWithinTolerance is the fraction of the elements that are close
+func (c NumericComparison) FractionWithinTolerance() float64 {
+	if c.NumElements == 0 {
+		return 1
+	}
+	return float64(c.NumElements-c.NumNotClose) / float64(c.NumElements)
+}
+
+// AllClose returns true if all the elements are close
+func (c NumericComparison) AllClose() bool {
+	return c.NumNotClose == 0
+}
+
+// CompareTensors compares the actual values against the expected ones in the spirit of numpy.allclose.
+// The relative error of an element whose expected value is zero is +Inf unless the actual value is zero.
+// The ulp distance is measured between float32 values.
+func CompareTensors(actual, expected []float64, atol, rtol float64) (*NumericComparison, error) {
+	return CompareTensorsWithElementType(actual, expected, "float32", atol, rtol)
+}
+
+// CompareTensorsWithElementType is CompareTensors with the ulp distance measured between values of the
+// element type. The elements where either value is NaN are counted and do not have an ulp distance.
+func CompareTensorsWithElementType(actual, expected []float64, elementType string, atol, rtol float64) (*NumericComparison, error) {
+	if len(actual) != len(expected) {
+		return nil, errors.Errorf("the tensor length %v does not match the expected length %v", len(actual), len(expected))
+	}
+	res := &NumericComparison{
+		AbsoluteTolerance: atol,
+		RelativeTolerance: rtol,
+		NumElements:       len(actual),
+	}
+	for ii, a := range actual {
+		e := expected[ii]
+		if !IsClose(a, e, atol, rtol) {
+			res.NumNotClose++
+		}
+		absoluteError := math.Abs(a - e)
+		if a == e {
+			absoluteError = 0
+		}
+		if absoluteError > res.MaxAbsoluteError || math.IsNaN(absoluteError) && !math.IsNaN(res.MaxAbsoluteError) {
+			res.MaxAbsoluteError = absoluteError
+			res.ArgMaxAbsoluteError = ii
+		}
+		relativeError := 0.0
+		if absoluteError != 0 {
+			relativeError = absoluteError / math.Abs(e)
+		}
+		if relativeError > res.MaxRelativeError || math.IsNaN(relativeError) {
+			res.MaxRelativeError = relativeError
+		}
+		ulp, ok := elementULPDistance(a, e, elementType)
+		if !ok {
+			res.NumNaN++
+			continue
+		}
+		if ulp > res.MaxULPDistance {
+			res.MaxULPDistance = ulp
+		}
+	}
+	return res, nil
+}
+
+// CompareFeatureTensors compares the raw tensor data carried by the features. The ulp distance is
+// measured in the expected element type, or between float32 values if the element types differ.
+func CompareFeatureTensors(actual, expected *dlframework.Features, atol, rtol float64) (*NumericComparison, error) {
+	actualTensor, actualType, err := TensorFromFeaturesWithElementType(actual)
+	if err != nil {
+		return nil, err
+	}
+	expectedTensor, expectedType, err := TensorFromFeaturesWithElementType(expected)
+	if err != nil {
+		return nil, err
+	}
+	elementType := expectedType
+	if actualType != expectedType {
+		elementType = "float32"
+	}
+	return CompareTensorsWithElementType(actualTensor, expectedTensor, elementType, atol, rtol)
+}
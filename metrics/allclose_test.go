@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestULPDistance(t *testing.T) {
+	ulp := func(d uint64, ok bool) uint64 {
+		assert.True(t, ok)
+		return d
+	}
+	assert.Equal(t, uint64(0), ulp(ULPDistance(1, 1)))
+	assert.Equal(t, uint64(0), ulp(ULPDistance(0, float32(math.Copysign(0, -1)))))
+	assert.Equal(t, uint64(1), ulp(ULPDistance(1, math.Nextafter32(1, 2))))
+	assert.Equal(t, uint64(2), ulp(ULPDistance(math.Nextafter32(0, -1), math.Nextafter32(0, 1))))
+	assert.Equal(t, uint64(0xff000000), ulp(ULPDistance(float32(math.Inf(-1)), float32(math.Inf(1)))))
+	_, ok := ULPDistance(float32(math.NaN()), 1)
+	assert.False(t, ok)
+
+	assert.Equal(t, uint64(1), ulp(ULPDistance64(1, math.Nextafter(1, 2))))
+	assert.Equal(t, uint64(2), ulp(ULPDistance64(math.Nextafter(0, -1), math.Nextafter(0, 1))))
+	assert.Equal(t, uint64(0xffe0000000000000), ulp(ULPDistance64(math.Inf(-1), math.Inf(1))))
+	_, ok = ULPDistance64(1, math.NaN())
+	assert.False(t, ok)
+}
+
+func TestCompareTensors(t *testing.T) {
+	c, err := CompareTensors([]float64{1, 2.00001, 0, 4}, []float64{1, 2, 1e-9, 3}, DefaultAbsoluteTolerance, DefaultRelativeTolerance)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, c.NumElements)
+	assert.Equal(t, 1, c.NumNotClose)
+	assert.InDelta(t, 0.75, c.FractionWithinTolerance(), 1e-9)
+	assert.False(t, c.AllClose())
+	assert.InDelta(t, 1, c.MaxAbsoluteError, 1e-9)
+	assert.Equal(t, 3, c.ArgMaxAbsoluteError)
+	assert.InDelta(t, 1, c.MaxRelativeError, 1e-9)
+
+	c, err = CompareTensors([]float64{1, math.Inf(1)}, []float64{1, math.Inf(1)}, 0, 0)
+	assert.NoError(t, err)
+	assert.True(t, c.AllClose())
+
+	c, err = CompareTensors([]float64{math.NaN(), 1}, []float64{math.NaN(), math.Nextafter(1, 2)}, 0, 0)
+	assert.NoError(t, err)
+	assert.False(t, c.AllClose())
+	assert.Equal(t, 1, c.NumNaN)
+	// the values are equal as float32
+	assert.Equal(t, uint64(0), c.MaxULPDistance)
+
+	c, err = CompareTensorsWithElementType([]float64{1, 3}, []float64{math.Nextafter(1, 2), 1}, "float64", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3)<<51, c.MaxULPDistance)
+
+	c, err = CompareTensorsWithElementType([]float64{1, 250}, []float64{3, 0}, "uint8", 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(250), c.MaxULPDistance)
+
+	_, err = CompareTensors([]float64{1}, []float64{1, 2}, 0, 0)
+	assert.Error(t, err)
+}
+
+func TestTensorFromFeatures(t *testing.T) {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint32(data, math.Float32bits(0.5))
+	binary.LittleEndian.PutUint32(data[4:], math.Float32bits(-2))
+	tensor, err := TensorFromFeatures(&dlframework.Features{
+		&dlframework.Feature{Feature: &dlframework.Feature_Raw{Raw: &dlframework.Raw{Data: data, Format: "float32"}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5, -2}, tensor)
+
+	tensor, elementType, err := TensorFromFeaturesWithElementType(&dlframework.Features{
+		&dlframework.Feature{Feature: &dlframework.Feature_Raw{Raw: &dlframework.Raw{Data: data, Format: "double"}}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "float64", elementType)
+	assert.Len(t, tensor, 1)
+
+	tensor, err = TensorFromFeatures(&dlframework.Features{
+		&dlframework.Feature{Feature: &dlframework.Feature_Classification{Classification: &dlframework.Classification{Index: 2}}, Probability: 0.75},
+		&dlframework.Feature{Feature: &dlframework.Feature_Classification{Classification: &dlframework.Classification{Index: 0}}, Probability: 0.25},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.25, 0, 0.75}, tensor)
+
+	_, err = TensorFromFeatures(&dlframework.Features{
+		&dlframework.Feature{Feature: &dlframework.Feature_Raw{Raw: &dlframework.Raw{Data: data[:3], Format: "float32"}}},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,266 @@
+package metrics
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/rai-project/config"
+	"github.com/rai-project/dlframework"
+	"github.com/spf13/cast"
+)
+
+// On Calibration of Modern Neural Networks https://arxiv.org/abs/1706.04599
+// https://en.wikipedia.org/wiki/Brier_score
+
+var (
+	// DefaultCalibrationBins is the number of equal width confidence bins
+	DefaultCalibrationBins = 15
+	// calibrationEpsilon bounds the probability of the expected label used in the negative log likelihood
+	calibrationEpsilon = 1e-12
+)
+
+// CalibrationBin is a confidence bin of a reliability diagram
+type CalibrationBin struct {
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	Count      int64   `json:"count"`
+	Accuracy   float64 `json:"accuracy"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Gap is the difference between the confidence and the accuracy of the bin
+func (b CalibrationBin) Gap() float64 {
+	return b.Confidence - b.Accuracy
+}
+
+// Calibration accumulates the top1 confidence of classification predictions
+type Calibration struct {
+	NumBins int
+
+	counts        []int64
+	correct       []int64
+	confidences   []float64
+	brierScore    float64
+	logLikelihood float64
+	total         int64
+}
+
+// NewCalibration creates a calibration with numBins equal width bins over [0, 1]
+func NewCalibration(numBins int) *Calibration {
+	if numBins <= 0 {
+		numBins = DefaultCalibrationBins
+	}
+	c := &Calibration{NumBins: numBins}
+	c.Reset()
+	return c
+}
+
+func (c *Calibration) Reset() {
+	c.counts = make([]int64, c.NumBins)
+	c.correct = make([]int64, c.NumBins)
+	c.confidences = make([]float64, c.NumBins)
+	c.brierScore = 0
+	c.logLikelihood = 0
+	c.total = 0
+}
+
+// ClassificationProbabilities returns the probability of each predicted class index
+func ClassificationProbabilities(features *dlframework.Features) (map[int]float64, error) {
+	if features == nil || len(*features) == 0 {
+		return nil, errors.New("expecting at least one feature")
+	}
+	res := map[int]float64{}
+	for _, feature := range *features {
+		if feature == nil {
+			continue
+		}
+		classification, ok := feature.Feature.(*dlframework.Feature_Classification)
+		if !ok {
+			return nil, errors.New("unable to convert feature to classification")
+		}
+		res[int(classification.Classification.Index)] += float64(feature.GetProbability())
+	}
+	return res, nil
+}
+
+// Add adds a prediction given the probability of each class and the expected class index.
+// Classes which are not in the probabilities have a zero probability.
+func (c *Calibration) Add(probabilities map[int]float64, expected int) {
+	top1 := -1
+	confidence := -1.0
+	brier := 1.0
+	for idx, p := range probabilities {
+		if p > confidence || (p == confidence && idx < top1) {
+			top1, confidence = idx, p
+		}
+		brier += p * p
+		if idx == expected {
+			brier -= 2 * p
+		}
+	}
+	confidence = math.Max(0, math.Min(1, confidence))
+
+	bin := int(confidence * float64(c.NumBins))
+	if bin >= c.NumBins {
+		bin = c.NumBins - 1
+	}
+	c.counts[bin]++
+	c.confidences[bin] += confidence
+	if top1 == expected {
+		c.correct[bin]++
+	}
+	c.brierScore += brier
+	c.logLikelihood += math.Log(math.Max(probabilities[expected], calibrationEpsilon))
+	c.total++
+}
+
+// AddFeatures adds the classification features predicted for an input
+func (c *Calibration) AddFeatures(features *dlframework.Features, expected int) error {
+	probabilities, err := ClassificationProbabilities(features)
+	if err != nil {
+		return err
+	}
+	c.Add(probabilities, expected)
+	return nil
+}
+
+// Total is the number of predictions added
+func (c *Calibration) Total() int64 {
+	return c.total
+}
+
+// Bins returns the reliability diagram bins
+func (c *Calibration) Bins() []CalibrationBin {
+	res := make([]CalibrationBin, c.NumBins)
+	for ii := range res {
+		res[ii] = CalibrationBin{
+			Lower: float64(ii) / float64(c.NumBins),
+			Upper: float64(ii+1) / float64(c.NumBins),
+			Count: c.counts[ii],
+		}
+		if c.counts[ii] != 0 {
+			res[ii].Accuracy = float64(c.correct[ii]) / float64(c.counts[ii])
+			res[ii].Confidence = c.confidences[ii] / float64(c.counts[ii])
+		}
+	}
+	return res
+}
+
+// Accuracy is the top1 accuracy of the predictions
+func (c *Calibration) Accuracy() float64 {
+	if c.total == 0 {
+		return 0
+	}
+	correct := int64(0)
+	for _, n := range c.correct {
+		correct += n
+	}
+	return float64(correct) / float64(c.total)
+}
+
+// ExpectedCalibrationError is the mean absolute gap between the confidence and the accuracy of the bins weighted by their size
+func (c *Calibration) ExpectedCalibrationError() float64 {
+	if c.total == 0 {
+		return 0
+	}
+	res := 0.0
+	for _, bin := range c.Bins() {
+		res += float64(bin.Count) / float64(c.total) * math.Abs(bin.Gap())
+	}
+	return res
+}
+
+// MaximumCalibrationError is the largest absolute gap between the confidence and the accuracy of the non empty bins
+func (c *Calibration) MaximumCalibrationError() float64 {
+	res := 0.0
+	for _, bin := range c.Bins() {
+		if bin.Count == 0 {
+			continue
+		}
+		res = math.Max(res, math.Abs(bin.Gap()))
+	}
+	return res
+}
+
+// BrierScore is the mean squared error between the probabilities and the one hot encoded expected class
+func (c *Calibration) BrierScore() float64 {
+	if c.total == 0 {
+		return 0
+	}
+	return c.brierScore / float64(c.total)
+}
+
+// NegativeLogLikelihood is the mean negative log probability of the expected class
+func (c *Calibration) NegativeLogLikelihood() float64 {
+	if c.total == 0 {
+		return 0
+	}
+	return -c.logLikelihood / float64(c.total)
+}
+
+// CalibrationAccumulator computes a calibration metric over all the inputs
+type CalibrationAccumulator struct {
+	Calibration *Calibration
+	metric      func(*Calibration) float64
+}
+
+func NewCalibrationAccumulator(metric func(*Calibration) float64) *CalibrationAccumulator {
+	return &CalibrationAccumulator{
+		Calibration: NewCalibration(DefaultCalibrationBins),
+		metric:      metric,
+	}
+}
+
+func (a *CalibrationAccumulator) Reset() {
+	a.Calibration.Reset()
+}
+
+func (a *CalibrationAccumulator) Add(actual *dlframework.Features, expected interface{}) error {
+	expectedLabel, err := cast.ToIntE(expected)
+	if err != nil {
+		return errors.New("expecting an int for second argument")
+	}
+	return a.Calibration.AddFeatures(actual, expectedLabel)
+}
+
+func (a *CalibrationAccumulator) Finalize() (float64, error) {
+	if a.Calibration.Total() == 0 {
+		return 0, errors.New("no samples were added to the accumulator")
+	}
+	return a.metric(a.Calibration), nil
+}
+
+func calibrationMetric(name, description string, metric func(*Calibration) float64) Metric {
+	return Metric{
+		Name:         name,
+		Description:  description,
+		FeatureTypes: []dlframework.FeatureType{dlframework.FeatureType_CLASSIFICATION},
+		Compare: func(actual *dlframework.Features, expected interface{}) (float64, error) {
+			acc := NewCalibrationAccumulator(metric)
+			if err := acc.Add(actual, expected); err != nil {
+				return 0, err
+			}
+			return acc.Finalize()
+		},
+		NewAccumulator: func() Accumulator {
+			return NewCalibrationAccumulator(metric)
+		},
+	}
+}
+
+func init() {
+	config.AfterInit(func() {
+		RegisterMetric(calibrationMetric("ExpectedCalibrationError",
+			"mean gap between the top1 confidence and accuracy of the confidence bins",
+			(*Calibration).ExpectedCalibrationError))
+		RegisterMetric(calibrationMetric("MaximumCalibrationError",
+			"largest gap between the top1 confidence and accuracy of the confidence bins",
+			(*Calibration).MaximumCalibrationError))
+		RegisterMetric(calibrationMetric("BrierScore",
+			"mean squared error of the class probabilities",
+			(*Calibration).BrierScore))
+		RegisterMetric(calibrationMetric("NegativeLogLikelihood",
+			"mean negative log probability of the expected class",
+			(*Calibration).NegativeLogLikelihood))
+	})
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibration(t *testing.T) {
+	c := NewCalibration(2)
+	c.Add(map[int]float64{0: 0.9, 1: 0.1}, 0)
+	c.Add(map[int]float64{0: 0.8, 1: 0.2}, 1)
+	c.Add(map[int]float64{0: 0.3, 1: 0.7}, 1)
+	c.Add(map[int]float64{0: 0.25, 1: 0.4, 2: 0.35}, 0)
+
+	assert.Equal(t, int64(4), c.Total())
+	assert.Equal(t, 0.5, c.Accuracy())
+
+	bins := c.Bins()
+	assert.Len(t, bins, 2)
+	assert.Equal(t, int64(1), bins[0].Count)
+	assert.Equal(t, int64(3), bins[1].Count)
+	assert.InDelta(t, 2.0/3, bins[1].Accuracy, 1e-9)
+	assert.InDelta(t, 0.8, bins[1].Confidence, 1e-9)
+
+	assert.InDelta(t, 0.2, c.ExpectedCalibrationError(), 1e-9)
+	assert.InDelta(t, 0.4, c.MaximumCalibrationError(), 1e-9)
+	assert.InDelta(t, 2.325/4, c.BrierScore(), 1e-9)
+	assert.InDelta(t, -(math.Log(0.9)+math.Log(0.2)+math.Log(0.7)+math.Log(0.25))/4, c.NegativeLogLikelihood(), 1e-9)
+}
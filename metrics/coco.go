@@ -1,3 +1,601 @@
 package metrics
 
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // github.com/dereklstinson/coco
+// https://github.com/cocodataset/cocoapi/blob/master/PythonAPI/pycocotools/cocoeval.py
+
+// DetectionBox is an axis aligned box in pixel coordinates
+type DetectionBox struct {
+	Xmin float64 `json:"xmin"`
+	Ymin float64 `json:"ymin"`
+	Xmax float64 `json:"xmax"`
+	Ymax float64 `json:"ymax"`
+}
+
+// Width ...
+func (b DetectionBox) Width() float64 {
+	return math.Max(b.Xmax-b.Xmin, 0)
+}
+
+// Height ...
+func (b DetectionBox) Height() float64 {
+	return math.Max(b.Ymax-b.Ymin, 0)
+}
+
+// Area ...
+func (b DetectionBox) Area() float64 {
+	return b.Width() * b.Height()
+}
+
+// Intersection is the area shared by the two boxes
+func (b DetectionBox) Intersection(other DetectionBox) float64 {
+	width := math.Min(b.Xmax, other.Xmax) - math.Max(b.Xmin, other.Xmin)
+	height := math.Min(b.Ymax, other.Ymax) - math.Max(b.Ymin, other.Ymin)
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+	return width * height
+}
+
+// IntersectionOverUnion ...
+func (b DetectionBox) IntersectionOverUnion(other DetectionBox) float64 {
+	intersection := b.Intersection(other)
+	union := b.Area() + other.Area() - intersection
+	if union <= 0 {
+		return 0
+	}
+	return intersection / union
+}
+
+// COCODetection is a detection made by the model
+type COCODetection struct {
+	ImageID  string       `json:"image_id"`
+	Category string       `json:"category"`
+	Box      DetectionBox `json:"box"`
+	Score    float64      `json:"score"`
+}
+
+// COCOGroundTruth is an annotated object
+type COCOGroundTruth struct {
+	ImageID  string       `json:"image_id"`
+	Category string       `json:"category"`
+	Box      DetectionBox `json:"box"`
+	// Area is the area of the segmentation mask, the box area is used if it is 0
+	Area    float64 `json:"area,omitempty"`
+	IsCrowd bool    `json:"is_crowd,omitempty"`
+}
+
+func (g COCOGroundTruth) area() float64 {
+	if g.Area > 0 {
+		return g.Area
+	}
+	return g.Box.Area()
+}
+
+// COCOAreaRange is the range of object areas (in pixels) an evaluation is restricted to
+type COCOAreaRange struct {
+	Name string
+	Min  float64
+	Max  float64
+}
+
+var (
+	// DefaultCOCOAreaRanges are the all, small, medium, and large ranges used by the COCO evaluation
+	DefaultCOCOAreaRanges = []COCOAreaRange{
+		{Name: "all", Min: 0, Max: 1e10},
+		{Name: "small", Min: 0, Max: 32 * 32},
+		{Name: "medium", Min: 32 * 32, Max: 96 * 96},
+		{Name: "large", Min: 96 * 96, Max: 1e10},
+	}
+	// DefaultCOCOMaxDetections is the maximum number of detections per image and category
+	DefaultCOCOMaxDetections = 100
+)
+
+// COCOIoUThresholds returns the .50:.05:.95 thresholds used by the COCO evaluation
+func COCOIoUThresholds() []float64 {
+	res := make([]float64, 10)
+	for ii := range res {
+		res[ii] = 0.5 + 0.05*float64(ii)
+	}
+	return res
+}
+
+type cocoKey struct {
+	imageID  string
+	category string
+}
+
+// COCOEvaluator computes the COCO detection metrics.
+// Ground truths and detections are added one image at a time and Evaluate computes
+// the metrics over everything added so far.
+type COCOEvaluator struct {
+	IoUThresholds    []float64
+	RecallThresholds []float64
+	AreaRanges       []COCOAreaRange
+	MaxDetections    int
+	groundTruths     map[cocoKey][]COCOGroundTruth
+	detections       map[cocoKey][]COCODetection
+	categories       map[string]bool
+}
+
+// NewCOCOEvaluator creates an evaluator with the default COCO parameters
+func NewCOCOEvaluator() *COCOEvaluator {
+	e := &COCOEvaluator{
+		IoUThresholds:    COCOIoUThresholds(),
+		RecallThresholds: RecallThresholds(101),
+		AreaRanges:       DefaultCOCOAreaRanges,
+		MaxDetections:    DefaultCOCOMaxDetections,
+	}
+	e.Reset()
+	return e
+}
+
+// Reset removes the ground truths and detections
+func (e *COCOEvaluator) Reset() {
+	e.groundTruths = map[cocoKey][]COCOGroundTruth{}
+	e.detections = map[cocoKey][]COCODetection{}
+	e.categories = map[string]bool{}
+}
+
+// AddGroundTruth ...
+func (e *COCOEvaluator) AddGroundTruth(gts ...COCOGroundTruth) {
+	for _, gt := range gts {
+		key := cocoKey{imageID: gt.ImageID, category: gt.Category}
+		e.groundTruths[key] = append(e.groundTruths[key], gt)
+		e.categories[gt.Category] = true
+	}
+}
+
+// AddDetection ...
+func (e *COCOEvaluator) AddDetection(dts ...COCODetection) {
+	for _, dt := range dts {
+		key := cocoKey{imageID: dt.ImageID, category: dt.Category}
+		e.detections[key] = append(e.detections[key], dt)
+		e.categories[dt.Category] = true
+	}
+}
+
+// COCOCategoryResult is the result for a single category over all the areas
+type COCOCategoryResult struct {
+	Category        string  `json:"category"`
+	NumGroundTruths int     `json:"num_ground_truths"`
+	NumDetections   int     `json:"num_detections"`
+	AP              float64 `json:"ap"`
+	AP50            float64 `json:"ap50"`
+	AP75            float64 `json:"ap75"`
+	// Precision is the interpolated precision at each recall threshold for an IoU of 0.5
+	Precision []float64 `json:"precision,omitempty"`
+	Recall    []float64 `json:"recall,omitempty"`
+}
+
+// COCOResult is the result of the COCO evaluation. The AP values are -1 if there
+// is no ground truth to compute them with.
+type COCOResult struct {
+	AP          float64              `json:"ap"`
+	AP50        float64              `json:"ap50"`
+	AP75        float64              `json:"ap75"`
+	APSmall     float64              `json:"ap_small"`
+	APMedium    float64              `json:"ap_medium"`
+	APLarge     float64              `json:"ap_large"`
+	AR          float64              `json:"ar"`
+	PerCategory []COCOCategoryResult `json:"per_category,omitempty"`
+}
+
+// cocoImageEvaluation is the matching of the detections of a single (image, category)
+// for one area range
+type cocoImageEvaluation struct {
+	scores []float64
+	// matched and ignored are indexed by [iou threshold][detection]
+	matched         [][]bool
+	ignored         [][]bool
+	numGroundTruths int
+}
+
+func (e *COCOEvaluator) evaluateImage(gts []COCOGroundTruth, dts []COCODetection, areaRange COCOAreaRange) *cocoImageEvaluation {
+	if len(gts) == 0 && len(dts) == 0 {
+		return nil
+	}
+
+	gtIgnore := make([]bool, len(gts))
+	for ii, gt := range gts {
+		area := gt.area()
+		gtIgnore[ii] = gt.IsCrowd || area < areaRange.Min || area > areaRange.Max
+	}
+
+	// ground truths that are not ignored go first
+	gtOrder := make([]int, len(gts))
+	for ii := range gtOrder {
+		gtOrder[ii] = ii
+	}
+	sort.SliceStable(gtOrder, func(ii, jj int) bool {
+		return !gtIgnore[gtOrder[ii]] && gtIgnore[gtOrder[jj]]
+	})
+
+	dtOrder := make([]int, len(dts))
+	for ii := range dtOrder {
+		dtOrder[ii] = ii
+	}
+	sort.SliceStable(dtOrder, func(ii, jj int) bool {
+		return dts[dtOrder[ii]].Score > dts[dtOrder[jj]].Score
+	})
+	if e.MaxDetections > 0 && len(dtOrder) > e.MaxDetections {
+		dtOrder = dtOrder[:e.MaxDetections]
+	}
+
+	ious := make([][]float64, len(dtOrder))
+	for ii, dtIdx := range dtOrder {
+		ious[ii] = make([]float64, len(gtOrder))
+		dt := dts[dtIdx]
+		for jj, gtIdx := range gtOrder {
+			gt := gts[gtIdx]
+			if gt.IsCrowd {
+				// a detection can cover part of a crowd region
+				area := dt.Box.Area()
+				if area > 0 {
+					ious[ii][jj] = dt.Box.Intersection(gt.Box) / area
+				}
+				continue
+			}
+			ious[ii][jj] = dt.Box.IntersectionOverUnion(gt.Box)
+		}
+	}
+
+	res := &cocoImageEvaluation{
+		scores:  make([]float64, len(dtOrder)),
+		matched: make([][]bool, len(e.IoUThresholds)),
+		ignored: make([][]bool, len(e.IoUThresholds)),
+	}
+	for ii, dtIdx := range dtOrder {
+		res.scores[ii] = dts[dtIdx].Score
+	}
+	for _, ignore := range gtIgnore {
+		if !ignore {
+			res.numGroundTruths++
+		}
+	}
+
+	for tt, threshold := range e.IoUThresholds {
+		gtMatched := make([]bool, len(gtOrder))
+		res.matched[tt] = make([]bool, len(dtOrder))
+		res.ignored[tt] = make([]bool, len(dtOrder))
+		for ii, dtIdx := range dtOrder {
+			best := math.Min(threshold, 1-1e-10)
+			match := -1
+			for jj, gtIdx := range gtOrder {
+				if gtMatched[jj] && !gts[gtIdx].IsCrowd {
+					continue
+				}
+				// stop once we reach the ignored ground truths if we already have a match
+				if match > -1 && !gtIgnore[gtOrder[match]] && gtIgnore[gtIdx] {
+					break
+				}
+				if ious[ii][jj] < best {
+					continue
+				}
+				best = ious[ii][jj]
+				match = jj
+			}
+			if match == -1 {
+				area := dts[dtIdx].Box.Area()
+				res.ignored[tt][ii] = area < areaRange.Min || area > areaRange.Max
+				continue
+			}
+			gtMatched[match] = true
+			res.matched[tt][ii] = true
+			res.ignored[tt][ii] = gtIgnore[gtOrder[match]]
+		}
+	}
+
+	return res
+}
+
+// accumulate returns the interpolated precision at each recall threshold (indexed by
+// [iou threshold][recall threshold]) and the recall at each iou threshold.
+// It returns nil if there is no ground truth.
+func (e *COCOEvaluator) accumulate(evals []*cocoImageEvaluation) ([][]float64, []float64) {
+	numGroundTruths := 0
+	numDetections := 0
+	for _, eval := range evals {
+		numGroundTruths += eval.numGroundTruths
+		numDetections += len(eval.scores)
+	}
+	if numGroundTruths == 0 {
+		return nil, nil
+	}
+
+	type detection struct {
+		score   float64
+		matched []bool
+		ignored []bool
+	}
+	detections := make([]detection, 0, numDetections)
+	for _, eval := range evals {
+		for ii, score := range eval.scores {
+			det := detection{
+				score:   score,
+				matched: make([]bool, len(e.IoUThresholds)),
+				ignored: make([]bool, len(e.IoUThresholds)),
+			}
+			for tt := range e.IoUThresholds {
+				det.matched[tt] = eval.matched[tt][ii]
+				det.ignored[tt] = eval.ignored[tt][ii]
+			}
+			detections = append(detections, det)
+		}
+	}
+	sort.SliceStable(detections, func(ii, jj int) bool {
+		return detections[ii].score > detections[jj].score
+	})
+
+	precision := make([][]float64, len(e.IoUThresholds))
+	recall := make([]float64, len(e.IoUThresholds))
+	for tt := range e.IoUThresholds {
+		truePositives := []bool{}
+		for _, det := range detections {
+			if det.ignored[tt] {
+				continue
+			}
+			truePositives = append(truePositives, det.matched[tt])
+		}
+		pr, rc := PrecisionRecallCurve(truePositives, numGroundTruths)
+		precision[tt] = InterpolatedPrecision(pr, rc, e.RecallThresholds)
+		if len(rc) > 0 {
+			recall[tt] = rc[len(rc)-1]
+		}
+	}
+	return precision, recall
+}
+
+func (e *COCOEvaluator) iouThresholdIndex(threshold float64) int {
+	for ii, t := range e.IoUThresholds {
+		if math.Abs(t-threshold) < 1e-6 {
+			return ii
+		}
+	}
+	return -1
+}
+
+// meanPrecision averages the precision over the given iou threshold (or all of them if
+// the index is -1) and returns -1 if there is nothing to average
+func meanPrecision(precisions [][][]float64, thresholdIdx int) float64 {
+	sum := 0.0
+	count := 0
+	for _, precision := range precisions {
+		if precision == nil {
+			continue
+		}
+		for tt, ps := range precision {
+			if thresholdIdx != -1 && tt != thresholdIdx {
+				continue
+			}
+			for _, p := range ps {
+				sum += p
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return -1
+	}
+	return sum / float64(count)
+}
+
+// Evaluate computes the COCO metrics for the ground truths and detections added so far
+func (e *COCOEvaluator) Evaluate() COCOResult {
+	categories := []string{}
+	for category := range e.categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	imageIDs := map[string][]cocoKey{}
+	for key := range e.groundTruths {
+		imageIDs[key.category] = append(imageIDs[key.category], key)
+	}
+	for key := range e.detections {
+		if _, ok := e.groundTruths[key]; ok {
+			continue
+		}
+		imageIDs[key.category] = append(imageIDs[key.category], key)
+	}
+
+	t50 := e.iouThresholdIndex(0.5)
+	t75 := e.iouThresholdIndex(0.75)
+
+	// precisions is indexed by [area range][category][iou threshold][recall threshold]
+	precisions := make([][][][]float64, len(e.AreaRanges))
+	recalls := []float64{}
+	res := COCOResult{}
+	for _, category := range categories {
+		keys := imageIDs[category]
+		numGroundTruths := 0
+		numDetections := 0
+		for _, key := range keys {
+			numGroundTruths += len(e.groundTruths[key])
+			numDetections += len(e.detections[key])
+		}
+		categoryResult := COCOCategoryResult{
+			Category:        category,
+			NumGroundTruths: numGroundTruths,
+			NumDetections:   numDetections,
+			AP:              -1,
+			AP50:            -1,
+			AP75:            -1,
+		}
+		for aa, areaRange := range e.AreaRanges {
+			evals := []*cocoImageEvaluation{}
+			for _, key := range keys {
+				eval := e.evaluateImage(e.groundTruths[key], e.detections[key], areaRange)
+				if eval == nil {
+					continue
+				}
+				evals = append(evals, eval)
+			}
+			precision, recall := e.accumulate(evals)
+			precisions[aa] = append(precisions[aa], precision)
+			if aa != 0 || precision == nil {
+				continue
+			}
+			for _, r := range recall {
+				recalls = append(recalls, r)
+			}
+			single := [][][]float64{precision}
+			categoryResult.AP = meanPrecision(single, -1)
+			if t50 != -1 {
+				categoryResult.AP50 = meanPrecision(single, t50)
+				categoryResult.Precision = precision[t50]
+				categoryResult.Recall = e.RecallThresholds
+			}
+			if t75 != -1 {
+				categoryResult.AP75 = meanPrecision(single, t75)
+			}
+		}
+		res.PerCategory = append(res.PerCategory, categoryResult)
+	}
+
+	areaPrecision := func(name string, thresholdIdx int) float64 {
+		for aa, areaRange := range e.AreaRanges {
+			if areaRange.Name == name {
+				return meanPrecision(precisions[aa], thresholdIdx)
+			}
+		}
+		return -1
+	}
+
+	res.AP = areaPrecision("all", -1)
+	res.AP50 = -1
+	if t50 != -1 {
+		res.AP50 = areaPrecision("all", t50)
+	}
+	res.AP75 = -1
+	if t75 != -1 {
+		res.AP75 = areaPrecision("all", t75)
+	}
+	res.APSmall = areaPrecision("small", -1)
+	res.APMedium = areaPrecision("medium", -1)
+	res.APLarge = areaPrecision("large", -1)
+	res.AR = -1
+	if len(recalls) != 0 {
+		res.AR = Mean(recalls)
+	}
+
+	return res
+}
+
+// COCOImage is an image entry in the COCO annotation file
+type COCOImage struct {
+	ID       int64  `json:"id"`
+	FileName string `json:"file_name"`
+	Width    int    `json:"width"`
+	Height   int    `json:"height"`
+}
+
+// COCOAnnotations are the ground truths read from a COCO instances annotation file
+// (e.g. instances_val2017.json)
+type COCOAnnotations struct {
+	Images       map[string]COCOImage
+	Categories   map[int64]string
+	GroundTruths map[string][]COCOGroundTruth
+	imageIDs     map[string]string
+}
+
+// ReadCOCOAnnotations reads a COCO instances annotation file. The image ids of the ground
+// truths are the decimal representation of the COCO image ids.
+func ReadCOCOAnnotations(path string) (*COCOAnnotations, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Images      []COCOImage `json:"images"`
+		Annotations []struct {
+			ImageID    int64     `json:"image_id"`
+			CategoryID int64     `json:"category_id"`
+			BBox       []float64 `json:"bbox"`
+			Area       float64   `json:"area"`
+			IsCrowd    int       `json:"iscrowd"`
+		} `json:"annotations"`
+		Categories []struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"categories"`
+	}
+	if err := json.Unmarshal(buf, &file); err != nil {
+		return nil, err
+	}
+
+	annotations := &COCOAnnotations{
+		Images:       map[string]COCOImage{},
+		Categories:   map[int64]string{},
+		GroundTruths: map[string][]COCOGroundTruth{},
+		imageIDs:     map[string]string{},
+	}
+	for _, category := range file.Categories {
+		annotations.Categories[category.ID] = NormalizeCategory(category.Name)
+	}
+	for _, image := range file.Images {
+		id := strconv.FormatInt(image.ID, 10)
+		annotations.Images[id] = image
+		annotations.imageIDs[id] = id
+		if image.FileName != "" {
+			annotations.imageIDs[image.FileName] = id
+			annotations.imageIDs[strings.TrimSuffix(image.FileName, filepath.Ext(image.FileName))] = id
+		}
+	}
+	for _, annotation := range file.Annotations {
+		if len(annotation.BBox) != 4 {
+			continue
+		}
+		id := strconv.FormatInt(annotation.ImageID, 10)
+		x, y, w, h := annotation.BBox[0], annotation.BBox[1], annotation.BBox[2], annotation.BBox[3]
+		annotations.GroundTruths[id] = append(annotations.GroundTruths[id], COCOGroundTruth{
+			ImageID:  id,
+			Category: annotations.Categories[annotation.CategoryID],
+			Box: DetectionBox{
+				Xmin: x,
+				Ymin: y,
+				Xmax: x + w,
+				Ymax: y + h,
+			},
+			Area:    annotation.Area,
+			IsCrowd: annotation.IsCrowd != 0,
+		})
+	}
+	return annotations, nil
+}
+
+// ImageID finds the COCO image id of an input. The input id can either be the
+// image id, the file name or the path to the file.
+func (a *COCOAnnotations) ImageID(inputID string) (string, bool) {
+	for _, key := range []string{
+		inputID,
+		filepath.Base(inputID),
+		strings.TrimSuffix(filepath.Base(inputID), filepath.Ext(inputID)),
+	} {
+		if id, ok := a.imageIDs[key]; ok {
+			return id, true
+		}
+	}
+	// COCO file names are the zero padded image id
+	if n, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(inputID), filepath.Ext(inputID)), 10, 64); err == nil {
+		id := strconv.FormatInt(n, 10)
+		if _, ok := a.Images[id]; ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// NormalizeCategory is used to compare the category names of the model and of the dataset
+func NormalizeCategory(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
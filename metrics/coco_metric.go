@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rai-project/config"
+	"github.com/rai-project/dlframework"
+)
+
+// DetectionBoxFromBoundingBox converts a bounding box feature to pixel coordinates. Models output
+// either pixel coordinates or coordinates normalized to [0, 1], in which case
+// the image width and height are used to scale them.
+func DetectionBoxFromBoundingBox(box *dlframework.BoundingBox, width, height float64) DetectionBox {
+	res := DetectionBox{
+		Xmin: float64(box.GetXmin()),
+		Ymin: float64(box.GetYmin()),
+		Xmax: float64(box.GetXmax()),
+		Ymax: float64(box.GetYmax()),
+	}
+	const eps = 1e-3
+	normalized := res.Xmax <= 1+eps && res.Ymax <= 1+eps
+	if normalized && width > 0 && height > 0 {
+		res.Xmin *= width
+		res.Xmax *= width
+		res.Ymin *= height
+		res.Ymax *= height
+	}
+	return res
+}
+
+// COCOSample is the expected value of the COCO metrics for a single image
+type COCOSample struct {
+	ImageID      string
+	Width        float64
+	Height       float64
+	GroundTruths []COCOGroundTruth
+	// Categories maps the category ids to names, it is used for detections without a label
+	Categories map[int64]string
+}
+
+// Detections converts the bounding box features predicted for the image to COCO detections.
+// Detections are matched to the ground truth categories by label, falling back to the
+// category id if the label is empty.
+func (s *COCOSample) Detections(features *dlframework.Features) []COCODetection {
+	res := []COCODetection{}
+	if features == nil {
+		return res
+	}
+	for _, feature := range *features {
+		if feature == nil {
+			continue
+		}
+		bbox, ok := feature.Feature.(*dlframework.Feature_BoundingBox)
+		if !ok {
+			continue
+		}
+		box := bbox.BoundingBox
+		category := NormalizeCategory(box.GetLabel())
+		if category == "" {
+			category = s.Categories[int64(box.GetIndex())]
+		}
+		res = append(res, COCODetection{
+			ImageID:  s.ImageID,
+			Category: category,
+			Box:      DetectionBoxFromBoundingBox(box, s.Width, s.Height),
+			Score:    float64(feature.GetProbability()),
+		})
+	}
+	return res
+}
+
+// COCOAccumulator evaluates the detections of all the inputs at once, as the COCO
+// average precision is not the mean of the per image average precisions.
+// The ground truths of an image are added once, however many samples there are for the image.
+type COCOAccumulator struct {
+	Evaluator *COCOEvaluator
+	metric    func(COCOResult) float64
+	annotated map[string]bool
+}
+
+func NewCOCOAccumulator(metric func(COCOResult) float64) *COCOAccumulator {
+	return &COCOAccumulator{
+		Evaluator: NewCOCOEvaluator(),
+		metric:    metric,
+		annotated: map[string]bool{},
+	}
+}
+
+func (a *COCOAccumulator) Reset() {
+	a.Evaluator.Reset()
+	a.annotated = map[string]bool{}
+}
+
+func (a *COCOAccumulator) Add(actual *dlframework.Features, expected interface{}) error {
+	sample, ok := expected.(*COCOSample)
+	if !ok {
+		return errors.New("expecting a coco sample for second argument")
+	}
+	if !a.annotated[sample.ImageID] {
+		a.Evaluator.AddGroundTruth(sample.GroundTruths...)
+		a.annotated[sample.ImageID] = true
+	}
+	a.Evaluator.AddDetection(sample.Detections(actual)...)
+	return nil
+}
+
+func (a *COCOAccumulator) Finalize() (float64, error) {
+	res := a.metric(a.Evaluator.Evaluate())
+	if res < 0 {
+		return 0, errors.New("no ground truths were added to the accumulator")
+	}
+	return res, nil
+}
+
+func cocoMetric(name, description string, metric func(COCOResult) float64) Metric {
+	return Metric{
+		Name:         name,
+		Description:  description,
+		FeatureTypes: []dlframework.FeatureType{dlframework.FeatureType_BOUNDINGBOX},
+		Compare: func(actual *dlframework.Features, expected interface{}) (float64, error) {
+			acc := NewCOCOAccumulator(metric)
+			if err := acc.Add(actual, expected); err != nil {
+				return 0, err
+			}
+			return acc.Finalize()
+		},
+		NewAccumulator: func() Accumulator {
+			return NewCOCOAccumulator(metric)
+		},
+	}
+}
+
+func init() {
+	config.AfterInit(func() {
+		RegisterMetric(cocoMetric("MeanAveragePrecision",
+			"COCO average precision over the .50:.05:.95 iou thresholds",
+			func(r COCOResult) float64 { return r.AP }))
+		RegisterMetric(cocoMetric("MeanAveragePrecision50",
+			"COCO average precision at .50 iou (PASCAL VOC metric)",
+			func(r COCOResult) float64 { return r.AP50 }))
+		RegisterMetric(cocoMetric("MeanAveragePrecision75",
+			"COCO average precision at .75 iou",
+			func(r COCOResult) float64 { return r.AP75 }))
+		RegisterMetric(cocoMetric("AverageRecall",
+			"COCO average recall over the .50:.05:.95 iou thresholds",
+			func(r COCOResult) float64 { return r.AR }))
+	})
+}
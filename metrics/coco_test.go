@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCOCOEvaluator(t *testing.T) {
+	e := NewCOCOEvaluator()
+	e.AddGroundTruth(
+		COCOGroundTruth{ImageID: "1", Category: "dog", Box: DetectionBox{Xmin: 0, Ymin: 0, Xmax: 100, Ymax: 100}},
+		COCOGroundTruth{ImageID: "1", Category: "dog", Box: DetectionBox{Xmin: 200, Ymin: 200, Xmax: 220, Ymax: 220}},
+	)
+	e.AddDetection(
+		COCODetection{ImageID: "1", Category: "dog", Box: DetectionBox{Xmin: 0, Ymin: 0, Xmax: 100, Ymax: 100}, Score: 0.9},
+		COCODetection{ImageID: "1", Category: "dog", Box: DetectionBox{Xmin: 400, Ymin: 400, Xmax: 500, Ymax: 500}, Score: 0.8},
+	)
+
+	res := e.Evaluate()
+
+	assert.InDelta(t, 51.0/101.0, res.AP, 1e-9)
+	assert.InDelta(t, 51.0/101.0, res.AP50, 1e-9)
+	assert.InDelta(t, 51.0/101.0, res.AP75, 1e-9)
+	// the only small object was not detected
+	assert.InDelta(t, 0.0, res.APSmall, 1e-9)
+	assert.InDelta(t, 1.0, res.APLarge, 1e-9)
+	assert.InDelta(t, 0.5, res.AR, 1e-9)
+	assert.Equal(t, 1, len(res.PerCategory))
+	assert.Equal(t, 2, res.PerCategory[0].NumGroundTruths)
+}
+
+func TestInterpolatedPrecision(t *testing.T) {
+	precision, recall := PrecisionRecallCurve([]bool{true, false, true}, 2)
+	assert.Equal(t, []float64{1, 0.5, 2.0 / 3.0}, precision)
+	assert.Equal(t, []float64{0.5, 0.5, 1}, recall)
+
+	ap := AveragePrecision(precision, recall, RecallThresholds(11))
+	assert.InDelta(t, (6+5*2.0/3.0)/11.0, ap, 1e-9)
+}
+
+func TestReadCOCOAnnotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coco")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "instances_val2017.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{
+	  "images": [{"id": 139, "file_name": "000000000139.jpg", "width": 640, "height": 426}, {"id": 285, "width": 586, "height": 640}],
+	  "annotations": [
+	    {"image_id": 139, "category_id": 1, "bbox": [10, 20, 30, 40], "area": 1200, "iscrowd": 0},
+	    {"image_id": 139, "category_id": 2, "bbox": [0, 0, 5, 5], "area": 25, "iscrowd": 1},
+	    {"image_id": 285, "category_id": 1, "bbox": [1, 2, 3]}
+	  ],
+	  "categories": [{"id": 1, "name": "Person"}, {"id": 2, "name": " bicycle"}]
+	}`), 0644))
+
+	annotations, err := ReadCOCOAnnotations(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]string{1: "person", 2: "bicycle"}, annotations.Categories)
+	assert.Equal(t, 640, annotations.Images["139"].Width)
+	require.Len(t, annotations.GroundTruths["139"], 2)
+	assert.Equal(t, COCOGroundTruth{
+		ImageID:  "139",
+		Category: "person",
+		Box:      DetectionBox{Xmin: 10, Ymin: 20, Xmax: 40, Ymax: 60},
+		Area:     1200,
+	}, annotations.GroundTruths["139"][0])
+	assert.True(t, annotations.GroundTruths["139"][1].IsCrowd)
+	// the annotations without a box are skipped
+	assert.Empty(t, annotations.GroundTruths["285"])
+
+	for _, inputID := range []string{"139", "000000000139.jpg", "000000000139", "/data/val2017/000000000139.jpg", "139.png"} {
+		id, ok := annotations.ImageID(inputID)
+		assert.True(t, ok, inputID)
+		assert.Equal(t, "139", id, inputID)
+	}
+	// the image without a file name is found by its zero padded id
+	id, ok := annotations.ImageID("/data/val2017/000000000285.jpg")
+	assert.True(t, ok)
+	assert.Equal(t, "285", id)
+	_, ok = annotations.ImageID("000000000632.jpg")
+	assert.False(t, ok)
+
+	_, err = ReadCOCOAnnotations(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestDetectionBoxFromBoundingBox(t *testing.T) {
+	// the boxes within [0, 1] are normalized and scaled to the image size
+	box := DetectionBoxFromBoundingBox(&dlframework.BoundingBox{Xmin: 0.25, Ymin: 0.5, Xmax: 0.75, Ymax: 1}, 200, 100)
+	assert.InDeltaSlice(t, []float64{50, 50, 150, 100}, []float64{box.Xmin, box.Ymin, box.Xmax, box.Ymax}, 1e-6)
+
+	// the boxes in pixels are kept as is
+	box = DetectionBoxFromBoundingBox(&dlframework.BoundingBox{Xmin: 10, Ymin: 20, Xmax: 30, Ymax: 40}, 200, 100)
+	assert.Equal(t, DetectionBox{Xmin: 10, Ymin: 20, Xmax: 30, Ymax: 40}, box)
+
+	// the normalized boxes are kept as is if the image size is not known
+	box = DetectionBoxFromBoundingBox(&dlframework.BoundingBox{Xmax: 0.5, Ymax: 0.5}, 0, 0)
+	assert.Equal(t, DetectionBox{Xmax: 0.5, Ymax: 0.5}, box)
+}
+
+func TestCOCOAccumulator(t *testing.T) {
+	sample := &COCOSample{
+		ImageID:      "1",
+		Width:        100,
+		Height:       100,
+		GroundTruths: []COCOGroundTruth{{ImageID: "1", Category: "dog", Box: DetectionBox{Xmax: 50, Ymax: 50}}},
+	}
+	features := dlframework.Features{
+		{Feature: &dlframework.Feature_BoundingBox{BoundingBox: &dlframework.BoundingBox{Label: "dog", Xmax: 0.5, Ymax: 0.5}}, Probability: 0.9},
+	}
+
+	// the ground truths of an image with several predictions are added once
+	acc := NewCOCOAccumulator(func(res COCOResult) float64 { return res.AR })
+	require.NoError(t, acc.Add(&features, sample))
+	require.NoError(t, acc.Add(&dlframework.Features{}, sample))
+	res := acc.Evaluator.Evaluate()
+	require.Len(t, res.PerCategory, 1)
+	assert.Equal(t, 1, res.PerCategory[0].NumGroundTruths)
+	assert.InDelta(t, 1.0, res.AR, 1e-9)
+
+	acc.Reset()
+	require.NoError(t, acc.Add(&features, sample))
+	assert.Equal(t, 1, acc.Evaluator.Evaluate().PerCategory[0].NumGroundTruths)
+}
@@ -0,0 +1,119 @@
+package metrics
+
+// ConfusionMatrix counts the (expected, actual) label pairs.
+// The matrix grows as new labels are added.
+type ConfusionMatrix struct {
+	// Counts is indexed by [expected][actual]
+	Counts [][]int64 `json:"counts"`
+}
+
+// NewConfusionMatrix creates a confusion matrix for numClasses classes
+func NewConfusionMatrix(numClasses int) *ConfusionMatrix {
+	c := &ConfusionMatrix{}
+	c.grow(numClasses)
+	return c
+}
+
+func (c *ConfusionMatrix) grow(numClasses int) {
+	if numClasses <= len(c.Counts) {
+		return
+	}
+	for ii := range c.Counts {
+		row := make([]int64, numClasses)
+		copy(row, c.Counts[ii])
+		c.Counts[ii] = row
+	}
+	for ii := len(c.Counts); ii < numClasses; ii++ {
+		c.Counts = append(c.Counts, make([]int64, numClasses))
+	}
+}
+
+// Add records one occurrence of the (expected, actual) pair.
+// Negative labels are ignored.
+func (c *ConfusionMatrix) Add(expected, actual int) {
+	c.AddN(expected, actual, 1)
+}
+
+// AddN records n occurrences of the (expected, actual) pair.
+// Negative labels are ignored.
+func (c *ConfusionMatrix) AddN(expected, actual int, n int64) {
+	if expected < 0 || actual < 0 {
+		return
+	}
+	c.grow(maxInt(expected, actual) + 1)
+	c.Counts[expected][actual] += n
+}
+
+// NumClasses ...
+func (c *ConfusionMatrix) NumClasses() int {
+	return len(c.Counts)
+}
+
+// Total is the number of pairs recorded
+func (c *ConfusionMatrix) Total() int64 {
+	total := int64(0)
+	for _, row := range c.Counts {
+		for _, n := range row {
+			total += n
+		}
+	}
+	return total
+}
+
+// Correct is the number of pairs where the actual label is the expected label
+func (c *ConfusionMatrix) Correct() int64 {
+	correct := int64(0)
+	for ii := range c.Counts {
+		correct += c.Counts[ii][ii]
+	}
+	return correct
+}
+
+// Accuracy is the fraction of pairs where the actual label is the expected label
+func (c *ConfusionMatrix) Accuracy() float64 {
+	total := c.Total()
+	if total == 0 {
+		return 0
+	}
+	return float64(c.Correct()) / float64(total)
+}
+
+// ExpectedTotal is the number of pairs with the expected class
+func (c *ConfusionMatrix) ExpectedTotal(class int) int64 {
+	if class < 0 || class >= len(c.Counts) {
+		return 0
+	}
+	total := int64(0)
+	for _, n := range c.Counts[class] {
+		total += n
+	}
+	return total
+}
+
+// ActualTotal is the number of pairs with the actual class
+func (c *ConfusionMatrix) ActualTotal(class int) int64 {
+	if class < 0 || class >= len(c.Counts) {
+		return 0
+	}
+	total := int64(0)
+	for _, row := range c.Counts {
+		total += row[class]
+	}
+	return total
+}
+
+// ClassAccuracy is the fraction of the pairs with the expected class that were labeled correctly
+func (c *ConfusionMatrix) ClassAccuracy(class int) float64 {
+	total := c.ExpectedTotal(class)
+	if total == 0 {
+		return 0
+	}
+	return float64(c.Counts[class][class]) / float64(total)
+}
+
+func maxInt(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfusionMatrix(t *testing.T) {
+	c := NewConfusionMatrix(2)
+	c.Add(0, 0)
+	c.Add(0, 1)
+	c.Add(1, 1)
+	c.Add(3, 3)
+	c.Add(-1, 2)
+
+	assert.Equal(t, 4, c.NumClasses())
+	assert.Equal(t, int64(4), c.Total())
+	assert.Equal(t, int64(3), c.Correct())
+	assert.Equal(t, 0.75, c.Accuracy())
+	assert.Equal(t, 0.5, c.ClassAccuracy(0))
+	assert.Equal(t, 1.0, c.ClassAccuracy(1))
+	assert.Equal(t, int64(2), c.ActualTotal(1))
+	assert.Equal(t, int64(0), c.ExpectedTotal(2))
+}
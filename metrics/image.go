@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rai-project/config"
+	"github.com/rai-project/dlframework"
+)
+
+// Image is a dense image stored in row major (height, width, channels) order.
+// The pixel values are in [0, DataRange], where a zero DataRange is DefaultImageDataRange.
+type Image struct {
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Channels  int       `json:"channels"`
+	Pixels    []float64 `json:"pixels"`
+	DataRange float64   `json:"data_range,omitempty"`
+}
+
+// NewImage ...
+func NewImage(width, height, channels int) *Image {
+	return &Image{
+		Width:    width,
+		Height:   height,
+		Channels: channels,
+		Pixels:   make([]float64, width*height*channels),
+	}
+}
+
+// GetDataRange returns the range of the pixel values
+func (img *Image) GetDataRange() float64 {
+	if img.DataRange == 0 {
+		return DefaultImageDataRange
+	}
+	return img.DataRange
+}
+
+// At returns the value of the pixel channel
+func (img *Image) At(x, y, c int) float64 {
+	return img.Pixels[(y*img.Width+x)*img.Channels+c]
+}
+
+// Set sets the value of the pixel channel
+func (img *Image) Set(x, y, c int, v float64) {
+	img.Pixels[(y*img.Width+x)*img.Channels+c] = v
+}
+
+// Channel returns the plane of a single channel
+func (img *Image) Channel(c int) *Image {
+	res := NewImage(img.Width, img.Height, 1)
+	res.DataRange = img.DataRange
+	for ii := range res.Pixels {
+		res.Pixels[ii] = img.Pixels[ii*img.Channels+c]
+	}
+	return res
+}
+
+// ReadImage reads a PNG or JPEG image with pixel values in [0, 255]
+func ReadImage(path string) (*Image, error) {
+	return ReadImageWithDataRange(path, DefaultImageDataRange)
+}
+
+// ReadImageWithDataRange reads a PNG or JPEG image with pixel values in [0, dataRange]
+func ReadImageWithDataRange(path string, dataRange float64) (*Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decode the image %s", path)
+	}
+	return ImageFromImageWithDataRange(img, dataRange), nil
+}
+
+// ImageFromImage converts an image to an RGB image with pixel values in [0, 255]
+func ImageFromImage(img image.Image) *Image {
+	return ImageFromImageWithDataRange(img, DefaultImageDataRange)
+}
+
+// ImageFromImageWithDataRange converts an image to an RGB image with pixel values in [0, dataRange]
+// (e.g. 1 to compare against models which output float images)
+func ImageFromImageWithDataRange(img image.Image, dataRange float64) *Image {
+	bounds := img.Bounds()
+	res := NewImage(bounds.Dx(), bounds.Dy(), 3)
+	res.DataRange = dataRange
+	scale := dataRange / 0xffff
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			xx, yy := x-bounds.Min.X, y-bounds.Min.Y
+			res.Set(xx, yy, 0, float64(r)*scale)
+			res.Set(xx, yy, 1, float64(g)*scale)
+			res.Set(xx, yy, 2, float64(b)*scale)
+		}
+	}
+	return res
+}
+
+// ImageFromFeatures returns the first raw image feature. Images stored as a char list
+// have pixel values in [0, 255] while the range of float list images depends on the model,
+// so it is left for the caller to set.
+func ImageFromFeatures(features *dlframework.Features) (*Image, error) {
+	if features == nil {
+		return nil, errors.New("no features found")
+	}
+	for _, feature := range *features {
+		if feature == nil {
+			continue
+		}
+		raw, ok := feature.Feature.(*dlframework.Feature_RawImage)
+		if !ok {
+			continue
+		}
+		width := int(raw.RawImage.Width)
+		height := int(raw.RawImage.Height)
+		channels := int(raw.RawImage.Channels)
+		if channels == 0 {
+			channels = 3
+		}
+		img := NewImage(width, height, channels)
+		switch {
+		case len(raw.RawImage.FloatList) == len(img.Pixels):
+			for ii, v := range raw.RawImage.FloatList {
+				img.Pixels[ii] = float64(v)
+			}
+		case len(raw.RawImage.CharList) == len(img.Pixels):
+			for ii, v := range raw.RawImage.CharList {
+				img.Pixels[ii] = float64(v)
+			}
+		default:
+			return nil, errors.Errorf("the raw image data size does not match %vx%vx%v", width, height, channels)
+		}
+		return img, nil
+	}
+	return nil, errors.New("expecting a raw image feature")
+}
+
+func checkImageShapes(actual, expected *Image) error {
+	if actual.Width != expected.Width || actual.Height != expected.Height || actual.Channels != expected.Channels {
+		return errors.Errorf("the image shape %vx%vx%v does not match the reference shape %vx%vx%v",
+			actual.Width, actual.Height, actual.Channels,
+			expected.Width, expected.Height, expected.Channels,
+		)
+	}
+	return nil
+}
+
+// imageCompareFunction compares the predicted image against the expected image, whose data range
+// (e.g. from ReadImageWithDataRange) is also the data range of the predicted image
+func imageCompareFunction(metric func(actual, expected *Image) (float64, error)) FeatureCompareFunction {
+	return func(actual *dlframework.Features, expected interface{}) (float64, error) {
+		expectedImage, ok := expected.(*Image)
+		if !ok {
+			return 0, errors.New("expecting an image for second argument")
+		}
+		actualImage, err := ImageFromFeatures(actual)
+		if err != nil {
+			return 0, err
+		}
+		actualImage.DataRange = expectedImage.DataRange
+		return metric(actualImage, expectedImage)
+	}
+}
+
+func imageMetric(name, description string, metric func(actual, expected *Image) (float64, error)) Metric {
+	return Metric{
+		Name:         name,
+		Description:  description,
+		FeatureTypes: []dlframework.FeatureType{dlframework.FeatureType_RAW_IMAGE},
+		Compare:      imageCompareFunction(metric),
+	}
+}
+
+func init() {
+	config.AfterInit(func() {
+		RegisterMetric(imageMetric("PeakSignalToNoiseRatio",
+			"peak signal to noise ratio against the reference image",
+			func(actual, expected *Image) (float64, error) {
+				if err := checkImageShapes(actual, expected); err != nil {
+					return 0, err
+				}
+				return PeakSignalToNoiseRatioWithDataRange(actual.Pixels, expected.Pixels, expected.GetDataRange()), nil
+			}))
+		RegisterMetric(imageMetric("StructuralSimilarity",
+			"structural similarity (SSIM) against the reference image",
+			func(actual, expected *Image) (float64, error) {
+				return StructuralSimilarity(actual, expected, expected.GetDataRange())
+			}))
+		RegisterMetric(imageMetric("MultiScaleStructuralSimilarity",
+			"multi scale structural similarity (MS-SSIM) against the reference image",
+			func(actual, expected *Image) (float64, error) {
+				return MultiScaleStructuralSimilarity(actual, expected, expected.GetDataRange())
+			}))
+		RegisterMetric(imageMetric("PerceptualIndex",
+			"PIRM perceptual index computed from the registered NIQE and Ma scores",
+			func(actual, _ *Image) (float64, error) {
+				return PerceptualIndex(actual)
+			}))
+	})
+}
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"github.com/chewxy/math32"
+	"github.com/pkg/errors"
 	"github.com/rai-project/config"
 	"github.com/rai-project/dlframework"
 )
@@ -33,30 +34,39 @@ func BoundingBoxIntersectionOverUnion(boxA, boxB *dlframework.BoundingBox) float
 	return iou
 }
 
-func IntersectionOverUnion(featA, featB *dlframework.Feature) float64 {
+func IntersectionOverUnion(featA, featB *dlframework.Feature) (float64, error) {
 	boxA, ok := featA.Feature.(*dlframework.Feature_BoundingBox)
 	if !ok {
-		panic("unable to convert first feature to boundingbox")
+		return 0, errors.New("unable to convert first feature to boundingbox")
 	}
 	boxB, ok := featB.Feature.(*dlframework.Feature_BoundingBox)
 	if !ok {
-		panic("unable to convert second feature to boundingbox")
+		return 0, errors.New("unable to convert second feature to boundingbox")
+	}
+	return BoundingBoxIntersectionOverUnion(boxA.BoundingBox, boxB.BoundingBox), nil
+}
+
+// boundingBoxCompareFunction compares a single predicted bounding box against the expected bounding box feature
+func boundingBoxCompareFunction(compare func(featA, featB *dlframework.Feature) (float64, error)) FeatureCompareFunction {
+	return func(actual *dlframework.Features, expected interface{}) (float64, error) {
+		if actual == nil || len(*actual) != 1 {
+			return 0, errors.New("expecting one feature for argument")
+		}
+		expectedFeature, ok := expected.(*dlframework.Feature)
+		if !ok {
+			return 0, errors.New("expecting a feature for second argument")
+		}
+		return compare((*actual)[0], expectedFeature)
 	}
-	return BoundingBoxIntersectionOverUnion(boxA.BoundingBox, boxB.BoundingBox)
 }
 
 func init() {
 	config.AfterInit(func() {
-		RegisterFeatureCompareFunction("IntersectionOverUnion",
-			func(actual *dlframework.Features, expected interface{}) float64 {
-				if actual == nil || len(*actual) != 1 {
-					panic("expecting one feature for argument")
-				}
-				expectedFeature, ok := expected.(*dlframework.Feature)
-				if !ok {
-					panic("expecting a feature for second argument")
-				}
-				return IntersectionOverUnion((*actual)[0], expectedFeature)
-			})
+		RegisterMetric(Metric{
+			Name:         "IntersectionOverUnion",
+			Description:  "intersection over union of a predicted and an expected bounding box",
+			FeatureTypes: []dlframework.FeatureType{dlframework.FeatureType_BOUNDINGBOX},
+			Compare:      boundingBoxCompareFunction(IntersectionOverUnion),
+		})
 	})
 }
@@ -23,7 +23,8 @@ func TestIOU(t *testing.T) {
 		feature.BoundingBoxYmax(114),
 	)
 
-	iou := IntersectionOverUnion(boxA, boxB)
+	iou, err := IntersectionOverUnion(boxA, boxB)
 
+	assert.NoError(t, err)
 	assert.Equal(t, iou, 0.7957712638154734)
 }
@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"github.com/pkg/errors"
 	"github.com/rai-project/config"
 	"github.com/rai-project/dlframework"
 )
@@ -26,30 +27,25 @@ func BoundingBoxJaccard(boxA, boxB *dlframework.BoundingBox) float64 {
 	return intersection / union
 }
 
-func Jaccard(featA, featB *dlframework.Feature) float64 {
+func Jaccard(featA, featB *dlframework.Feature) (float64, error) {
 	boxA, ok := featA.Feature.(*dlframework.Feature_BoundingBox)
 	if !ok {
-		panic("unable to convert first feature to boundingbox")
+		return 0, errors.New("unable to convert first feature to boundingbox")
 	}
 	boxB, ok := featB.Feature.(*dlframework.Feature_BoundingBox)
 	if !ok {
-		panic("unable to convert second feature to boundingbox")
+		return 0, errors.New("unable to convert second feature to boundingbox")
 	}
-	return BoundingBoxJaccard(boxA.BoundingBox, boxB.BoundingBox)
+	return BoundingBoxJaccard(boxA.BoundingBox, boxB.BoundingBox), nil
 }
 
 func init() {
 	config.AfterInit(func() {
-		RegisterFeatureCompareFunction("Jaccard",
-			func(actual *dlframework.Features, expected interface{}) float64 {
-				if actual == nil || len(*actual) != 1 {
-					panic("expecting one feature for argument")
-				}
-				expectedFeature, ok := expected.(*dlframework.Feature)
-				if !ok {
-					panic("expecting a feature for second argument")
-				}
-				return Jaccard((*actual)[0], expectedFeature)
-			})
+		RegisterMetric(Metric{
+			Name:         "Jaccard",
+			Description:  "jaccard overlap of a predicted and an expected bounding box",
+			FeatureTypes: []dlframework.FeatureType{dlframework.FeatureType_BOUNDINGBOX},
+			Compare:      boundingBoxCompareFunction(Jaccard),
+		})
 	})
 }
@@ -1,3 +1,454 @@
 package metrics
 
+import (
+	"bufio"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 // https://github.com/ctuning/ck-tensorflow/blob/master/program/kitti-eval-tool/evaluate_object.cpp
+
+// KITTIDifficulty is the difficulty bucket of the ground truth
+type KITTIDifficulty int
+
+const (
+	KITTIEasy KITTIDifficulty = iota
+	KITTIModerate
+	KITTIHard
+)
+
+// KITTIDifficulties are the difficulties the evaluation is performed on
+var KITTIDifficulties = []KITTIDifficulty{KITTIEasy, KITTIModerate, KITTIHard}
+
+func (d KITTIDifficulty) String() string {
+	switch d {
+	case KITTIEasy:
+		return "easy"
+	case KITTIModerate:
+		return "moderate"
+	case KITTIHard:
+		return "hard"
+	}
+	return "unknown"
+}
+
+var (
+	// KITTIClasses are the classes evaluated by the KITTI 2D object benchmark
+	KITTIClasses = []string{"car", "pedestrian", "cyclist"}
+	// COCOToKITTILabels maps the labels of the models trained on COCO to the KITTI object types.
+	// The COCO bicycle box does not include its rider, so the cyclists are only roughly matched.
+	COCOToKITTILabels = map[string]string{
+		"person":  "pedestrian",
+		"bicycle": "cyclist",
+		"car":     "car",
+		"truck":   "truck",
+		"train":   "tram",
+	}
+	// KITTIMinOverlap is the minimum overlap for a detection to be counted as a true positive
+	KITTIMinOverlap = map[string]float64{
+		"car":        0.7,
+		"pedestrian": 0.5,
+		"cyclist":    0.5,
+	}
+	// minimum height of the ground truth boxes (in pixels) for each difficulty
+	kittiMinHeight = []float64{40, 25, 25}
+	// maximum occlusion level of the ground truth for each difficulty
+	kittiMaxOcclusion = []int{0, 1, 2}
+	// maximum truncation of the ground truth for each difficulty
+	kittiMaxTruncation = []float64{0.15, 0.3, 0.5}
+	// classes whose detections are neither counted as true nor false positives
+	kittiNeighborClasses = map[string]string{
+		"car":        "van",
+		"pedestrian": "person_sitting",
+	}
+)
+
+const (
+	kittiNumSamplePoints = 41
+	kittiNoDetection     = -10000000.0
+)
+
+// KITTIObject is an object in a KITTI label file. Only the fields used by the 2D
+// evaluation are kept.
+type KITTIObject struct {
+	Type       string       `json:"type"`
+	Truncation float64      `json:"truncation"`
+	Occlusion  int          `json:"occlusion"`
+	Alpha      float64      `json:"alpha"`
+	Box        DetectionBox `json:"box"`
+	Score      float64      `json:"score,omitempty"`
+}
+
+// ReadKITTILabels reads a KITTI label file
+func ReadKITTILabels(path string) ([]KITTIObject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseKITTILabels(f)
+}
+
+// ParseKITTILabels parses the objects in the KITTI label format
+//
+//	type truncated occluded alpha x1 y1 x2 y2 h w l x y z ry [score]
+func ParseKITTILabels(r io.Reader) ([]KITTIObject, error) {
+	objects := []KITTIObject{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 8 {
+			return nil, errors.Errorf("invalid kitti label line %v", scanner.Text())
+		}
+		vals := make([]float64, len(fields))
+		for ii, field := range fields[1:] {
+			val, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid kitti label line %v", scanner.Text())
+			}
+			vals[ii+1] = val
+		}
+		object := KITTIObject{
+			Type:       fields[0],
+			Truncation: vals[1],
+			Occlusion:  int(vals[2]),
+			Alpha:      vals[3],
+			Box: DetectionBox{
+				Xmin: vals[4],
+				Ymin: vals[5],
+				Xmax: vals[6],
+				Ymax: vals[7],
+			},
+		}
+		if len(fields) > 15 {
+			object.Score = vals[15]
+		}
+		objects = append(objects, object)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// kittiOverlap computes the overlap between a and b. If normalizeByA is true then the
+// intersection is divided by the area of a, otherwise by the union.
+func kittiOverlap(a, b DetectionBox, normalizeByA bool) float64 {
+	intersection := a.Intersection(b)
+	if intersection == 0 {
+		return 0
+	}
+	if normalizeByA {
+		return intersection / a.Area()
+	}
+	return intersection / (a.Area() + b.Area() - intersection)
+}
+
+type kittiImage struct {
+	groundTruths []KITTIObject
+	detections   []KITTIObject
+}
+
+// KITTIEvaluator computes the KITTI 2D object detection average precision
+type KITTIEvaluator struct {
+	// RecallPoints40 uses the 40 recall points introduced in 2019 instead of 11 recall points
+	RecallPoints40 bool
+	images         []kittiImage
+}
+
+// NewKITTIEvaluator ...
+func NewKITTIEvaluator() *KITTIEvaluator {
+	return &KITTIEvaluator{}
+}
+
+// Reset removes the ground truths and detections
+func (e *KITTIEvaluator) Reset() {
+	e.images = nil
+}
+
+// Add adds the ground truths and detections of an image
+func (e *KITTIEvaluator) Add(groundTruths, detections []KITTIObject) {
+	e.images = append(e.images, kittiImage{
+		groundTruths: groundTruths,
+		detections:   detections,
+	})
+}
+
+// KITTIClassResult is the result for one class, the values are indexed by difficulty
+type KITTIClassResult struct {
+	Class           string      `json:"class"`
+	NumGroundTruths []int       `json:"num_ground_truths"`
+	AP              []float64   `json:"ap"`
+	Precision       [][]float64 `json:"precision,omitempty"`
+	Recall          [][]float64 `json:"recall,omitempty"`
+}
+
+// KITTIResult is the result of the KITTI evaluation. The AP values are -1 if there is
+// no ground truth to compute them with.
+type KITTIResult struct {
+	PerClass []KITTIClassResult `json:"per_class"`
+}
+
+// Class returns the result of a class
+func (r KITTIResult) Class(class string) (KITTIClassResult, bool) {
+	for _, c := range r.PerClass {
+		if c.Class == class {
+			return c, true
+		}
+	}
+	return KITTIClassResult{}, false
+}
+
+// cleanData marks the ground truths and detections which are ignored
+// (-1 is a different class, 1 is ignored and 0 is used)
+func kittiCleanData(class string, image kittiImage, difficulty KITTIDifficulty) (ignoredGroundTruths []int, dontCares []DetectionBox, ignoredDetections []int, numGroundTruths int) {
+	for _, gt := range image.groundTruths {
+		gtClass := NormalizeCategory(gt.Type)
+		validClass := -1
+		if gtClass == class {
+			validClass = 1
+		} else if neighbor, ok := kittiNeighborClasses[class]; ok && neighbor == gtClass {
+			validClass = 0
+		}
+
+		height := math.Abs(gt.Box.Ymax - gt.Box.Ymin)
+		ignore := gt.Occlusion > kittiMaxOcclusion[difficulty] ||
+			gt.Truncation > kittiMaxTruncation[difficulty] ||
+			height <= kittiMinHeight[difficulty]
+
+		switch {
+		case validClass == 1 && !ignore:
+			ignoredGroundTruths = append(ignoredGroundTruths, 0)
+			numGroundTruths++
+		case validClass == 0 || (ignore && validClass == 1):
+			ignoredGroundTruths = append(ignoredGroundTruths, 1)
+		default:
+			ignoredGroundTruths = append(ignoredGroundTruths, -1)
+		}
+
+		if gtClass == "dontcare" {
+			dontCares = append(dontCares, gt.Box)
+		}
+	}
+
+	for _, det := range image.detections {
+		height := math.Abs(det.Box.Ymax - det.Box.Ymin)
+		switch {
+		case height < kittiMinHeight[difficulty]:
+			ignoredDetections = append(ignoredDetections, 1)
+		case NormalizeCategory(det.Type) == class:
+			ignoredDetections = append(ignoredDetections, 0)
+		default:
+			ignoredDetections = append(ignoredDetections, -1)
+		}
+	}
+	return
+}
+
+type kittiStatistics struct {
+	tp     int
+	fp     int
+	fn     int
+	scores []float64
+}
+
+func kittiComputeStatistics(minOverlap float64, image kittiImage, dontCares []DetectionBox, ignoredGroundTruths, ignoredDetections []int, computeFalsePositives bool, threshold float64) kittiStatistics {
+	stat := kittiStatistics{}
+	dets := image.detections
+	assignedDetection := make([]bool, len(dets))
+	ignoredThreshold := make([]bool, len(dets))
+	if computeFalsePositives {
+		for ii, det := range dets {
+			ignoredThreshold[ii] = det.Score < threshold
+		}
+	}
+
+	for ii, gt := range image.groundTruths {
+		if ignoredGroundTruths[ii] == -1 {
+			continue
+		}
+		detIdx := -1
+		validDetection := kittiNoDetection
+		maxOverlap := 0.0
+		assignedIgnoredDetection := false
+		for jj, det := range dets {
+			if ignoredDetections[jj] == -1 || assignedDetection[jj] || ignoredThreshold[jj] {
+				continue
+			}
+			overlap := kittiOverlap(det.Box, gt.Box, false)
+			if overlap <= minOverlap {
+				continue
+			}
+			switch {
+			case !computeFalsePositives && det.Score > validDetection:
+				detIdx = jj
+				validDetection = det.Score
+			case computeFalsePositives && (overlap > maxOverlap || assignedIgnoredDetection) && ignoredDetections[jj] == 0:
+				maxOverlap = overlap
+				detIdx = jj
+				validDetection = 1
+				assignedIgnoredDetection = false
+			case computeFalsePositives && validDetection == kittiNoDetection && ignoredDetections[jj] == 1:
+				detIdx = jj
+				validDetection = 1
+				assignedIgnoredDetection = true
+			}
+		}
+
+		switch {
+		case validDetection == kittiNoDetection && ignoredGroundTruths[ii] == 0:
+			stat.fn++
+		case validDetection != kittiNoDetection && (ignoredGroundTruths[ii] == 1 || ignoredDetections[detIdx] == 1):
+			assignedDetection[detIdx] = true
+		case validDetection != kittiNoDetection:
+			stat.tp++
+			stat.scores = append(stat.scores, dets[detIdx].Score)
+			assignedDetection[detIdx] = true
+		}
+	}
+
+	if !computeFalsePositives {
+		return stat
+	}
+
+	for ii := range dets {
+		if !(assignedDetection[ii] || ignoredDetections[ii] == -1 || ignoredDetections[ii] == 1 || ignoredThreshold[ii]) {
+			stat.fp++
+		}
+	}
+
+	// detections in the don't care areas are not false positives
+	numDontCare := 0
+	for _, dc := range dontCares {
+		for jj, det := range dets {
+			if assignedDetection[jj] || ignoredDetections[jj] == -1 || ignoredDetections[jj] == 1 || ignoredThreshold[jj] {
+				continue
+			}
+			if kittiOverlap(det.Box, dc, true) > minOverlap {
+				assignedDetection[jj] = true
+				numDontCare++
+			}
+		}
+	}
+	stat.fp -= numDontCare
+
+	return stat
+}
+
+// kittiThresholds picks the score thresholds which sample the recall at kittiNumSamplePoints points
+func kittiThresholds(scores []float64, numGroundTruths int) []float64 {
+	sort.Sort(sort.Reverse(sort.Float64Slice(scores)))
+	thresholds := []float64{}
+	currentRecall := 0.0
+	for ii, score := range scores {
+		leftRecall := float64(ii+1) / float64(numGroundTruths)
+		rightRecall := leftRecall
+		if ii < len(scores)-1 {
+			rightRecall = float64(ii+2) / float64(numGroundTruths)
+		}
+		if (rightRecall-currentRecall) < (currentRecall-leftRecall) && ii < len(scores)-1 {
+			continue
+		}
+		thresholds = append(thresholds, score)
+		currentRecall += 1.0 / (kittiNumSamplePoints - 1.0)
+	}
+	return thresholds
+}
+
+func (e *KITTIEvaluator) evaluateClass(class string, difficulty KITTIDifficulty) (precision []float64, recall []float64, numGroundTruths int) {
+	minOverlap := KITTIMinOverlap[class]
+
+	ignoredGroundTruths := make([][]int, len(e.images))
+	ignoredDetections := make([][]int, len(e.images))
+	dontCares := make([][]DetectionBox, len(e.images))
+	scores := []float64{}
+	for ii, image := range e.images {
+		igt, dc, idet, n := kittiCleanData(class, image, difficulty)
+		ignoredGroundTruths[ii] = igt
+		ignoredDetections[ii] = idet
+		dontCares[ii] = dc
+		numGroundTruths += n
+		stat := kittiComputeStatistics(minOverlap, image, dc, igt, idet, false, 0)
+		scores = append(scores, stat.scores...)
+	}
+	if numGroundTruths == 0 {
+		return nil, nil, 0
+	}
+
+	thresholds := kittiThresholds(scores, numGroundTruths)
+	stats := make([]kittiStatistics, len(thresholds))
+	for ii, image := range e.images {
+		for tt, threshold := range thresholds {
+			stat := kittiComputeStatistics(minOverlap, image, dontCares[ii], ignoredGroundTruths[ii], ignoredDetections[ii], true, threshold)
+			stats[tt].tp += stat.tp
+			stats[tt].fp += stat.fp
+			stats[tt].fn += stat.fn
+		}
+	}
+
+	precision = make([]float64, kittiNumSamplePoints)
+	recall = make([]float64, kittiNumSamplePoints)
+	for tt, stat := range stats {
+		if stat.tp+stat.fn > 0 {
+			recall[tt] = float64(stat.tp) / float64(stat.tp+stat.fn)
+		}
+		if stat.tp+stat.fp > 0 {
+			precision[tt] = float64(stat.tp) / float64(stat.tp+stat.fp)
+		}
+	}
+	for tt := range stats {
+		for jj := tt; jj < len(stats); jj++ {
+			precision[tt] = math.Max(precision[tt], precision[jj])
+		}
+	}
+	return precision, recall, numGroundTruths
+}
+
+func (e *KITTIEvaluator) averagePrecision(precision []float64) float64 {
+	sum := 0.0
+	if e.RecallPoints40 {
+		for ii := 1; ii < len(precision); ii++ {
+			sum += precision[ii]
+		}
+		return sum / 40.0
+	}
+	for ii := 0; ii < len(precision); ii += 4 {
+		sum += precision[ii]
+	}
+	return sum / 11.0
+}
+
+// Evaluate computes the per class average precision for each difficulty
+func (e *KITTIEvaluator) Evaluate() KITTIResult {
+	res := KITTIResult{}
+	for _, class := range KITTIClasses {
+		classResult := KITTIClassResult{
+			Class:           class,
+			NumGroundTruths: make([]int, len(KITTIDifficulties)),
+			AP:              make([]float64, len(KITTIDifficulties)),
+			Precision:       make([][]float64, len(KITTIDifficulties)),
+			Recall:          make([][]float64, len(KITTIDifficulties)),
+		}
+		for ii, difficulty := range KITTIDifficulties {
+			precision, recall, numGroundTruths := e.evaluateClass(class, difficulty)
+			classResult.NumGroundTruths[ii] = numGroundTruths
+			classResult.Precision[ii] = precision
+			classResult.Recall[ii] = recall
+			classResult.AP[ii] = -1
+			if numGroundTruths != 0 {
+				classResult.AP[ii] = e.averagePrecision(precision)
+			}
+		}
+		res.PerClass = append(res.PerClass, classResult)
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKITTIEvaluator(t *testing.T) {
+	groundTruths, err := ParseKITTILabels(strings.NewReader(`Car 0.00 0 -1.58 587.01 173.33 614.12 200.12 1.65 1.67 3.64 -0.65 1.71 46.70 -1.59
+Car 0.00 0 1.85 387.63 181.54 423.81 203.12 1.67 1.87 3.69 -16.53 2.39 58.49 1.57
+Pedestrian 0.00 0 -0.20 712.40 143.00 810.73 307.92 1.89 0.48 1.20 1.84 1.47 8.41 0.01
+DontCare -1 -1 -10 800.38 163.67 825.45 184.07 -1 -1 -1 -1000 -1000 -1000 -10
+`))
+	assert.NoError(t, err)
+	assert.Len(t, groundTruths, 4)
+	assert.Equal(t, 143.0, groundTruths[2].Box.Ymin)
+
+	detections, err := ParseKITTILabels(strings.NewReader(`Pedestrian -1 -1 -10 712.40 143.00 810.73 307.92 -1 -1 -1 -1000 -1000 -1000 -10 0.9
+Pedestrian -1 -1 -10 100 100 150 200 -1 -1 -1 -1000 -1000 -1000 -10 0.8
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 0.8, detections[1].Score)
+
+	e := NewKITTIEvaluator()
+	e.Add(groundTruths, detections)
+	res := e.Evaluate()
+
+	car, ok := res.Class("car")
+	assert.True(t, ok)
+	// the cars are shorter than 40 pixels and the second one is shorter than 25 pixels
+	assert.Equal(t, []int{0, 1, 1}, car.NumGroundTruths)
+	assert.Equal(t, -1.0, car.AP[0])
+	assert.Equal(t, 0.0, car.AP[1])
+
+	pedestrian, ok := res.Class("pedestrian")
+	assert.True(t, ok)
+	assert.Equal(t, []int{1, 1, 1}, pedestrian.NumGroundTruths)
+	assert.InDelta(t, 1.0/11.0, pedestrian.AP[0], 1e-9)
+}
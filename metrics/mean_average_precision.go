@@ -1,23 +1,73 @@
 package metrics
 
+import "sort"
+
 // https://github.com/alonewithyou/GoPredictor/blob/master/Measurement/metrics.go#L127
 // https://github.com/ariaaan/mean-average-precision-calculation/blob/master/measure_map.py#L9
 // https://forums.fast.ai/t/mean-average-precision-map/14345
 
-// func ClassificationTop1(features *dlframework.Features, expectedLabelIndex int) bool {
-// 	for _, feature := range []*dlframework.Feature(*features) {
-// 		_, ok := feature.Feature.(*dlframework.Feature_Classification)
-// 		if !ok {
-// 			panic("unable to convert first feature to boundingbox")
-// 		}
-// 	}
+// RecallThresholds returns n evenly spaced recall thresholds in [0, 1]
+// (101 for COCO, 11 for PASCAL VOC 2007)
+func RecallThresholds(n int) []float64 {
+	if n == 1 {
+		return []float64{0}
+	}
+	res := make([]float64, n)
+	for ii := range res {
+		res[ii] = float64(ii) / float64(n-1)
+	}
+	return res
+}
+
+// PrecisionRecallCurve computes the precision and recall after each detection.
+// The detections must be sorted by decreasing score and truePositives marks the
+// detections that matched a ground truth.
+func PrecisionRecallCurve(truePositives []bool, numGroundTruths int) (precision []float64, recall []float64) {
+	precision = make([]float64, len(truePositives))
+	recall = make([]float64, len(truePositives))
+	tp, fp := 0.0, 0.0
+	for ii, isTruePositive := range truePositives {
+		if isTruePositive {
+			tp++
+		} else {
+			fp++
+		}
+		precision[ii] = tp / (tp + fp)
+		if numGroundTruths > 0 {
+			recall[ii] = tp / float64(numGroundTruths)
+		}
+	}
+	return precision, recall
+}
 
-// 	sort.Sort(features)
+// InterpolatedPrecision returns the precision at each of the recall thresholds.
+// The precision at a recall threshold is the maximum precision at any recall
+// greater or equal to the threshold, or 0 if the threshold is never reached.
+func InterpolatedPrecision(precision, recall, recallThresholds []float64) []float64 {
+	envelope := make([]float64, len(precision))
+	copy(envelope, precision)
+	for ii := len(envelope) - 1; ii > 0; ii-- {
+		if envelope[ii] > envelope[ii-1] {
+			envelope[ii-1] = envelope[ii]
+		}
+	}
 
-// 	actualLabelIndex := int([]*dlframework.Feature(*features)[0].Feature.(*dlframework.Feature_Classification).Classification.Index)
-// 	return actualLabelIndex == expectedLabelIndex
-// }
+	res := make([]float64, len(recallThresholds))
+	for ii, threshold := range recallThresholds {
+		idx := sort.Search(len(recall), func(jj int) bool {
+			return recall[jj] >= threshold
+		})
+		if idx < len(envelope) {
+			res[ii] = envelope[idx]
+		}
+	}
+	return res
+}
 
-// func MeanAveragePrecision(features *dlframework.Features, expectedLabelIndex int) bool {
-// 	return ClassificationTop1(features, expectedLabelIndex)
-// }
+// AveragePrecision is the mean of the interpolated precision at the recall thresholds
+func AveragePrecision(precision, recall, recallThresholds []float64) float64 {
+	if len(recallThresholds) == 0 {
+		return 0
+	}
+	return Mean(InterpolatedPrecision(precision, recall, recallThresholds))
+}
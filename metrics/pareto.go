@@ -0,0 +1,43 @@
+package metrics
+
+import "sort"
+
+// ParetoFrontier returns whether each point is pareto optimal when minimizing the cost and
+// maximizing the benefit. A point is optimal if no other point has a lower or equal cost and
+// a higher or equal benefit with at least one of them strictly better.
+func ParetoFrontier(costs, benefits []float64) []bool {
+	n := len(costs)
+	if n != len(benefits) {
+		panic("length not equal")
+	}
+	order := make([]int, n)
+	for ii := range order {
+		order[ii] = ii
+	}
+	sort.SliceStable(order, func(ii, jj int) bool {
+		a, b := order[ii], order[jj]
+		if costs[a] != costs[b] {
+			return costs[a] < costs[b]
+		}
+		return benefits[a] > benefits[b]
+	})
+
+	res := make([]bool, n)
+	for ii := 0; ii < n; ii++ {
+		idx := order[ii]
+		optimal := true
+		// all the points before have a lower or equal cost
+		for jj := 0; jj < ii; jj++ {
+			other := order[jj]
+			if benefits[other] < benefits[idx] {
+				continue
+			}
+			if costs[other] < costs[idx] || benefits[other] > benefits[idx] {
+				optimal = false
+				break
+			}
+		}
+		res[idx] = optimal
+	}
+	return res
+}
@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParetoFrontier(t *testing.T) {
+	latencies := []float64{10, 20, 15, 30, 10, 25}
+	accuracies := []float64{0.70, 0.80, 0.65, 0.79, 0.70, 0.81}
+
+	frontier := ParetoFrontier(latencies, accuracies)
+
+	// the duplicate points are both optimal, the third and fourth points are dominated
+	assert.Equal(t, []bool{true, true, false, false, true, true}, frontier)
+}
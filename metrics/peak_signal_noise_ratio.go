@@ -2,12 +2,35 @@ package metrics
 
 import "math"
 
+// DefaultImageDataRange is the data range of 8 bit images
+var DefaultImageDataRange = 255.0
+
 /*
-	Suppose pixel values in [0,1]
-	refer https://en.wikipedia.org/wiki/Peak_signal-to-noise_ratio
+Suppose pixel values in [0,1]
+refer https://en.wikipedia.org/wiki/Peak_signal-to-noise_ratio
 */
 func PeakSignalToNoiseRatio(input, reference []float64) float64 {
+	return PeakSignalToNoiseRatioWithDataRange(input, reference, 1.0)
+}
+
+// PeakSignalToNoiseRatioWithDataRange computes the PSNR for pixel values in [0, dataRange].
+// The PSNR is +Inf if the input and reference are identical.
+func PeakSignalToNoiseRatioWithDataRange(input, reference []float64, dataRange float64) float64 {
 	mse := MeanSquaredError(input, reference)
-	ret := 20*math.Log10(1.0) - 10*math.Log10(float64(mse))
-	return ret
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 20*math.Log10(dataRange) - 10*math.Log10(mse)
+}
+
+// ImagePeakSignalToNoiseRatio computes the PSNR of each channel of the image
+func ImagePeakSignalToNoiseRatio(actual, expected *Image, dataRange float64) ([]float64, error) {
+	if err := checkImageShapes(actual, expected); err != nil {
+		return nil, err
+	}
+	res := make([]float64, actual.Channels)
+	for c := range res {
+		res[c] = PeakSignalToNoiseRatioWithDataRange(actual.Channel(c).Pixels, expected.Channel(c).Pixels, dataRange)
+	}
+	return res, nil
 }
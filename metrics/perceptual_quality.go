@@ -1,6 +1,150 @@
 package metrics
 
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
 // https://www.pirm2018.org/PIRM-SR.html
 // \text{Perceptual index} = \tfrac{1}{2} ((10 - \text{Ma}) + \text{NIQE}).
 // https://sites.google.com/site/chaoma99/sr-metric
 // https://ieeexplore.ieee.org/document/6353522/
+
+// There is no Go implementation of NIQE and the Ma et al. score (both rely on trained models),
+// so they are provided by registering a NoReferenceQualityFunction. CommandNoReferenceQualityFunction
+// can be used to call an external implementation (e.g. the matlab PIRM toolbox).
+
+const (
+	NIQEName = "NIQE"
+	MaName   = "Ma"
+)
+
+// NoReferenceQualityFunction computes a quality score of an image without a reference image.
+// The image pixel values are in [0, img.GetDataRange()].
+type NoReferenceQualityFunction func(img *Image) (float64, error)
+
+type noReferenceQualityRegistryMap struct {
+	fs map[string]NoReferenceQualityFunction
+	sync.RWMutex
+}
+
+var noReferenceQualityRegistry = noReferenceQualityRegistryMap{
+	fs: map[string]NoReferenceQualityFunction{},
+}
+
+func RegisterNoReferenceQualityFunction(name string, f NoReferenceQualityFunction) {
+	noReferenceQualityRegistry.Lock()
+	noReferenceQualityRegistry.fs[name] = f
+	noReferenceQualityRegistry.Unlock()
+}
+
+func GetNoReferenceQualityFunction(name string) NoReferenceQualityFunction {
+	noReferenceQualityRegistry.RLock()
+	f, ok := noReferenceQualityRegistry.fs[name]
+	noReferenceQualityRegistry.RUnlock()
+	if !ok {
+		return nil
+	}
+	return f
+}
+
+// NoReferenceQuality computes the score of the image using the registered function
+func NoReferenceQuality(name string, img *Image) (float64, error) {
+	f := GetNoReferenceQualityFunction(name)
+	if f == nil {
+		return 0, errors.Errorf("no %s quality function has been registered", name)
+	}
+	return f(img)
+}
+
+// NaturalnessImageQualityEvaluator computes the NIQE score (lower is better) of the image
+func NaturalnessImageQualityEvaluator(img *Image) (float64, error) {
+	return NoReferenceQuality(NIQEName, img)
+}
+
+// MaScore computes the Ma et al. score (higher is better) of the image
+func MaScore(img *Image) (float64, error) {
+	return NoReferenceQuality(MaName, img)
+}
+
+// PerceptualIndexFromScores computes the PIRM perceptual index (lower is better)
+func PerceptualIndexFromScores(ma, niqe float64) float64 {
+	return ((10 - ma) + niqe) / 2
+}
+
+// PerceptualIndex computes the PIRM perceptual index of the image using the registered NIQE and Ma functions
+func PerceptualIndex(img *Image) (float64, error) {
+	ma, err := MaScore(img)
+	if err != nil {
+		return 0, err
+	}
+	niqe, err := NaturalnessImageQualityEvaluator(img)
+	if err != nil {
+		return 0, err
+	}
+	return PerceptualIndexFromScores(ma, niqe), nil
+}
+
+// CommandNoReferenceQualityFunction returns a quality function which writes the image to a
+// temporary PNG file and runs the command with the file path as the last argument.
+// The pixel values are rescaled from the data range of the image to [0, 255] in the file.
+// The score is the last value printed by the command.
+func CommandNoReferenceQualityFunction(command string, args ...string) NoReferenceQualityFunction {
+	return func(img *Image) (float64, error) {
+		f, err := ioutil.TempFile("", "evaluation-image-*.png")
+		if err != nil {
+			return 0, err
+		}
+		defer os.Remove(f.Name())
+
+		err = png.Encode(f, img.toImage())
+		f.Close()
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to encode the image")
+		}
+
+		var stdout bytes.Buffer
+		cmd := exec.Command(command, append(args, f.Name())...)
+		cmd.Stdout = &stdout
+		if err := cmd.Run(); err != nil {
+			return 0, errors.Wrapf(err, "failed to run %s", command)
+		}
+		fields := strings.Fields(stdout.String())
+		if len(fields) == 0 {
+			return 0, errors.Errorf("no score returned by %s", command)
+		}
+		return strconv.ParseFloat(fields[len(fields)-1], 64)
+	}
+}
+
+func (img *Image) toImage() image.Image {
+	scale := 255 / img.GetDataRange()
+	clamp := func(v float64) uint8 {
+		return uint8(math.Max(0, math.Min(255, math.Round(v*scale))))
+	}
+	res := image.NewRGBA(image.Rect(0, 0, img.Width, img.Height))
+	for y := 0; y < img.Height; y++ {
+		for x := 0; x < img.Width; x++ {
+			var c color.RGBA
+			if img.Channels < 3 {
+				v := clamp(img.At(x, y, 0))
+				c = color.RGBA{R: v, G: v, B: v, A: 255}
+			} else {
+				c = color.RGBA{R: clamp(img.At(x, y, 0)), G: clamp(img.At(x, y, 1)), B: clamp(img.At(x, y, 2)), A: 255}
+			}
+			res.SetRGBA(x, y, c)
+		}
+	}
+	return res
+}
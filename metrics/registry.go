@@ -1,34 +1,210 @@
 package metrics
 
 import (
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/pkg/errors"
 	"github.com/rai-project/dlframework"
 )
 
-type FeatureCompareFunction func(actual *dlframework.Features, expected interface{}) float64
+// FeatureCompareFunction compares the features predicted for a single input against the expected value
+type FeatureCompareFunction func(actual *dlframework.Features, expected interface{}) (float64, error)
 
-type featureCompareRegistryMap struct {
-	fs map[string]FeatureCompareFunction
+// Accumulator aggregates the samples of a metric into a dataset level result
+// (e.g. the mean average precision or the mean iou of a confusion matrix)
+type Accumulator interface {
+	Reset()
+	Add(actual *dlframework.Features, expected interface{}) error
+	Finalize() (float64, error)
+}
+
+// Metric describes a registered metric
+type Metric struct {
+	Name        string
+	Description string
+	// FeatureTypes are the types of the predicted features the metric accepts
+	FeatureTypes []dlframework.FeatureType
+	// Compare computes the metric of a single input
+	Compare FeatureCompareFunction
+	// NewAccumulator creates the dataset level accumulator, the mean of Compare is used if it is nil
+	NewAccumulator func() Accumulator
+}
+
+type metricRegistryMap struct {
+	ms map[string]Metric
 	sync.RWMutex
 }
 
-var featureCompareRegistry = featureCompareRegistryMap{
-	fs: map[string]FeatureCompareFunction{},
+var metricRegistry = metricRegistryMap{
+	ms: map[string]Metric{},
+}
+
+func RegisterMetric(m Metric) {
+	metricRegistry.Lock()
+	metricRegistry.ms[m.Name] = m
+	metricRegistry.Unlock()
+}
+
+func GetMetric(name string) *Metric {
+	metricRegistry.RLock()
+	m, ok := metricRegistry.ms[name]
+	metricRegistry.RUnlock()
+	if !ok {
+		return nil
+	}
+	return &m
+}
+
+// RegisteredMetrics returns the registered metrics sorted by name
+func RegisteredMetrics() []Metric {
+	metricRegistry.RLock()
+	res := make([]Metric, 0, len(metricRegistry.ms))
+	for _, m := range metricRegistry.ms {
+		res = append(res, m)
+	}
+	metricRegistry.RUnlock()
+	sort.Slice(res, func(ii, jj int) bool {
+		return res[ii].Name < res[jj].Name
+	})
+	return res
 }
 
+// RegisterFeatureCompareFunction registers a metric which accepts any feature type
+// and whose dataset level result is the mean over the inputs
 func RegisterFeatureCompareFunction(name string, f FeatureCompareFunction) {
-	featureCompareRegistry.Lock()
-	featureCompareRegistry.fs[name] = f
-	featureCompareRegistry.Unlock()
+	RegisterMetric(Metric{
+		Name:    name,
+		Compare: f,
+	})
 }
 
+// GetFeatureCompareFunction returns the compare function of the registered metric.
+// The returned function checks the feature types before comparing.
 func GetFeatureCompareFunction(name string) FeatureCompareFunction {
-	featureCompareRegistry.RLock()
-	f, ok := featureCompareRegistry.fs[name]
-	featureCompareRegistry.RUnlock()
-	if !ok {
+	m := GetMetric(name)
+	if m == nil {
 		return nil
 	}
-	return f
+	return m.Evaluate
+}
+
+// featureTypeOf infers the type of the feature from its content
+func featureTypeOf(feature *dlframework.Feature) dlframework.FeatureType {
+	switch feature.Feature.(type) {
+	case *dlframework.Feature_Classification:
+		return dlframework.FeatureType_CLASSIFICATION
+	case *dlframework.Feature_BoundingBox:
+		return dlframework.FeatureType_BOUNDINGBOX
+	case *dlframework.Feature_SemanticSegment:
+		return dlframework.FeatureType_SEMANTICSEGMENT
+	case *dlframework.Feature_RawImage:
+		return dlframework.FeatureType_RAW_IMAGE
+	case *dlframework.Feature_Raw:
+		return dlframework.FeatureType_RAW
+	}
+	return feature.Type
+}
+
+// Accepts returns true if the metric accepts the feature type
+func (m Metric) Accepts(featureType dlframework.FeatureType) bool {
+	if len(m.FeatureTypes) == 0 {
+		return true
+	}
+	for _, t := range m.FeatureTypes {
+		if t == featureType {
+			return true
+		}
+	}
+	return false
+}
+
+// FeatureTypeNames returns the names of the accepted feature types
+func (m Metric) FeatureTypeNames() []string {
+	res := make([]string, len(m.FeatureTypes))
+	for ii, t := range m.FeatureTypes {
+		res[ii] = strings.ToLower(t.String())
+	}
+	return res
+}
+
+// CheckFeatures returns an error if one of the features is not accepted by the metric
+func (m Metric) CheckFeatures(features *dlframework.Features) error {
+	if features == nil {
+		return errors.Errorf("no features provided to the %s metric", m.Name)
+	}
+	for _, feature := range *features {
+		if feature == nil {
+			continue
+		}
+		if t := featureTypeOf(feature); !m.Accepts(t) {
+			return errors.Errorf("the %s metric does not accept %s features", m.Name, strings.ToLower(t.String()))
+		}
+	}
+	return nil
+}
+
+// Evaluate checks the feature types and computes the metric of a single input
+func (m Metric) Evaluate(actual *dlframework.Features, expected interface{}) (float64, error) {
+	if err := m.CheckFeatures(actual); err != nil {
+		return 0, err
+	}
+	return m.Compare(actual, expected)
+}
+
+// Accumulator returns a new dataset level accumulator of the metric
+func (m Metric) Accumulator() Accumulator {
+	var acc Accumulator
+	if m.NewAccumulator != nil {
+		acc = m.NewAccumulator()
+	} else {
+		acc = NewMeanAccumulator(m.Compare)
+	}
+	return &checkedAccumulator{metric: m, Accumulator: acc}
+}
+
+type checkedAccumulator struct {
+	Accumulator
+	metric Metric
+}
+
+func (a *checkedAccumulator) Add(actual *dlframework.Features, expected interface{}) error {
+	if err := a.metric.CheckFeatures(actual); err != nil {
+		return err
+	}
+	return a.Accumulator.Add(actual, expected)
+}
+
+// MeanAccumulator averages the compare function over the inputs
+type MeanAccumulator struct {
+	compare FeatureCompareFunction
+	sum     float64
+	count   int
+}
+
+func NewMeanAccumulator(compare FeatureCompareFunction) *MeanAccumulator {
+	return &MeanAccumulator{compare: compare}
+}
+
+func (a *MeanAccumulator) Reset() {
+	a.sum = 0
+	a.count = 0
+}
+
+func (a *MeanAccumulator) Add(actual *dlframework.Features, expected interface{}) error {
+	v, err := a.compare(actual, expected)
+	if err != nil {
+		return err
+	}
+	a.sum += v
+	a.count++
+	return nil
+}
+
+func (a *MeanAccumulator) Finalize() (float64, error) {
+	if a.count == 0 {
+		return 0, errors.New("no samples were added to the accumulator")
+	}
+	return a.sum / float64(a.count), nil
 }
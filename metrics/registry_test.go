@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricRegistry(t *testing.T) {
+	RegisterMetric(topKMetric(1))
+
+	m := GetMetric("Top1")
+	if !assert.NotNil(t, m) {
+		return
+	}
+	assert.True(t, m.Accepts(dlframework.FeatureType_CLASSIFICATION))
+	assert.False(t, m.Accepts(dlframework.FeatureType_BOUNDINGBOX))
+
+	classifications := dlframework.Features{
+		{Probability: 0.9, Feature: &dlframework.Feature_Classification{Classification: &dlframework.Classification{Index: 1}}},
+		{Probability: 0.1, Feature: &dlframework.Feature_Classification{Classification: &dlframework.Classification{Index: 2}}},
+	}
+	boxes := dlframework.Features{
+		{Probability: 0.9, Feature: &dlframework.Feature_BoundingBox{BoundingBox: &dlframework.BoundingBox{}}},
+	}
+
+	v, err := m.Evaluate(&classifications, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, v)
+
+	_, err = m.Evaluate(&boxes, 1)
+	assert.Error(t, err)
+
+	_, err = m.Evaluate(&dlframework.Features{}, 1)
+	assert.Error(t, err)
+
+	acc := m.Accumulator()
+	assert.NoError(t, acc.Add(&classifications, 1))
+	assert.NoError(t, acc.Add(&classifications, 2))
+	assert.Error(t, acc.Add(&boxes, 1))
+	v, err = acc.Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, v)
+
+	acc.Reset()
+	_, err = acc.Finalize()
+	assert.Error(t, err)
+}
+
+func TestSegmentationAccumulator(t *testing.T) {
+	m := segmentationMetric("MeanIntersectionOverUnion", "", (*ConfusionMatrix).MeanIntersectionOverUnion)
+	features := func(mask ...int32) *dlframework.Features {
+		return &dlframework.Features{
+			{Feature: &dlframework.Feature_SemanticSegment{SemanticSegment: &dlframework.SemanticSegment{Width: 2, Height: 1, IntMask: mask}}},
+		}
+	}
+	expected := &SegmentationMask{Width: 2, Height: 1, Labels: []int{0, 1}}
+
+	acc := m.Accumulator()
+	assert.NoError(t, acc.Add(features(0, 1), expected))
+	assert.NoError(t, acc.Add(features(0, 0), expected))
+	assert.Error(t, acc.Add(features(0, 0), nil))
+
+	// class 0: 2 / 3, class 1: 1 / 2
+	v, err := acc.Finalize()
+	assert.NoError(t, err)
+	assert.InDelta(t, (2.0/3+0.5)/2, v, 1e-9)
+}
@@ -0,0 +1,290 @@
+package metrics
+
+import (
+	"image"
+	_ "image/png"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rai-project/config"
+	"github.com/rai-project/dlframework"
+)
+
+// https://github.com/tensorflow/models/blob/master/research/deeplab/evaluation/segmentation_metrics.md
+
+// DefaultSegmentationIgnoreLabel is the label of the pixels which are not evaluated (e.g. the object boundaries in PASCAL VOC)
+var DefaultSegmentationIgnoreLabel = 255
+
+// SegmentationMask is a dense label map stored in row major order
+type SegmentationMask struct {
+	Width  int   `json:"width"`
+	Height int   `json:"height"`
+	Labels []int `json:"labels"`
+}
+
+// ReadSegmentationMask reads a ground truth mask from a PNG file. Paletted images (e.g. PASCAL VOC)
+// use the palette index as the label and gray images use the gray value.
+func ReadSegmentationMask(path string) (*SegmentationMask, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decode the segmentation mask %s", path)
+	}
+	mask, err := SegmentationMaskFromImage(img)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the segmentation mask %s", path)
+	}
+	return mask, nil
+}
+
+// SegmentationMaskFromImage converts a paletted or gray image to a label map. The labels of
+// the other images (e.g. color masks) cannot be told from their pixels, so they are rejected.
+func SegmentationMaskFromImage(img image.Image) (*SegmentationMask, error) {
+	switch img.(type) {
+	case *image.Paletted, *image.Gray, *image.Gray16:
+	default:
+		return nil, errors.Errorf("expecting a paletted or gray image, got %T", img)
+	}
+	bounds := img.Bounds()
+	mask := &SegmentationMask{
+		Width:  bounds.Dx(),
+		Height: bounds.Dy(),
+		Labels: make([]int, bounds.Dx()*bounds.Dy()),
+	}
+	idx := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			switch img := img.(type) {
+			case *image.Paletted:
+				mask.Labels[idx] = int(img.ColorIndexAt(x, y))
+			case *image.Gray:
+				mask.Labels[idx] = int(img.GrayAt(x, y).Y)
+			case *image.Gray16:
+				mask.Labels[idx] = int(img.Gray16At(x, y).Y)
+			}
+			idx++
+		}
+	}
+	return mask, nil
+}
+
+// CheckLabels returns an error if a label other than the ignore label is not in [0, numClasses).
+// The labels are not checked if numClasses is 0.
+func (m *SegmentationMask) CheckLabels(numClasses, ignoreLabel int) error {
+	if numClasses <= 0 {
+		return nil
+	}
+	for _, label := range m.Labels {
+		if label != ignoreLabel && (label < 0 || label >= numClasses) {
+			return errors.Errorf("the label %v is not in [0, %v) nor the ignore label %v", label, numClasses, ignoreLabel)
+		}
+	}
+	return nil
+}
+
+// SegmentationMaskFromFeatures returns the label map of the first semantic segment feature
+func SegmentationMaskFromFeatures(features *dlframework.Features) (*SegmentationMask, error) {
+	if features == nil {
+		return nil, errors.New("no features found")
+	}
+	for _, feature := range *features {
+		if feature == nil {
+			continue
+		}
+		segment, ok := feature.Feature.(*dlframework.Feature_SemanticSegment)
+		if !ok {
+			continue
+		}
+		width := int(segment.SemanticSegment.Width)
+		height := int(segment.SemanticSegment.Height)
+		if len(segment.SemanticSegment.IntMask) != width*height {
+			return nil, errors.Errorf("the semantic segment mask size %v does not match %vx%v", len(segment.SemanticSegment.IntMask), width, height)
+		}
+		mask := &SegmentationMask{
+			Width:  width,
+			Height: height,
+			Labels: make([]int, width*height),
+		}
+		for ii, label := range segment.SemanticSegment.IntMask {
+			mask.Labels[ii] = int(label)
+		}
+		return mask, nil
+	}
+	return nil, errors.New("expecting a semantic segment feature")
+}
+
+// Resize resizes the mask using nearest neighbor interpolation
+func (m *SegmentationMask) Resize(width, height int) *SegmentationMask {
+	if m.Width == width && m.Height == height {
+		return m
+	}
+	res := &SegmentationMask{
+		Width:  width,
+		Height: height,
+		Labels: make([]int, width*height),
+	}
+	for y := 0; y < height; y++ {
+		sy := y * m.Height / height
+		for x := 0; x < width; x++ {
+			sx := x * m.Width / width
+			res.Labels[y*width+x] = m.Labels[sy*m.Width+sx]
+		}
+	}
+	return res
+}
+
+// AddSegmentation accumulates the pixels of the predicted mask into the confusion matrix.
+// The predicted mask is resized to the size of the expected mask and the pixels
+// with the ignore label in the expected mask are skipped.
+func (c *ConfusionMatrix) AddSegmentation(actual, expected *SegmentationMask, ignoreLabel int) {
+	actual = actual.Resize(expected.Width, expected.Height)
+	for ii, label := range expected.Labels {
+		if label == ignoreLabel {
+			continue
+		}
+		c.Add(label, actual.Labels[ii])
+	}
+}
+
+// IntersectionOverUnion is the IoU of a class. It is -1 if the class does not appear
+// in either the expected or actual labels.
+func (c *ConfusionMatrix) IntersectionOverUnion(class int) float64 {
+	if class < 0 || class >= len(c.Counts) {
+		return -1
+	}
+	intersection := c.Counts[class][class]
+	union := c.ExpectedTotal(class) + c.ActualTotal(class) - intersection
+	if union == 0 {
+		return -1
+	}
+	return float64(intersection) / float64(union)
+}
+
+// MeanIntersectionOverUnion is the mean of the IoU of the classes which appear in the labels
+func (c *ConfusionMatrix) MeanIntersectionOverUnion() float64 {
+	sum := 0.0
+	count := 0
+	for ii := range c.Counts {
+		iou := c.IntersectionOverUnion(ii)
+		if iou < 0 {
+			continue
+		}
+		sum += iou
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// FrequencyWeightedIntersectionOverUnion is the mean of the IoU of the classes weighted by
+// how often they appear in the expected labels
+func (c *ConfusionMatrix) FrequencyWeightedIntersectionOverUnion() float64 {
+	total := c.Total()
+	if total == 0 {
+		return 0
+	}
+	sum := 0.0
+	for ii := range c.Counts {
+		iou := c.IntersectionOverUnion(ii)
+		if iou < 0 {
+			continue
+		}
+		sum += float64(c.ExpectedTotal(ii)) / float64(total) * iou
+	}
+	return sum
+}
+
+func segmentationMasks(actual *dlframework.Features, expected interface{}) (*SegmentationMask, *SegmentationMask, error) {
+	expectedMask, ok := expected.(*SegmentationMask)
+	if !ok {
+		return nil, nil, errors.New("expecting a segmentation mask for second argument")
+	}
+	actualMask, err := SegmentationMaskFromFeatures(actual)
+	if err != nil {
+		return nil, nil, err
+	}
+	return actualMask, expectedMask, nil
+}
+
+// SegmentationAccumulator accumulates the pixels of all the inputs into a confusion matrix,
+// so the dataset level metric is computed over all the pixels rather than averaged over the inputs.
+// If NumClasses is set, the masks with labels outside of the classes are rejected.
+type SegmentationAccumulator struct {
+	ConfusionMatrix *ConfusionMatrix
+	NumClasses      int
+	IgnoreLabel     int
+	metric          func(*ConfusionMatrix) float64
+}
+
+func NewSegmentationAccumulator(metric func(*ConfusionMatrix) float64) *SegmentationAccumulator {
+	return &SegmentationAccumulator{
+		ConfusionMatrix: NewConfusionMatrix(0),
+		IgnoreLabel:     DefaultSegmentationIgnoreLabel,
+		metric:          metric,
+	}
+}
+
+func (a *SegmentationAccumulator) Reset() {
+	a.ConfusionMatrix = NewConfusionMatrix(0)
+}
+
+func (a *SegmentationAccumulator) Add(actual *dlframework.Features, expected interface{}) error {
+	actualMask, expectedMask, err := segmentationMasks(actual, expected)
+	if err != nil {
+		return err
+	}
+	if err := expectedMask.CheckLabels(a.NumClasses, a.IgnoreLabel); err != nil {
+		return errors.Wrap(err, "invalid expected segmentation mask")
+	}
+	if err := actualMask.CheckLabels(a.NumClasses, a.IgnoreLabel); err != nil {
+		return errors.Wrap(err, "invalid predicted segmentation mask")
+	}
+	a.ConfusionMatrix.AddSegmentation(actualMask, expectedMask, a.IgnoreLabel)
+	return nil
+}
+
+func (a *SegmentationAccumulator) Finalize() (float64, error) {
+	if a.ConfusionMatrix.Total() == 0 {
+		return 0, errors.New("no pixels were added to the accumulator")
+	}
+	return a.metric(a.ConfusionMatrix), nil
+}
+
+func segmentationMetric(name, description string, metric func(*ConfusionMatrix) float64) Metric {
+	return Metric{
+		Name:         name,
+		Description:  description,
+		FeatureTypes: []dlframework.FeatureType{dlframework.FeatureType_SEMANTICSEGMENT},
+		Compare: func(actual *dlframework.Features, expected interface{}) (float64, error) {
+			acc := NewSegmentationAccumulator(metric)
+			if err := acc.Add(actual, expected); err != nil {
+				return 0, err
+			}
+			return acc.Finalize()
+		},
+		NewAccumulator: func() Accumulator {
+			return NewSegmentationAccumulator(metric)
+		},
+	}
+}
+
+func init() {
+	config.AfterInit(func() {
+		RegisterMetric(segmentationMetric("PixelAccuracy",
+			"fraction of the pixels labeled correctly",
+			(*ConfusionMatrix).Accuracy))
+		RegisterMetric(segmentationMetric("MeanIntersectionOverUnion",
+			"mean of the per class intersection over union of the pixels",
+			(*ConfusionMatrix).MeanIntersectionOverUnion))
+		RegisterMetric(segmentationMetric("FrequencyWeightedIntersectionOverUnion",
+			"per class intersection over union of the pixels weighted by the class frequency",
+			(*ConfusionMatrix).FrequencyWeightedIntersectionOverUnion))
+	})
+}
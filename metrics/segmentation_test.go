@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentationMetrics(t *testing.T) {
+	expected := &SegmentationMask{
+		Width:  2,
+		Height: 2,
+		Labels: []int{0, 1, 1, 255},
+	}
+	actual := &SegmentationMask{
+		Width:  4,
+		Height: 4,
+		Labels: []int{
+			0, 0, 0, 0,
+			0, 0, 0, 0,
+			1, 1, 2, 2,
+			1, 1, 2, 2,
+		},
+	}
+
+	c := NewConfusionMatrix(0)
+	c.AddSegmentation(actual, expected, DefaultSegmentationIgnoreLabel)
+
+	assert.Equal(t, int64(3), c.Total())
+	assert.InDelta(t, 2.0/3.0, c.Accuracy(), 1e-9)
+	assert.InDelta(t, 0.5, c.IntersectionOverUnion(0), 1e-9)
+	assert.InDelta(t, 0.5, c.IntersectionOverUnion(1), 1e-9)
+	assert.Equal(t, -1.0, c.IntersectionOverUnion(2))
+	assert.InDelta(t, 0.5, c.MeanIntersectionOverUnion(), 1e-9)
+	assert.InDelta(t, 0.5, c.FrequencyWeightedIntersectionOverUnion(), 1e-9)
+
+	features := dlframework.Features{
+		&dlframework.Feature{
+			Feature: &dlframework.Feature_SemanticSegment{
+				SemanticSegment: &dlframework.SemanticSegment{
+					Width:   2,
+					Height:  2,
+					IntMask: []int32{0, 1, 1, 1},
+				},
+			},
+		},
+	}
+	m := segmentationMetric("PixelAccuracy", "", (*ConfusionMatrix).Accuracy)
+	v, err := m.Evaluate(&features, expected)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, v)
+}
+
+func TestSegmentationMaskFromImage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.Pix = []uint8{3, 255}
+	mask, err := SegmentationMaskFromImage(img)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 255}, mask.Labels)
+
+	assert.NoError(t, mask.CheckLabels(4, 255))
+	assert.NoError(t, mask.CheckLabels(0, 255))
+	assert.Error(t, mask.CheckLabels(3, 255))
+	assert.Error(t, mask.CheckLabels(4, 0))
+
+	paletted := image.NewPaletted(image.Rect(0, 0, 2, 1), color.Palette{color.Black, color.White})
+	paletted.Pix = []uint8{1, 0}
+	mask, err = SegmentationMaskFromImage(paletted)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 0}, mask.Labels)
+
+	// the labels of a color mask cannot be told from its pixels
+	_, err = SegmentationMaskFromImage(image.NewRGBA(image.Rect(0, 0, 2, 1)))
+	assert.Error(t, err)
+
+	acc := NewSegmentationAccumulator((*ConfusionMatrix).Accuracy)
+	acc.NumClasses = 2
+	segment := &dlframework.SemanticSegment{Width: 2, Height: 1, IntMask: []int32{1, 2}}
+	features := dlframework.Features{{Feature: &dlframework.Feature_SemanticSegment{SemanticSegment: segment}}}
+	assert.Error(t, acc.Add(&features, &SegmentationMask{Width: 2, Height: 1, Labels: []int{1, 0}}))
+	segment.IntMask = []int32{1, 0}
+	assert.Error(t, acc.Add(&features, &SegmentationMask{Width: 2, Height: 1, Labels: []int{1, 2}}))
+	assert.NoError(t, acc.Add(&features, &SegmentationMask{Width: 2, Height: 1, Labels: []int{1, 255}}))
+	assert.Equal(t, int64(1), acc.ConfusionMatrix.Total())
+}
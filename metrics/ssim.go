@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// https://ece.uwaterloo.ca/~z70wang/research/ssim/
+// https://ece.uwaterloo.ca/~z70wang/publications/msssim.pdf
+
+var (
+	// DefaultStructuralSimilarityWindowSize is the size of the gaussian window
+	DefaultStructuralSimilarityWindowSize = 11
+	// DefaultStructuralSimilaritySigma is the standard deviation of the gaussian window
+	DefaultStructuralSimilaritySigma = 1.5
+	// DefaultMultiScaleStructuralSimilarityWeights are the weights of each scale from the MS-SSIM paper
+	DefaultMultiScaleStructuralSimilarityWeights = []float64{0.0448, 0.2856, 0.3001, 0.2363, 0.1333}
+)
+
+const (
+	ssimK1 = 0.01
+	ssimK2 = 0.03
+)
+
+func gaussianWindow(size int, sigma float64) []float64 {
+	res := make([]float64, size*size)
+	center := float64(size-1) / 2
+	sum := 0.0
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			dx, dy := float64(x)-center, float64(y)-center
+			v := math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+			res[y*size+x] = v
+			sum += v
+		}
+	}
+	for ii := range res {
+		res[ii] /= sum
+	}
+	return res
+}
+
+// structuralSimilarity computes the mean ssim and mean contrast structure of a single channel
+// using the valid part of the gaussian filtered image (same as the reference matlab implementation)
+func structuralSimilarity(a, b *Image, window []float64, size int, dataRange float64) (float64, float64) {
+	c1 := (ssimK1 * dataRange) * (ssimK1 * dataRange)
+	c2 := (ssimK2 * dataRange) * (ssimK2 * dataRange)
+
+	ssim := 0.0
+	cs := 0.0
+	count := 0
+	for y := 0; y+size <= a.Height; y++ {
+		for x := 0; x+size <= a.Width; x++ {
+			var muA, muB, aa, bb, ab float64
+			for wy := 0; wy < size; wy++ {
+				for wx := 0; wx < size; wx++ {
+					w := window[wy*size+wx]
+					va := a.Pixels[(y+wy)*a.Width+x+wx]
+					vb := b.Pixels[(y+wy)*b.Width+x+wx]
+					muA += w * va
+					muB += w * vb
+					aa += w * va * va
+					bb += w * vb * vb
+					ab += w * va * vb
+				}
+			}
+			sigmaA := aa - muA*muA
+			sigmaB := bb - muB*muB
+			sigmaAB := ab - muA*muB
+
+			contrastStructure := (2*sigmaAB + c2) / (sigmaA + sigmaB + c2)
+			luminance := (2*muA*muB + c1) / (muA*muA + muB*muB + c1)
+
+			ssim += luminance * contrastStructure
+			cs += contrastStructure
+			count++
+		}
+	}
+	return ssim / float64(count), cs / float64(count)
+}
+
+// StructuralSimilarity computes the SSIM index between the images for pixel values in [0, dataRange].
+// The SSIM of multi channel images is the mean of the SSIM of each channel.
+func StructuralSimilarity(actual, expected *Image, dataRange float64) (float64, error) {
+	if err := checkImageShapes(actual, expected); err != nil {
+		return 0, err
+	}
+	size := DefaultStructuralSimilarityWindowSize
+	if actual.Width < size || actual.Height < size {
+		return 0, errors.Errorf("the image size %vx%v is smaller than the window size %v", actual.Width, actual.Height, size)
+	}
+	window := gaussianWindow(size, DefaultStructuralSimilaritySigma)
+	sum := 0.0
+	for c := 0; c < actual.Channels; c++ {
+		ssim, _ := structuralSimilarity(actual.Channel(c), expected.Channel(c), window, size, dataRange)
+		sum += ssim
+	}
+	return sum / float64(actual.Channels), nil
+}
+
+// downsample halves the image size by averaging 2x2 blocks
+func downsample(img *Image) *Image {
+	res := NewImage(img.Width/2, img.Height/2, img.Channels)
+	for y := 0; y < res.Height; y++ {
+		for x := 0; x < res.Width; x++ {
+			for c := 0; c < img.Channels; c++ {
+				v := img.At(2*x, 2*y, c) + img.At(2*x+1, 2*y, c) + img.At(2*x, 2*y+1, c) + img.At(2*x+1, 2*y+1, c)
+				res.Set(x, y, c, v/4)
+			}
+		}
+	}
+	return res
+}
+
+// MultiScaleStructuralSimilarity computes the MS-SSIM index between the images for pixel values in [0, dataRange]
+// using the default five scales. The images must be at least 176 pixels wide and high.
+func MultiScaleStructuralSimilarity(actual, expected *Image, dataRange float64) (float64, error) {
+	if err := checkImageShapes(actual, expected); err != nil {
+		return 0, err
+	}
+	weights := DefaultMultiScaleStructuralSimilarityWeights
+	size := DefaultStructuralSimilarityWindowSize
+	minSize := size << uint(len(weights)-1)
+	if actual.Width < minSize || actual.Height < minSize {
+		return 0, errors.Errorf("the image size %vx%v is smaller than the minimum size %v for %v scales", actual.Width, actual.Height, minSize, len(weights))
+	}
+	window := gaussianWindow(size, DefaultStructuralSimilaritySigma)
+
+	sum := 0.0
+	for c := 0; c < actual.Channels; c++ {
+		a, b := actual.Channel(c), expected.Channel(c)
+		res := 1.0
+		for scale, weight := range weights {
+			ssim, cs := structuralSimilarity(a, b, window, size, dataRange)
+			if scale == len(weights)-1 {
+				res *= math.Pow(math.Max(ssim, 0), weight)
+				break
+			}
+			res *= math.Pow(math.Max(cs, 0), weight)
+			a, b = downsample(a), downsample(b)
+		}
+		sum += res
+	}
+	return sum / float64(actual.Channels), nil
+}
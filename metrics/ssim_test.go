@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+)
+
+func testImage(width, height, channels int, f func(x, y, c int) float64) *Image {
+	img := NewImage(width, height, channels)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for c := 0; c < channels; c++ {
+				img.Set(x, y, c, f(x, y, c))
+			}
+		}
+	}
+	return img
+}
+
+func TestImageQuality(t *testing.T) {
+	reference := testImage(192, 192, 3, func(x, y, c int) float64 {
+		return float64((x*7 + y*3 + c*50) % 256)
+	})
+	noisy := testImage(192, 192, 3, func(x, y, c int) float64 {
+		v := reference.At(x, y, c)
+		if (x+y)%2 == 0 {
+			return v + 10
+		}
+		return v - 10
+	})
+
+	psnr, err := ImagePeakSignalToNoiseRatio(noisy, reference, 255)
+	assert.NoError(t, err)
+	assert.Len(t, psnr, 3)
+	assert.InDelta(t, 20*math.Log10(255)-20, psnr[0], 1e-9)
+	assert.True(t, math.IsInf(PeakSignalToNoiseRatioWithDataRange(reference.Pixels, reference.Pixels, 255), 1))
+
+	ssim, err := StructuralSimilarity(reference, reference, 255)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0, ssim, 1e-9)
+
+	ssim, err = StructuralSimilarity(noisy, reference, 255)
+	assert.NoError(t, err)
+	assert.True(t, ssim < 1 && ssim > 0)
+
+	msssim, err := MultiScaleStructuralSimilarity(reference, reference, 255)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.0, msssim, 1e-9)
+
+	msssim, err = MultiScaleStructuralSimilarity(noisy, reference, 255)
+	assert.NoError(t, err)
+	assert.True(t, msssim < 1 && msssim > ssim)
+
+	_, err = MultiScaleStructuralSimilarity(reference.Channel(0), reference, 255)
+	assert.Error(t, err)
+}
+
+func TestPerceptualIndex(t *testing.T) {
+	img := NewImage(1, 1, 3)
+
+	_, err := PerceptualIndex(img)
+	assert.Error(t, err)
+
+	for _, name := range []string{MaName, NIQEName} {
+		defer restoreNoReferenceQualityFunction(name, GetNoReferenceQualityFunction(name))
+	}
+	RegisterNoReferenceQualityFunction(MaName, func(*Image) (float64, error) { return 8, nil })
+	RegisterNoReferenceQualityFunction(NIQEName, func(*Image) (float64, error) { return 4, nil })
+
+	pi, err := PerceptualIndex(img)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, pi)
+}
+
+func restoreNoReferenceQualityFunction(name string, f NoReferenceQualityFunction) {
+	noReferenceQualityRegistry.Lock()
+	defer noReferenceQualityRegistry.Unlock()
+	if f == nil {
+		delete(noReferenceQualityRegistry.fs, name)
+		return
+	}
+	noReferenceQualityRegistry.fs[name] = f
+}
+
+func TestImageFromImageWithDataRange(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 255, G: 0, B: 51, A: 255})
+	img.Set(1, 0, color.RGBA{R: 102, G: 255, B: 0, A: 255})
+
+	res := ImageFromImage(img)
+	assert.InDeltaSlice(t, []float64{255, 0, 51, 102, 255, 0}, res.Pixels, 1e-9)
+
+	res = ImageFromImageWithDataRange(img, 1)
+	assert.InDeltaSlice(t, []float64{1, 0, 0.2, 0.4, 1, 0}, res.Pixels, 1e-9)
+}
+
+func TestImageDataRange(t *testing.T) {
+	img := NewImage(2, 1, 1)
+	img.Pixels = []float64{0.2, 1}
+	img.DataRange = 1
+
+	// the pixel values are rescaled to [0, 255] rather than clamped
+	res := img.toImage()
+	assert.Equal(t, color.RGBA{R: 51, G: 51, B: 51, A: 255}, res.At(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, res.At(1, 0))
+	assert.Equal(t, 1.0, img.Channel(0).GetDataRange())
+	assert.Equal(t, DefaultImageDataRange, NewImage(1, 1, 1).GetDataRange())
+
+	// the predicted image is compared in the data range of the expected image
+	expected := NewImage(2, 1, 1)
+	expected.Pixels = []float64{0, 1}
+	expected.DataRange = 1
+	features := dlframework.Features{
+		{Feature: &dlframework.Feature_RawImage{RawImage: &dlframework.RawImage{Width: 2, Height: 1, Channels: 1, FloatList: []float32{0.1, 1}}}},
+	}
+	psnr, err := imageCompareFunction(func(actual, expected *Image) (float64, error) {
+		return PeakSignalToNoiseRatioWithDataRange(actual.Pixels, expected.Pixels, actual.GetDataRange()), nil
+	})(&features, expected)
+	assert.NoError(t, err)
+	assert.InDelta(t, 10*math.Log10(2/(0.1*0.1)), psnr, 1e-5)
+}
@@ -3,78 +3,121 @@ package metrics
 import (
 	"sort"
 
+	"github.com/pkg/errors"
 	"github.com/rai-project/config"
 	"github.com/rai-project/dlframework"
 	"github.com/spf13/cast"
 )
 
-// ClassificationTop1 ...
-func ClassificationTop1(features *dlframework.Features, expectedLabelIndex int) bool {
-	for _, feature := range []*dlframework.Feature(*features) {
-		_, ok := feature.Feature.(*dlframework.Feature_Classification)
-		if !ok {
-			panic("unable to convert first feature to boundingbox")
+// classificationFeatures returns the non nil features sorted by probability. It returns an error
+// if one of the features is not a classification or if there is no feature.
+func classificationFeatures(features *dlframework.Features) ([]*dlframework.Feature, error) {
+	if features == nil {
+		return nil, errors.New("expecting at least one feature")
+	}
+	fs := []*dlframework.Feature{}
+	for _, feature := range *features {
+		if feature == nil {
+			continue
 		}
+		if _, ok := feature.Feature.(*dlframework.Feature_Classification); !ok {
+			return nil, errors.New("unable to convert feature to classification")
+		}
+		fs = append(fs, feature)
 	}
+	if len(fs) == 0 {
+		return nil, errors.New("expecting at least one feature")
+	}
+	sort.SliceStable(fs, func(ii, jj int) bool {
+		return fs[ii].GetProbability() > fs[jj].GetProbability()
+	})
+	return fs, nil
+}
 
-	sort.Sort(features)
-
-	actualLabelIndex := int([]*dlframework.Feature(*features)[0].Feature.(*dlframework.Feature_Classification).Classification.Index)
-	return actualLabelIndex == expectedLabelIndex
+// ClassificationTop1 ...
+func ClassificationTop1(features *dlframework.Features, expectedLabelIndex int) (bool, error) {
+	return ClassificationTopK(features, expectedLabelIndex, 1)
 }
 
-func Top1(features *dlframework.Features, expectedLabelIndex int) bool {
+func Top1(features *dlframework.Features, expectedLabelIndex int) (bool, error) {
 	return ClassificationTop1(features, expectedLabelIndex)
 }
 
 // ClassificationTop5 ...
-func ClassificationTop5(features *dlframework.Features, expectedLabelIndex int) bool {
-	for _, feature := range []*dlframework.Feature(*features) {
-		_, ok := feature.Feature.(*dlframework.Feature_Classification)
-		if !ok {
-			panic("unable to convert first feature to boundingbox")
+func ClassificationTop5(features *dlframework.Features, expectedLabelIndex int) (bool, error) {
+	return ClassificationTopK(features, expectedLabelIndex, 5)
+}
+
+// Top5 ...
+func Top5(features *dlframework.Features, expectedLabelIndex int) (bool, error) {
+	return ClassificationTop5(features, expectedLabelIndex)
+}
+
+// ClassificationTopK returns true if the expected label is within the k most probable features
+func ClassificationTopK(features *dlframework.Features, expectedLabelIndex int, k int) (bool, error) {
+	labels, err := ClassificationTopKIndices(features, k)
+	if err != nil {
+		return false, err
+	}
+	for _, label := range labels {
+		if label == expectedLabelIndex {
+			return true, nil
 		}
 	}
+	return false, nil
+}
 
-	sort.Sort(features)
+// TopK ...
+func TopK(features *dlframework.Features, expectedLabelIndex int, k int) (bool, error) {
+	return ClassificationTopK(features, expectedLabelIndex, k)
+}
 
-	for _, feature := range []*dlframework.Feature(*features)[:5] {
-		if int(feature.Feature.(*dlframework.Feature_Classification).Classification.Index) == expectedLabelIndex {
-			return true
-		}
+// TopKName is the name the top k metric is registered under
+func TopKName(k int) string {
+	return "Top" + cast.ToString(k)
+}
+
+// GetTopKMetric returns the registered top k metric.
+// Top1 and Top5 are registered by default, other values of k get registered on first use.
+func GetTopKMetric(k int) *Metric {
+	if m := GetMetric(TopKName(k)); m != nil {
+		return m
 	}
-	return false
+	m := topKMetric(k)
+	RegisterMetric(m)
+	return &m
 }
 
-// Top5 ...
-func Top5(features *dlframework.Features, expectedLabelIndex int) bool {
-	return ClassificationTop5(features, expectedLabelIndex)
+// GetTopKFeatureCompareFunction returns the compare function of the top k metric
+func GetTopKFeatureCompareFunction(k int) FeatureCompareFunction {
+	return GetTopKMetric(k).Evaluate
+}
+
+func topKMetric(k int) Metric {
+	return Metric{
+		Name:         TopKName(k),
+		Description:  "fraction of the inputs whose expected label is within the " + cast.ToString(k) + " most probable predictions",
+		FeatureTypes: []dlframework.FeatureType{dlframework.FeatureType_CLASSIFICATION},
+		Compare: func(actual *dlframework.Features, expected interface{}) (float64, error) {
+			expectedLabel, err := cast.ToIntE(expected)
+			if err != nil {
+				return 0, errors.New("expecting an int for second argument")
+			}
+			ok, err := TopK(actual, expectedLabel, k)
+			if err != nil {
+				return 0, err
+			}
+			if ok {
+				return 1.0, nil
+			}
+			return 0.0, nil
+		},
+	}
 }
 
 func init() {
 	config.AfterInit(func() {
-		RegisterFeatureCompareFunction("Top1",
-			func(actual *dlframework.Features, expected interface{}) float64 {
-				expectedLabel, err := cast.ToIntE(expected)
-				if err != nil {
-					panic("expecting an int for second argument")
-				}
-				if Top1(actual, expectedLabel) {
-					return 1.0
-				}
-				return 0.0
-			})
-
-		RegisterFeatureCompareFunction("Top5",
-			func(actual *dlframework.Features, expected interface{}) float64 {
-				expectedLabel, err := cast.ToIntE(expected)
-				if err != nil {
-					panic("expecting an int for second argument")
-				}
-				if Top5(actual, expectedLabel) {
-					return 1.0
-				}
-				return 0.0
-			})
+		RegisterMetric(topKMetric(1))
+		RegisterMetric(topKMetric(5))
 	})
 }
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassificationTopK(t *testing.T) {
+	classification := func(index int32, probability float32) *dlframework.Feature {
+		return &dlframework.Feature{
+			Probability: probability,
+			Feature:     &dlframework.Feature_Classification{Classification: &dlframework.Classification{Index: index}},
+		}
+	}
+	features := dlframework.Features{classification(1, 0.2), nil, classification(2, 0.5), classification(3, 0.3)}
+
+	ok, err := Top1(&features, 2)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = TopK(&features, 1, 2)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = Top5(&features, 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = Top1(&dlframework.Features{nil}, 1)
+	assert.Error(t, err)
+
+	_, err = Top1(nil, 1)
+	assert.Error(t, err)
+
+	boxes := dlframework.Features{
+		classification(1, 0.9),
+		{Feature: &dlframework.Feature_BoundingBox{BoundingBox: &dlframework.BoundingBox{}}},
+	}
+	_, err = Top1(&boxes, 1)
+	assert.Error(t, err)
+
+	v, err := topKMetric(1).Evaluate(&dlframework.Features{nil, classification(4, 1)}, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, v)
+}
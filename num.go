@@ -156,3 +156,26 @@ func transpose(a [][]float64) [][]float64 {
 	}
 	return r
 }
+
+// Percentile computes the percentile (in [0, 100]) of the data using linear interpolation between the closest ranks
+func Percentile(data []float64, percent float64) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(data))
+	copy(sorted, data)
+	sort.Float64s(sorted)
+
+	rank := math.Max(0, math.Min(100, percent)) / 100 * float64(len(sorted)-1)
+	lower := floor(rank)
+	upper := ceil(rank)
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// Median computes the median of the data
+func Median(data []float64) float64 {
+	return Percentile(data, 50)
+}
@@ -0,0 +1,36 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	cases := []struct {
+		name     string
+		data     []float64
+		percent  float64
+		expected float64
+	}{
+		{"empty", nil, 50, 0},
+		{"single value", []float64{3}, 95, 3},
+		{"min", []float64{3, 1, 2}, 0, 1},
+		{"max", []float64{3, 1, 2}, 100, 3},
+		{"interpolated", []float64{4, 1, 2, 3}, 50, 2.5},
+		{"below range", []float64{3, 1, 2}, -10, 1},
+		{"above range", []float64{3, 1, 2}, 110, 3},
+	}
+	for _, c := range cases {
+		assert.InDelta(t, c.expected, Percentile(c.data, c.percent), 1e-12, c.name)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	data := []float64{5, 1, 3}
+	assert.Equal(t, 3.0, Median(data))
+	assert.Equal(t, []float64{5, 1, 3}, data, "the data is not sorted in place")
+	assert.Equal(t, 0.0, Median(nil))
+	assert.Equal(t, 2.0, Median([]float64{2}))
+	assert.Equal(t, 2.5, Median([]float64{1, 2, 3, 4}))
+}
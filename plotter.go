@@ -1,7 +1,10 @@
 package evaluation
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path"
 
@@ -38,7 +41,31 @@ type PiePlotter interface {
 	OpenPiePlot() error
 }
 
-func writeBarPlot(o BarPlotter, filepath string) error {
+type ScatterPlotter interface {
+	PlotNamed
+	ScatterPlot() *charts.Scatter
+	ScatterPlotAdd(*charts.Scatter) *charts.Scatter
+	WriteScatterPlot(string) error
+	OpenScatterPlot() error
+}
+
+type LinePlotter interface {
+	PlotNamed
+	LinePlot() *charts.Line
+	LinePlotAdd(*charts.Line) *charts.Line
+	WriteLinePlot(string) error
+	OpenLinePlot() error
+}
+
+type HeatMapPlotter interface {
+	PlotNamed
+	HeatMapPlot() *charts.HeatMap
+	HeatMapPlotAdd(*charts.HeatMap) *charts.HeatMap
+	WriteHeatMapPlot(string) error
+	OpenHeatMapPlot() error
+}
+
+func renderBarPlot(o BarPlotter, w io.Writer) error {
 	bar := o.BarPlot()
 
 	if DefaultShowTitle {
@@ -70,20 +97,27 @@ func writeBarPlot(o BarPlotter, filepath string) error {
 		},
 		// charts.DataZoomOpts{XAxisIndex: []int{0}, Start: 50, End: 100},
 	)
-	os.MkdirAll(path.Dir(filepath), os.ModePerm)
-	f, err := os.Create(filepath)
+	return bar.Render(w)
+}
+
+// staticBarPlot reads the options of the bar plot to render it as an image
+func staticBarPlot(o BarPlotter) (*staticPlot, error) {
+	bar := o.BarPlot()
+	options, err := chartJSON(func(w io.Writer) error { return bar.Render(w) }, bar)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
-	err = bar.Render(f)
-	if err != nil {
-		return err
+	return newStaticPlot("bar", o.PlotName(), DefaultBarPlotWidth, DefaultBarPlotHeight, options)
+}
+
+func writeBarPlot(o BarPlotter, filepath string) error {
+	if isStaticPlotPath(filepath) {
+		return writeStaticPlot(filepath, func() (*staticPlot, error) { return staticBarPlot(o) })
 	}
-	return nil
+	return writePlotPage(filepath, func(w io.Writer) error { return renderBarPlot(o, w) })
 }
 
-func writeBoxPlot(o BoxPlotter, filepath string) error {
+func renderBoxPlot(o BoxPlotter, w io.Writer) error {
 	box := o.BoxPlot()
 
 	if DefaultShowTitle {
@@ -113,20 +147,27 @@ func writeBoxPlot(o BoxPlotter, filepath string) error {
 			Height:     fmt.Sprintf("%vpx", DefaultBarPlotHeight),
 		},
 	)
-	os.MkdirAll(path.Dir(filepath), os.ModePerm)
-	f, err := os.Create(filepath)
+	return box.Render(w)
+}
+
+// staticBoxPlot reads the options of the box plot to render it as an image
+func staticBoxPlot(o BoxPlotter) (*staticPlot, error) {
+	box := o.BoxPlot()
+	options, err := chartJSON(func(w io.Writer) error { return box.Render(w) }, box)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer f.Close()
-	err = box.Render(f)
-	if err != nil {
-		return err
+	return newStaticPlot("boxplot", o.PlotName(), DefaultBarPlotWidth, DefaultBarPlotHeight, options)
+}
+
+func writeBoxPlot(o BoxPlotter, filepath string) error {
+	if isStaticPlotPath(filepath) {
+		return writeStaticPlot(filepath, func() (*staticPlot, error) { return staticBoxPlot(o) })
 	}
-	return nil
+	return writePlotPage(filepath, func(w io.Writer) error { return renderBoxPlot(o, w) })
 }
 
-func writePiePlot(o PiePlotter, filepath string) error {
+func renderPiePlot(o PiePlotter, w io.Writer) error {
 	pie := o.PiePlot()
 
 	if DefaultShowTitle {
@@ -156,17 +197,184 @@ func writePiePlot(o PiePlotter, filepath string) error {
 			Height:     fmt.Sprintf("%vpx", DefaultPiePlotHeight),
 		},
 	)
-	os.MkdirAll(path.Dir(filepath), os.ModePerm)
-	f, err := os.Create(filepath)
+	return pie.Render(w)
+}
+
+// staticPiePlot reads the options of the pie plot to render it as an image
+func staticPiePlot(o PiePlotter) (*staticPlot, error) {
+	pie := o.PiePlot()
+	options, err := chartJSON(func(w io.Writer) error { return pie.Render(w) }, pie)
+	if err != nil {
+		return nil, err
+	}
+	return newStaticPlot("pie", o.PlotName(), DefaultPiePlotWidth, DefaultPiePlotHeight, options)
+}
+
+func writePiePlot(o PiePlotter, filepath string) error {
+	if isStaticPlotPath(filepath) {
+		return writeStaticPlot(filepath, func() (*staticPlot, error) { return staticPiePlot(o) })
+	}
+	return writePlotPage(filepath, func(w io.Writer) error { return renderPiePlot(o, w) })
+}
+
+func renderScatterPlot(o ScatterPlotter, w io.Writer) error {
+	scatter := o.ScatterPlot()
+
+	if DefaultShowTitle {
+		scatter.SetGlobalOptions(
+			charts.TitleOpts{
+				Title: o.PlotName(),
+				Right: "center",
+				Top:   "top",
+				TitleStyle: charts.TextStyleOpts{
+					FontSize: DefaultTitleFontSize,
+				},
+			})
+	}
+
+	scatter.SetGlobalOptions(
+		charts.LegendOpts{
+			Right: "right",
+			Top:   "middle",
+			TextStyle: charts.TextStyleOpts{
+				FontSize: DefaultLegendFontSize,
+			},
+		},
+		charts.ToolboxOpts{Show: true, TBFeature: charts.TBFeature{SaveAsImage: charts.SaveAsImage{PixelRatio: 5}}},
+		charts.InitOpts{
+			AssetsHost: DefaultAssetHost,
+			Theme:      charts.ThemeType.Shine,
+			Width:      fmt.Sprintf("%vpx", DefaultBarPlotWidth),
+			Height:     fmt.Sprintf("%vpx", DefaultBarPlotHeight),
+		},
+	)
+	return scatter.Render(w)
+}
+
+// staticScatterPlot reads the options of the scatter plot to render it as an image
+func staticScatterPlot(o ScatterPlotter) (*staticPlot, error) {
+	scatter := o.ScatterPlot()
+	options, err := chartJSON(func(w io.Writer) error { return scatter.Render(w) }, scatter)
+	if err != nil {
+		return nil, err
+	}
+	return newStaticPlot("scatter", o.PlotName(), DefaultBarPlotWidth, DefaultBarPlotHeight, options)
+}
+
+func writeScatterPlot(o ScatterPlotter, filepath string) error {
+	if isStaticPlotPath(filepath) {
+		return writeStaticPlot(filepath, func() (*staticPlot, error) { return staticScatterPlot(o) })
+	}
+	return writePlotPage(filepath, func(w io.Writer) error { return renderScatterPlot(o, w) })
+}
+
+func renderLinePlot(o LinePlotter, w io.Writer) error {
+	line := o.LinePlot()
+
+	if DefaultShowTitle {
+		line.SetGlobalOptions(
+			charts.TitleOpts{
+				Title: o.PlotName(),
+				Right: "center",
+				Top:   "top",
+				TitleStyle: charts.TextStyleOpts{
+					FontSize: DefaultTitleFontSize,
+				},
+			})
+	}
+
+	line.SetGlobalOptions(
+		charts.LegendOpts{
+			Right: "right",
+			Top:   "middle",
+			TextStyle: charts.TextStyleOpts{
+				FontSize: DefaultLegendFontSize,
+			},
+		},
+		charts.ToolboxOpts{Show: true, TBFeature: charts.TBFeature{SaveAsImage: charts.SaveAsImage{PixelRatio: 5}}},
+		charts.InitOpts{
+			AssetsHost: DefaultAssetHost,
+			Theme:      charts.ThemeType.Shine,
+			Width:      fmt.Sprintf("%vpx", DefaultBarPlotWidth),
+			Height:     fmt.Sprintf("%vpx", DefaultBarPlotHeight),
+		},
+	)
+	return line.Render(w)
+}
+
+// staticLinePlot reads the options of the line plot to render it as an image
+func staticLinePlot(o LinePlotter) (*staticPlot, error) {
+	line := o.LinePlot()
+	options, err := chartJSON(func(w io.Writer) error { return line.Render(w) }, line)
+	if err != nil {
+		return nil, err
+	}
+	return newStaticPlot("line", o.PlotName(), DefaultBarPlotWidth, DefaultBarPlotHeight, options)
+}
+
+func writeLinePlot(o LinePlotter, filepath string) error {
+	if isStaticPlotPath(filepath) {
+		return writeStaticPlot(filepath, func() (*staticPlot, error) { return staticLinePlot(o) })
+	}
+	return writePlotPage(filepath, func(w io.Writer) error { return renderLinePlot(o, w) })
+}
+
+func renderHeatMapPlot(o HeatMapPlotter, w io.Writer) error {
+	heatMap := o.HeatMapPlot()
+
+	if DefaultShowTitle {
+		heatMap.SetGlobalOptions(
+			charts.TitleOpts{
+				Title: o.PlotName(),
+				Right: "center",
+				Top:   "top",
+				TitleStyle: charts.TextStyleOpts{
+					FontSize: DefaultTitleFontSize,
+				},
+			})
+	}
+
+	heatMap.SetGlobalOptions(
+		charts.ToolboxOpts{Show: true, TBFeature: charts.TBFeature{SaveAsImage: charts.SaveAsImage{PixelRatio: 5}}},
+		charts.InitOpts{
+			AssetsHost: DefaultAssetHost,
+			Theme:      charts.ThemeType.Shine,
+			Width:      fmt.Sprintf("%vpx", DefaultHeatMapPlotWidth),
+			Height:     fmt.Sprintf("%vpx", DefaultHeatMapPlotHeight),
+		},
+	)
+	return heatMap.Render(w)
+}
+
+// staticHeatMapPlot reads the options of the heatmap plot to render it as an image
+func staticHeatMapPlot(o HeatMapPlotter) (*staticPlot, error) {
+	heatMap := o.HeatMapPlot()
+	options, err := chartJSON(func(w io.Writer) error { return heatMap.Render(w) }, heatMap)
 	if err != nil {
+		return nil, err
+	}
+	return newStaticPlot("heatmap", o.PlotName(), DefaultHeatMapPlotWidth, DefaultHeatMapPlotHeight, options)
+}
+
+func writeHeatMapPlot(o HeatMapPlotter, filepath string) error {
+	if isStaticPlotPath(filepath) {
+		return writeStaticPlot(filepath, func() (*staticPlot, error) { return staticHeatMapPlot(o) })
+	}
+	return writePlotPage(filepath, func(w io.Writer) error { return renderHeatMapPlot(o, w) })
+}
+
+// writePlotPage writes the html page of the plot with its assets loaded following the DefaultPlotAssetMode
+func writePlotPage(filepath string, render func(io.Writer) error) error {
+	buf := new(bytes.Buffer)
+	if err := render(buf); err != nil {
 		return err
 	}
-	defer f.Close()
-	err = pie.Render(f)
+	html, err := localizePlotAssets(buf.Bytes(), DefaultPlotAssetMode, path.Dir(filepath))
 	if err != nil {
 		return err
 	}
-	return nil
+	os.MkdirAll(path.Dir(filepath), os.ModePerm)
+	return ioutil.WriteFile(filepath, html, 0644)
 }
 
 func openBarPlot(o BarPlotter) error {
@@ -219,3 +427,50 @@ func openPiePlot(o PiePlotter) error {
 
 	return nil
 }
+
+func openHeatMapPlot(o HeatMapPlotter) error {
+	filepath := TempFile("", "heatMapPlot_*.html")
+	if filepath == "" {
+		return errors.New("failed to create temporary file")
+	}
+	err := o.WriteHeatMapPlot(filepath)
+	if err != nil {
+		return err
+	}
+	if ok := browser.Open(filepath); !ok {
+		return errors.New("failed to open browser filepath")
+	}
+	return nil
+}
+
+func openLinePlot(o LinePlotter) error {
+	filepath := TempFile("", "linePlot_*.html")
+	if filepath == "" {
+		return errors.New("failed to create temporary file")
+	}
+	err := o.WriteLinePlot(filepath)
+	if err != nil {
+		return err
+	}
+	if ok := browser.Open(filepath); !ok {
+		return errors.New("failed to open browser filepath")
+	}
+
+	return nil
+}
+
+func openScatterPlot(o ScatterPlotter) error {
+	filepath := TempFile("", "scatterPlot_*.html")
+	if filepath == "" {
+		return errors.New("failed to create temporary file")
+	}
+	err := o.WriteScatterPlot(filepath)
+	if err != nil {
+		return err
+	}
+	if ok := browser.Open(filepath); !ok {
+		return errors.New("failed to open browser filepath")
+	}
+
+	return nil
+}
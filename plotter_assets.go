@@ -0,0 +1,106 @@
+package evaluation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PlotAssetMode is how the generated html plots load the echarts javascript and css assets
+type PlotAssetMode string
+
+const (
+	// PlotAssetsRemote loads the assets from the DefaultAssetHost
+	PlotAssetsRemote PlotAssetMode = "remote"
+	// PlotAssetsEmbed inlines the assets in the html, so the plots render without network access
+	PlotAssetsEmbed PlotAssetMode = "embed"
+	// PlotAssetsCopy copies the assets to an assets directory next to the html
+	PlotAssetsCopy PlotAssetMode = "copy"
+)
+
+// ParsePlotAssetMode returns the plot asset mode of the name
+func ParsePlotAssetMode(name string) (PlotAssetMode, error) {
+	switch mode := PlotAssetMode(strings.ToLower(name)); mode {
+	case PlotAssetsRemote, PlotAssetsEmbed, PlotAssetsCopy:
+		return mode, nil
+	}
+	return "", errors.Errorf("invalid plot asset mode %v, expecting remote, embed or copy", name)
+}
+
+// vendoredPlotAssets are the assets of the asset host vendored in the repository, keyed by their
+// path relative to the DefaultAssetHost. They are registered by gen-plotter_assets.go, which
+// plotter_assets_gen.go generates from the assets directory.
+var vendoredPlotAssets = map[string][]byte{}
+
+// plotAsset returns the content of the asset. The assets are read from the DefaultAssetDir, which
+// can hold another version of the assets, and otherwise from the vendored assets. The network is
+// never used, so the plots can be localized on air-gapped machines.
+func plotAsset(name string) ([]byte, error) {
+	if DefaultAssetDir != "" {
+		path := filepath.Join(DefaultAssetDir, filepath.FromSlash(name))
+		if bts, err := ioutil.ReadFile(path); err == nil {
+			return bts, nil
+		}
+	}
+	if bts, ok := vendoredPlotAssets[name]; ok {
+		return bts, nil
+	}
+	if DefaultAssetDir != "" {
+		return nil, errors.Errorf("the %v asset is neither in %v nor vendored", name, DefaultAssetDir)
+	}
+	return nil, errors.Errorf("the %v asset is not vendored, run go generate to vendor the plot assets", name)
+}
+
+var plotAssetTagRegexp = regexp.MustCompile(`<script[^>]*\ssrc="([^"]*)"[^>]*>\s*</script>|<link[^>]*\shref="([^"]*)"[^>]*>`)
+
+// localizePlotAssets rewrites the assets of the html page loaded from the DefaultAssetHost. The
+// embedded scripts and stylesheets replace their tags. The copied assets are written to an assets
+// directory in dir, and are embedded if dir is empty.
+func localizePlotAssets(html []byte, mode PlotAssetMode, dir string) ([]byte, error) {
+	if mode == PlotAssetsRemote || mode == "" {
+		return html, nil
+	}
+	var err error
+	res := plotAssetTagRegexp.ReplaceAllFunc(html, func(tag []byte) []byte {
+		if err != nil {
+			return tag
+		}
+		m := plotAssetTagRegexp.FindSubmatch(tag)
+		url, isScript := string(m[1]), true
+		if url == "" {
+			url, isScript = string(m[2]), false
+		}
+		if !strings.HasPrefix(url, DefaultAssetHost) {
+			return tag
+		}
+		name := strings.TrimPrefix(url, DefaultAssetHost)
+		var asset []byte
+		asset, err = plotAsset(name)
+		if err != nil {
+			return tag
+		}
+		if mode == PlotAssetsCopy && dir != "" {
+			path := filepath.Join(dir, "assets", filepath.FromSlash(name))
+			os.MkdirAll(filepath.Dir(path), os.ModePerm)
+			if err = ioutil.WriteFile(path, asset, 0644); err != nil {
+				return tag
+			}
+			return bytes.Replace(tag, []byte(url), []byte("assets/"+name), 1)
+		}
+		if isScript {
+			// the script must not close its own tag
+			asset = bytes.Replace(asset, []byte("</script"), []byte(`<\/script`), -1)
+			return append(append([]byte(`<script type="text/javascript">`), asset...), []byte("</script>")...)
+		}
+		return append(append([]byte("<style>"), asset...), []byte("</style>")...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
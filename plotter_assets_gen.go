@@ -0,0 +1,86 @@
+//go:build ignore
+// +build ignore
+
+// plotter_assets_gen.go vendors the echarts assets of the html plots. The assets missing from the
+// assets directory are downloaded once from the asset host, and all the assets are written to
+// gen-plotter_assets.go so that they are compiled in the binary.
+//
+//	go run plotter_assets_gen.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const assetHost = `https://s3.amazonaws.com/store.carml.org/model_analysis_2019/assets/`
+
+// the assets loaded by the go-echarts pages and the timeline plot
+var assetNames = []string{
+	"echarts.min.js",
+	"themes/shine.js",
+	"bulma.min.css",
+}
+
+func download(name, path string) error {
+	resp, err := http.Get(assetHost + name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %v", name)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download %v, got status %v", name, resp.Status)
+	}
+	bts, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download %v", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, bts, 0644)
+}
+
+func generate() error {
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, "// Code generated by plotter_assets_gen.go. DO NOT EDIT.")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "package evaluation")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "func init() {")
+	for _, name := range assetNames {
+		path := filepath.Join("assets", filepath.FromSlash(name))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := download(name, path); err != nil {
+				return err
+			}
+		}
+		bts, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "\tvendoredPlotAssets[%q] = []byte(%s)\n", name, strconv.Quote(string(bts)))
+	}
+	fmt.Fprintln(buf, "}")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "failed to format the vendored assets")
+	}
+	return ioutil.WriteFile("gen-plotter_assets.go", src, 0644)
+}
+
+func main() {
+	if err := generate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
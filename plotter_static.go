@@ -0,0 +1,794 @@
+package evaluation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// The bar, box, pie, line, scatter and heatmap plots are rendered as svg or png images, without the
+// echarts javascript, when they are written to a path with a .svg or .png extension.
+
+var staticPlotColors = []color.RGBA{
+	{0xc2, 0x35, 0x31, 0xff},
+	{0x2f, 0x45, 0x54, 0xff},
+	{0x61, 0xa0, 0xa8, 0xff},
+	{0xd4, 0x82, 0x65, 0xff},
+	{0x91, 0xc7, 0xae, 0xff},
+	{0x74, 0x9f, 0x83, 0xff},
+	{0xca, 0x86, 0x22, 0xff},
+	{0xbd, 0xa2, 0x9a, 0xff},
+	{0x6e, 0x70, 0x74, 0xff},
+	{0x54, 0x65, 0x70, 0xff},
+}
+
+var (
+	staticPlotBlack = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	staticPlotGrey  = color.RGBA{0xcc, 0xcc, 0xcc, 0xff}
+	staticPlotWhite = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+// maximum number of x axis labels, the category indices are shown if there are more categories
+const staticPlotMaxLabels = 20
+
+type staticSlice struct {
+	Name  string
+	Value float64
+}
+
+type staticSeries struct {
+	Name  string
+	Stack string
+	// Values are the bar heights
+	Values []float64
+	// Boxes are the min, lower quartile, median, upper quartile and max of the box plots
+	Boxes [][]float64
+	// Slices are the pie slices
+	Slices []staticSlice
+	// Points are the x and y values of the lines
+	Points [][2]float64
+	// Cells are the x index, y index and value of the heatmap cells
+	Cells [][3]float64
+}
+
+type staticPlot struct {
+	Kind    string
+	Title   string
+	XLabels []string
+	YLabels []string
+	XName   string
+	YName   string
+	XLog    bool
+	YLog    bool
+	Series  []staticSeries
+	Width   int
+	Height  int
+}
+
+func isStaticPlotPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".svg" || ext == ".png"
+}
+
+// chartJSON renders the chart, which validates its options, and returns its exported options as json
+func chartJSON(render func(io.Writer) error, chart interface{}) (interface{}, error) {
+	if err := render(ioutil.Discard); err != nil {
+		return nil, err
+	}
+	bts, err := json.Marshal(chart)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the chart options")
+	}
+	var res interface{}
+	if err := json.Unmarshal(bts, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// findJSON returns the first value, in depth first order of the sorted keys, for which the predicate
+// holds. The path is the keys leading to the value.
+func findJSON(v interface{}, pred func(path []string, v interface{}) bool) interface{} {
+	var res interface{}
+	var walk func(path []string, v interface{}) bool
+	walk = func(path []string, v interface{}) bool {
+		if pred(path, v) {
+			res = v
+			return true
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if walk(append(path[:len(path):len(path)], k), v[k]) {
+					return true
+				}
+			}
+		case []interface{}:
+			for _, elem := range v {
+				if walk(path, elem) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	walk(nil, v)
+	return res
+}
+
+// underJSONKey returns true if the last key of the path is the key and one of its ancestors contains the parent
+func underJSONKey(path []string, parent, key string) bool {
+	if len(path) < 2 || path[len(path)-1] != key {
+		return false
+	}
+	for _, p := range path[:len(path)-1] {
+		if strings.Contains(strings.ToLower(p), parent) {
+			return true
+		}
+	}
+	return false
+}
+
+func isJSONSeries(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, hasType := m["type"].(string)
+	_, hasData := m["data"]
+	return hasType && hasData
+}
+
+func jsonFloat(v interface{}) float64 {
+	if m, ok := v.(map[string]interface{}); ok {
+		v = m["value"]
+	}
+	return cast.ToFloat64(v)
+}
+
+func jsonFloats(v interface{}) []float64 {
+	if m, ok := v.(map[string]interface{}); ok {
+		v = m["value"]
+	}
+	elems, _ := v.([]interface{})
+	res := make([]float64, len(elems))
+	for ii, elem := range elems {
+		res[ii] = cast.ToFloat64(elem)
+	}
+	return res
+}
+
+// newStaticPlot reads the categories and series of the rendered chart options
+func newStaticPlot(kind, title string, width, height int, options interface{}) (*staticPlot, error) {
+	plot := &staticPlot{
+		Kind:   kind,
+		Title:  strings.Join(strings.Fields(title), " "),
+		Width:  width,
+		Height: height,
+	}
+
+	series, _ := findJSON(options, func(path []string, v interface{}) bool {
+		elems, ok := v.([]interface{})
+		return ok && len(elems) != 0 && isJSONSeries(elems[0])
+	}).([]interface{})
+	for _, elem := range series {
+		m, ok := elem.(map[string]interface{})
+		if !ok || !isJSONSeries(m) {
+			continue
+		}
+		s := staticSeries{
+			Name:  cast.ToString(m["name"]),
+			Stack: cast.ToString(m["stack"]),
+		}
+		data, _ := m["data"].([]interface{})
+		for _, d := range data {
+			switch kind {
+			case "bar":
+				s.Values = append(s.Values, jsonFloat(d))
+			case "boxplot":
+				box := jsonFloats(d)
+				if len(box) != 5 {
+					return nil, errors.Errorf("expecting 5 values for each box but got %v", len(box))
+				}
+				s.Boxes = append(s.Boxes, box)
+			case "pie":
+				dm, _ := d.(map[string]interface{})
+				s.Slices = append(s.Slices, staticSlice{Name: cast.ToString(dm["name"]), Value: jsonFloat(d)})
+			case "line", "scatter":
+				if xy := jsonFloats(d); len(xy) >= 2 {
+					s.Points = append(s.Points, [2]float64{xy[0], xy[1]})
+				} else {
+					s.Points = append(s.Points, [2]float64{float64(len(s.Points)), jsonFloat(d)})
+				}
+			case "heatmap":
+				if cell := jsonFloats(d); len(cell) == 3 {
+					s.Cells = append(s.Cells, [3]float64{cell[0], cell[1], cell[2]})
+				}
+			}
+		}
+		plot.Series = append(plot.Series, s)
+	}
+	if len(plot.Series) == 0 {
+		return nil, errors.New("the chart has no series to render")
+	}
+
+	for _, axis := range []struct {
+		name   string
+		labels *[]string
+	}{{"xaxis", &plot.XLabels}, {"yaxis", &plot.YLabels}} {
+		labels, _ := findJSON(options, func(path []string, v interface{}) bool {
+			_, ok := v.([]interface{})
+			return ok && underJSONKey(path, axis.name, "data")
+		}).([]interface{})
+		for _, label := range labels {
+			*axis.labels = append(*axis.labels, cast.ToString(label))
+		}
+	}
+	for _, axis := range []struct {
+		name  string
+		label *string
+	}{{"xaxis", &plot.XName}, {"yaxis", &plot.YName}} {
+		*axis.label, _ = findJSON(options, func(path []string, v interface{}) bool {
+			_, ok := v.(string)
+			return ok && underJSONKey(path, axis.name, "name")
+		}).(string)
+	}
+	for _, axis := range []struct {
+		name string
+		log  *bool
+	}{{"xaxis", &plot.XLog}, {"yaxis", &plot.YLog}} {
+		typ, _ := findJSON(options, func(path []string, v interface{}) bool {
+			_, ok := v.(string)
+			return ok && underJSONKey(path, axis.name, "type")
+		}).(string)
+		*axis.log = typ == "log"
+	}
+	return plot, nil
+}
+
+// staticCanvas draws the plots, with the origin at the top left
+type staticCanvas interface {
+	rect(x, y, w, h float64, c color.RGBA)
+	line(x0, y0, x1, y1 float64, c color.RGBA)
+	wedge(cx, cy, r, a0, a1 float64, c color.RGBA)
+	// text draws the text with its baseline at y, the anchor is start, middle or end
+	text(x, y float64, s string, anchor string)
+}
+
+type svgCanvas struct {
+	b bytes.Buffer
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(&c.b, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+	return c
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func svgEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, col color.RGBA) {
+	fmt.Fprintf(&c.b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`+"\n", x, y, w, h, svgColor(col))
+}
+
+func (c *svgCanvas) line(x0, y0, x1, y1 float64, col color.RGBA) {
+	fmt.Fprintf(&c.b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s"/>`+"\n", x0, y0, x1, y1, svgColor(col))
+}
+
+func (c *svgCanvas) wedge(cx, cy, r, a0, a1 float64, col color.RGBA) {
+	if a1-a0 >= 2*math.Pi-1e-9 {
+		fmt.Fprintf(&c.b, `<circle cx="%.2f" cy="%.2f" r="%.2f" fill="%s"/>`+"\n", cx, cy, r, svgColor(col))
+		return
+	}
+	largeArc := 0
+	if a1-a0 > math.Pi {
+		largeArc = 1
+	}
+	fmt.Fprintf(&c.b, `<path d="M %.2f %.2f L %.2f %.2f A %.2f %.2f 0 %d 1 %.2f %.2f Z" fill="%s" stroke="#ffffff"/>`+"\n",
+		cx, cy, cx+r*math.Cos(a0), cy+r*math.Sin(a0), r, r, largeArc, cx+r*math.Cos(a1), cy+r*math.Sin(a1), svgColor(col))
+}
+
+func (c *svgCanvas) text(x, y float64, s string, anchor string) {
+	fmt.Fprintf(&c.b, `<text x="%.2f" y="%.2f" text-anchor="%s" fill="%s">%s</text>`+"\n", x, y, anchor, svgColor(staticPlotBlack), svgEscape(s))
+}
+
+func (c *svgCanvas) WriteTo(w io.Writer) (int64, error) {
+	c.b.WriteString("</svg>\n")
+	return c.b.WriteTo(w)
+}
+
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNGCanvas(width, height int) *pngCanvas {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(staticPlotWhite), image.ZP, draw.Src)
+	return &pngCanvas{img: img}
+}
+
+func (c *pngCanvas) rect(x, y, w, h float64, col color.RGBA) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(c.img, r, image.NewUniform(col), image.ZP, draw.Src)
+}
+
+func (c *pngCanvas) line(x0, y0, x1, y1 float64, col color.RGBA) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	for ii := 0; ii <= steps; ii++ {
+		t := float64(ii) / float64(steps)
+		c.img.Set(int(math.Round(x0+t*(x1-x0))), int(math.Round(y0+t*(y1-y0))), col)
+	}
+}
+
+func (c *pngCanvas) wedge(cx, cy, r, a0, a1 float64, col color.RGBA) {
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			if dx*dx+dy*dy > r*r {
+				continue
+			}
+			a := math.Atan2(dy, dx)
+			for a < a0 {
+				a += 2 * math.Pi
+			}
+			if a < a1 {
+				c.img.Set(x, y, col)
+			}
+		}
+	}
+}
+
+func (c *pngCanvas) text(x, y float64, s string, anchor string) {
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(staticPlotBlack),
+		Face: basicfont.Face7x13,
+	}
+	width := float64(d.MeasureString(s).Round())
+	switch anchor {
+	case "middle":
+		x -= width / 2
+	case "end":
+		x -= width
+	}
+	d.Dot = fixed.P(int(math.Round(x)), int(math.Round(y)))
+	d.DrawString(s)
+}
+
+func (c *pngCanvas) WriteTo(w io.Writer) (int64, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, c.img); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(w)
+}
+
+func shortenLabel(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-2] + ".."
+}
+
+// niceTicks returns about n round tick values covering [0, max]
+func niceTicks(max float64, n int) []float64 {
+	if max <= 0 || math.IsNaN(max) || math.IsInf(max, 0) {
+		return []float64{0, 1}
+	}
+	step := math.Pow(10, math.Floor(math.Log10(max/float64(n))))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if max/(step*m) <= float64(n) {
+			step *= m
+			break
+		}
+	}
+	res := []float64{}
+	for v := 0.0; v < max+step; v += step {
+		res = append(res, v)
+	}
+	return res
+}
+
+// drawLegend draws the names in the right margin
+func drawLegend(c staticCanvas, x, y float64, names []string) {
+	for ii, name := range names {
+		c.rect(x, y+float64(ii)*18-9, 10, 10, staticPlotColors[ii%len(staticPlotColors)])
+		c.text(x+16, y+float64(ii)*18, shortenLabel(name, 24), "start")
+	}
+}
+
+func (p *staticPlot) draw(c staticCanvas) {
+	width, height := float64(p.Width), float64(p.Height)
+	if p.Title != "" {
+		c.text(width/2, 20, p.Title, "middle")
+	}
+	if p.Kind == "pie" {
+		p.drawPie(c, width, height)
+		return
+	}
+	if p.Kind == "line" || p.Kind == "scatter" {
+		p.drawLines(c, width, height)
+		return
+	}
+	if p.Kind == "heatmap" {
+		p.drawHeatMap(c, width, height)
+		return
+	}
+
+	left, right, top, bottom := 70.0, 190.0, 40.0, 40.0
+	plotWidth, plotHeight := width-left-right, height-top-bottom
+	numCategories := len(p.XLabels)
+	for _, s := range p.Series {
+		if n := len(s.Values) + len(s.Boxes); n > numCategories {
+			numCategories = n
+		}
+	}
+	if numCategories == 0 || plotWidth <= 0 || plotHeight <= 0 {
+		return
+	}
+
+	// the series of the same stack are drawn on top of each other, the others side by side
+	stacked := map[string][]float64{}
+	max := 0.0
+	groups := []string{}
+	for ii, s := range p.Series {
+		group := s.Stack
+		if group == "" {
+			group = fmt.Sprintf("series %d", ii)
+		}
+		if _, ok := stacked[group]; !ok {
+			stacked[group] = make([]float64, numCategories)
+			groups = append(groups, group)
+		}
+		for jj, v := range s.Values {
+			stacked[group][jj] += v
+			max = math.Max(max, stacked[group][jj])
+		}
+		for _, box := range s.Boxes {
+			max = math.Max(max, box[4])
+		}
+	}
+	ticks := niceTicks(max, 5)
+	yMax := ticks[len(ticks)-1]
+	yOf := func(v float64) float64 {
+		return top + plotHeight - v/yMax*plotHeight
+	}
+
+	for _, tick := range ticks {
+		c.line(left, yOf(tick), left+plotWidth, yOf(tick), staticPlotGrey)
+		c.text(left-6, yOf(tick)+4, cast.ToString(tick), "end")
+	}
+	c.line(left, top+plotHeight, left+plotWidth, top+plotHeight, staticPlotBlack)
+	if p.YName != "" {
+		c.text(left, top-8, p.YName, "middle")
+	}
+
+	categoryWidth := plotWidth / float64(numCategories)
+	labelEvery := int(math.Ceil(float64(numCategories) / staticPlotMaxLabels))
+	for ii := 0; ii < numCategories; ii += labelEvery {
+		label := cast.ToString(ii)
+		if ii < len(p.XLabels) && numCategories <= staticPlotMaxLabels {
+			label = shortenLabel(p.XLabels[ii], int(categoryWidth/7))
+		}
+		c.text(left+(float64(ii)+0.5)*categoryWidth, top+plotHeight+16, label, "middle")
+	}
+
+	barWidth := categoryWidth * 0.8 / float64(len(groups))
+	offsets := map[string][]float64{}
+	for _, group := range groups {
+		offsets[group] = make([]float64, numCategories)
+	}
+	names := []string{}
+	for ii, s := range p.Series {
+		col := staticPlotColors[ii%len(staticPlotColors)]
+		names = append(names, s.Name)
+		group := s.Stack
+		if group == "" {
+			group = fmt.Sprintf("series %d", ii)
+		}
+		groupIdx := 0
+		for jj, g := range groups {
+			if g == group {
+				groupIdx = jj
+			}
+		}
+		for jj, v := range s.Values {
+			x := left + float64(jj)*categoryWidth + categoryWidth*0.1 + float64(groupIdx)*barWidth
+			base := offsets[group][jj]
+			c.rect(x, yOf(base+v), barWidth, yOf(base)-yOf(base+v), col)
+			offsets[group][jj] += v
+		}
+		for jj, box := range s.Boxes {
+			x := left + float64(jj)*categoryWidth + categoryWidth*0.1 + float64(groupIdx)*barWidth
+			mid := x + barWidth/2
+			c.line(mid, yOf(box[0]), mid, yOf(box[1]), staticPlotBlack)
+			c.line(mid, yOf(box[3]), mid, yOf(box[4]), staticPlotBlack)
+			c.line(x+barWidth/4, yOf(box[0]), x+barWidth*3/4, yOf(box[0]), staticPlotBlack)
+			c.line(x+barWidth/4, yOf(box[4]), x+barWidth*3/4, yOf(box[4]), staticPlotBlack)
+			c.rect(x, yOf(box[3]), barWidth, yOf(box[1])-yOf(box[3]), col)
+			c.line(x, yOf(box[2]), x+barWidth, yOf(box[2]), staticPlotWhite)
+		}
+	}
+	drawLegend(c, left+plotWidth+20, top+10, names)
+}
+
+func (p *staticPlot) drawPie(c staticCanvas, width, height float64) {
+	slices := []staticSlice{}
+	total := 0.0
+	for _, s := range p.Series {
+		for _, slice := range s.Slices {
+			if slice.Value > 0 {
+				slices = append(slices, slice)
+				total += slice.Value
+			}
+		}
+	}
+	if total == 0 {
+		return
+	}
+	r := math.Min(width-240, height-60) / 2
+	cx, cy := 20+r, height/2+10
+	a := -math.Pi / 2
+	names := []string{}
+	for ii, slice := range slices {
+		sweep := slice.Value / total * 2 * math.Pi
+		c.wedge(cx, cy, r, a, a+sweep, staticPlotColors[ii%len(staticPlotColors)])
+		a += sweep
+		names = append(names, fmt.Sprintf("%s (%.1f%%)", slice.Name, slice.Value/total*100))
+	}
+	// the legend is centered vertically
+	drawLegend(c, cx+r+30, math.Max(40, height/2-float64(len(names))*9), names)
+}
+
+// staticScale maps the values to the plot, on a log scale the non positive values are clamped to the minimum
+type staticScale struct {
+	min, max float64
+	log      bool
+}
+
+func newStaticScale(values []float64, log bool) staticScale {
+	sc := staticScale{min: math.Inf(1), max: math.Inf(-1), log: log}
+	for _, v := range values {
+		if log && v <= 0 {
+			continue
+		}
+		sc.min, sc.max = math.Min(sc.min, v), math.Max(sc.max, v)
+	}
+	if math.IsInf(sc.min, 0) {
+		sc.min, sc.max = 1, 10
+	}
+	if !log && sc.min > 0 {
+		sc.min = 0
+	}
+	if log {
+		sc.min = math.Pow(10, math.Floor(math.Log10(sc.min)))
+		sc.max = math.Pow(10, math.Ceil(math.Log10(sc.max)))
+	}
+	if sc.max <= sc.min {
+		sc.max = sc.min + 1
+	}
+	return sc
+}
+
+// fraction is the position of the value between the minimum (0) and the maximum (1)
+func (sc staticScale) fraction(v float64) float64 {
+	if sc.log {
+		v = math.Max(v, sc.min)
+		return math.Log10(v/sc.min) / math.Log10(sc.max/sc.min)
+	}
+	return (v - sc.min) / (sc.max - sc.min)
+}
+
+// ticks are the powers of ten on a log scale and round values otherwise
+func (sc staticScale) ticks() []float64 {
+	if !sc.log {
+		res := []float64{}
+		for _, tick := range niceTicks(sc.max, 5) {
+			if tick >= sc.min {
+				res = append(res, tick)
+			}
+		}
+		return res
+	}
+	res := []float64{}
+	for v := sc.min; v <= sc.max*1.0001; v *= 10 {
+		res = append(res, v)
+	}
+	return res
+}
+
+func (p *staticPlot) drawLines(c staticCanvas, width, height float64) {
+	left, right, top, bottom := 70.0, 190.0, 40.0, 40.0
+	plotWidth, plotHeight := width-left-right, height-top-bottom
+	xs, ys := []float64{}, []float64{}
+	distinctXs := map[float64]bool{}
+	for _, s := range p.Series {
+		for _, pt := range s.Points {
+			xs, ys = append(xs, pt[0]), append(ys, pt[1])
+			distinctXs[pt[0]] = true
+		}
+	}
+	if len(xs) == 0 || plotWidth <= 0 || plotHeight <= 0 {
+		return
+	}
+	xScale, yScale := newStaticScale(xs, p.XLog), newStaticScale(ys, p.YLog)
+	if !p.XLog {
+		xScale.max = niceTicks(xScale.max, 5)[len(niceTicks(xScale.max, 5))-1]
+	}
+	if !p.YLog {
+		yScale.max = niceTicks(yScale.max, 5)[len(niceTicks(yScale.max, 5))-1]
+	}
+	xOf := func(v float64) float64 { return left + xScale.fraction(v)*plotWidth }
+	yOf := func(v float64) float64 { return top + plotHeight - yScale.fraction(v)*plotHeight }
+
+	for _, tick := range yScale.ticks() {
+		c.line(left, yOf(tick), left+plotWidth, yOf(tick), staticPlotGrey)
+		c.text(left-6, yOf(tick)+4, cast.ToString(tick), "end")
+	}
+	// the x values, such as the batch sizes, are the ticks if there are few of them
+	xTicks := xScale.ticks()
+	if len(distinctXs) <= staticPlotMaxLabels {
+		xTicks = []float64{}
+		for x := range distinctXs {
+			xTicks = append(xTicks, x)
+		}
+		sort.Float64s(xTicks)
+	}
+	for _, tick := range xTicks {
+		c.line(xOf(tick), top+plotHeight, xOf(tick), top+plotHeight+4, staticPlotBlack)
+		c.text(xOf(tick), top+plotHeight+16, cast.ToString(tick), "middle")
+	}
+	c.line(left, top+plotHeight, left+plotWidth, top+plotHeight, staticPlotBlack)
+	c.line(left, top, left, top+plotHeight, staticPlotBlack)
+	if p.YName != "" {
+		c.text(left, top-8, p.YName, "middle")
+	}
+	if p.XName != "" {
+		c.text(left+plotWidth/2, top+plotHeight+32, p.XName, "middle")
+	}
+
+	names := []string{}
+	for ii, s := range p.Series {
+		col := staticPlotColors[ii%len(staticPlotColors)]
+		names = append(names, s.Name)
+		for jj, pt := range s.Points {
+			// the points of the scatter plots are not joined
+			if jj != 0 && p.Kind == "line" {
+				prev := s.Points[jj-1]
+				c.line(xOf(prev[0]), yOf(prev[1]), xOf(pt[0]), yOf(pt[1]), col)
+			}
+			c.rect(xOf(pt[0])-2, yOf(pt[1])-2, 4, 4, col)
+		}
+	}
+	drawLegend(c, left+plotWidth+20, top+10, names)
+}
+
+// heatColor interpolates the color of the fraction between blue, yellow and red
+func heatColor(frac float64) color.RGBA {
+	stops := []color.RGBA{{0x50, 0xa3, 0xba, 0xff}, {0xea, 0xc7, 0x36, 0xff}, {0xd9, 0x4e, 0x5d, 0xff}}
+	frac = math.Max(0, math.Min(1, frac)) * float64(len(stops)-1)
+	ii := int(math.Min(frac, float64(len(stops)-2)))
+	t := frac - float64(ii)
+	mix := func(a, b uint8) uint8 { return uint8(float64(a) + t*(float64(b)-float64(a))) }
+	a, b := stops[ii], stops[ii+1]
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 0xff}
+}
+
+func (p *staticPlot) drawHeatMap(c staticCanvas, width, height float64) {
+	left, right, top, bottom := 190.0, 90.0, 40.0, 40.0
+	plotWidth, plotHeight := width-left-right, height-top-bottom
+	numColumns, numRows := len(p.XLabels), len(p.YLabels)
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, s := range p.Series {
+		for _, cell := range s.Cells {
+			numColumns = int(math.Max(float64(numColumns), cell[0]+1))
+			numRows = int(math.Max(float64(numRows), cell[1]+1))
+			min, max = math.Min(min, cell[2]), math.Max(max, cell[2])
+		}
+	}
+	if numColumns == 0 || numRows == 0 || plotWidth <= 0 || plotHeight <= 0 {
+		return
+	}
+	if max <= min {
+		max = min + 1
+	}
+	cellWidth, cellHeight := plotWidth/float64(numColumns), plotHeight/float64(numRows)
+	for _, s := range p.Series {
+		for _, cell := range s.Cells {
+			// the first row is at the bottom, as in echarts
+			y := top + plotHeight - (cell[1]+1)*cellHeight
+			c.rect(left+cell[0]*cellWidth, y, cellWidth, cellHeight, heatColor((cell[2]-min)/(max-min)))
+		}
+	}
+
+	for ii := 0; ii < numColumns; ii++ {
+		label := cast.ToString(ii)
+		if ii < len(p.XLabels) {
+			label = p.XLabels[ii]
+		}
+		c.text(left+(float64(ii)+0.5)*cellWidth, top+plotHeight+16, label, "middle")
+	}
+	// the rows are labeled every few rows so the labels do not overlap
+	labelEvery := int(math.Ceil(14 / cellHeight))
+	for ii := 0; ii < numRows; ii += labelEvery {
+		label := cast.ToString(ii)
+		if ii < len(p.YLabels) {
+			label = p.YLabels[ii]
+		}
+		c.text(left-6, top+plotHeight-(float64(ii)+0.5)*cellHeight+4, label, "end")
+	}
+	if p.XName != "" {
+		c.text(left+plotWidth/2, top+plotHeight+32, p.XName, "middle")
+	}
+
+	// the color scale
+	scaleX, scaleHeight := left+plotWidth+20, math.Min(plotHeight, 200)
+	for ii := 0; ii < 50; ii++ {
+		c.rect(scaleX, top+scaleHeight*float64(49-ii)/50, 16, scaleHeight/50+1, heatColor(float64(ii)/49))
+	}
+	c.text(scaleX+8, top-4, fmt.Sprintf("%.4g", max), "middle")
+	c.text(scaleX+8, top+scaleHeight+14, fmt.Sprintf("%.4g", min), "middle")
+}
+
+// Write writes the plot as a svg or png image
+func (p *staticPlot) Write(w io.Writer, format string) error {
+	var canvas interface {
+		staticCanvas
+		io.WriterTo
+	}
+	switch strings.ToLower(format) {
+	case "svg":
+		canvas = newSVGCanvas(p.Width, p.Height)
+	case "png":
+		canvas = newPNGCanvas(p.Width, p.Height)
+	default:
+		return errors.Errorf("the static plot format %v is not supported, expecting svg or png", format)
+	}
+	p.draw(canvas)
+	_, err := canvas.WriteTo(w)
+	return err
+}
+
+func writeStaticPlot(filepath string, newPlot func() (*staticPlot, error)) error {
+	plot, err := newPlot()
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(path.Dir(filepath), os.ModePerm)
+	f, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return plot.Write(f, strings.TrimPrefix(path.Ext(filepath), "."))
+}
@@ -0,0 +1,261 @@
+package evaluation
+
+import (
+	"bytes"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticPlot(t *testing.T) {
+	options := map[string]interface{}{
+		"XAxisOptsList": []interface{}{map[string]interface{}{"data": []interface{}{"conv", "relu"}}},
+		"YAxisOptsList": []interface{}{map[string]interface{}{"name": "Latency(us)"}},
+		"MultiSeries": []interface{}{
+			map[string]interface{}{"name": "GPU", "type": "bar", "stack": "stack", "data": []interface{}{10.0, 2.5}},
+			map[string]interface{}{"name": "CPU", "type": "bar", "stack": "stack", "data": []interface{}{map[string]interface{}{"value": 1}, 3}},
+		},
+	}
+	plot, err := newStaticPlot("bar", "resnet\n  Layer Latency", 900, 300, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "resnet Layer Latency", plot.Title)
+	assert.Equal(t, []string{"conv", "relu"}, plot.XLabels)
+	assert.Equal(t, "Latency(us)", plot.YName)
+	assert.Equal(t, []float64{1, 3}, plot.Series[1].Values)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, plot.Write(buf, "svg"))
+	assert.True(t, strings.HasPrefix(buf.String(), "<svg"))
+	assert.Contains(t, buf.String(), ">conv</text>")
+
+	buf.Reset()
+	assert.NoError(t, plot.Write(buf, "png"))
+	img, err := png.Decode(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 900, img.Bounds().Dx())
+
+	pie, err := newStaticPlot("pie", "", 900, 500, map[string]interface{}{
+		"Series": []interface{}{map[string]interface{}{"type": "pie", "data": []interface{}{
+			map[string]interface{}{"name": "Conv2D", "value": 75},
+			map[string]interface{}{"name": "Relu", "value": 25},
+		}}},
+	})
+	assert.NoError(t, err)
+	buf.Reset()
+	assert.NoError(t, pie.Write(buf, "svg"))
+	assert.Contains(t, buf.String(), "Conv2D (75.0%)")
+
+	line, err := newStaticPlot("line", "", 900, 300, map[string]interface{}{
+		"XAxisOptsList": []interface{}{map[string]interface{}{"name": "Batch Size", "type": "log"}},
+		"YAxisOptsList": []interface{}{map[string]interface{}{"type": "value"}},
+		"MultiSeries": []interface{}{
+			map[string]interface{}{"name": "ResNet50", "type": "line", "data": []interface{}{[]interface{}{1, 2.5}, []interface{}{64, 40}}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, line.XLog)
+	assert.False(t, line.YLog)
+	assert.Equal(t, "Batch Size", line.XName)
+	assert.Equal(t, [][2]float64{{1, 2.5}, {64, 40}}, line.Series[0].Points)
+	buf.Reset()
+	assert.NoError(t, line.Write(buf, "svg"))
+	assert.Contains(t, buf.String(), ">64</text>")
+
+	heatMap, err := newStaticPlot("heatmap", "", 900, 300, map[string]interface{}{
+		"XAxisOptsList": []interface{}{map[string]interface{}{"data": []interface{}{"1", "32"}}},
+		"YAxisOptsList": []interface{}{map[string]interface{}{"data": []interface{}{"0 conv", "1 relu"}}},
+		"MultiSeries": []interface{}{
+			map[string]interface{}{"type": "heatmap", "data": []interface{}{[]interface{}{0, 0, 10}, []interface{}{1, 1, 2.5}}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0 conv", "1 relu"}, heatMap.YLabels)
+	assert.Equal(t, [][3]float64{{0, 0, 10}, {1, 1, 2.5}}, heatMap.Series[0].Cells)
+	buf.Reset()
+	assert.NoError(t, heatMap.Write(buf, "svg"))
+	assert.Contains(t, buf.String(), ">1 relu</text>")
+
+	_, err = newStaticPlot("box", "", 900, 300, map[string]interface{}{})
+	assert.Error(t, err)
+}
+
+func TestLocalizePlotAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plot_assets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assetDir := DefaultAssetDir
+	DefaultAssetDir = filepath.Join(dir, "cache")
+	defer func() { DefaultAssetDir = assetDir }()
+	os.MkdirAll(DefaultAssetDir, os.ModePerm)
+	ioutil.WriteFile(filepath.Join(DefaultAssetDir, "echarts.min.js"), []byte("var echarts = '</script>';"), 0644)
+
+	html := []byte(`<head><script src="` + DefaultAssetHost + `echarts.min.js"></script><script src="other.js"></script></head>`)
+
+	res, err := localizePlotAssets(html, PlotAssetsRemote, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, html, res)
+
+	res, err = localizePlotAssets(html, PlotAssetsEmbed, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, `<head><script type="text/javascript">var echarts = '<\/script>';</script><script src="other.js"></script></head>`, string(res))
+
+	res, err = localizePlotAssets(html, PlotAssetsCopy, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, `<head><script src="assets/echarts.min.js"></script><script src="other.js"></script></head>`, string(res))
+	assert.FileExists(t, filepath.Join(dir, "assets", "echarts.min.js"))
+
+	_, err = ParsePlotAssetMode("inline")
+	assert.Error(t, err)
+}
+
+func TestPlotAssetVendored(t *testing.T) {
+	assetDir := DefaultAssetDir
+	defer func() { DefaultAssetDir = assetDir }()
+	vendored := vendoredPlotAssets
+	defer func() { vendoredPlotAssets = vendored }()
+
+	vendoredPlotAssets = map[string][]byte{"echarts.min.js": []byte("var echarts = {};")}
+	DefaultAssetDir = ""
+	asset, err := plotAsset("echarts.min.js")
+	assert.NoError(t, err)
+	assert.Equal(t, "var echarts = {};", string(asset))
+
+	// the assets which are not vendored are not downloaded
+	_, err = plotAsset("themes/shine.js")
+	assert.Error(t, err)
+
+	// the assets of the asset directory are used instead of the vendored ones
+	dir, err := ioutil.TempDir("", "plot_assets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	DefaultAssetDir = dir
+	ioutil.WriteFile(filepath.Join(dir, "echarts.min.js"), []byte("var echarts = 1;"), 0644)
+	asset, err = plotAsset("echarts.min.js")
+	assert.NoError(t, err)
+	assert.Equal(t, "var echarts = 1;", string(asset))
+}
+
+// the assets loaded by the pages are vendored by gen-plotter_assets.go, so the html plots are
+// rendered offline with the vendored map itself
+func TestPlotAssetsAreVendored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vendored_plots")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assetDir, mode := DefaultAssetDir, DefaultPlotAssetMode
+	defer func() { DefaultAssetDir, DefaultPlotAssetMode = assetDir, mode }()
+	DefaultAssetDir = ""
+
+	for _, name := range []string{"echarts.min.js", "themes/shine.js", "bulma.min.css"} {
+		asset, err := plotAsset(name)
+		if assert.NoError(t, err, name) {
+			assert.NotEmpty(t, asset, name)
+		}
+	}
+
+	_, aggre := testPlotterLayers()
+	path := filepath.Join(dir, "pie.html")
+	DefaultPlotAssetMode = PlotAssetsEmbed
+	if assert.NoError(t, SummaryLayerAggreDurationInformations(aggre).WritePiePlot(path)) {
+		html, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(html), DefaultAssetHost)
+		assert.Contains(t, string(html), string(vendoredPlotAssets["echarts.min.js"]))
+	}
+}
+
+func testPlotterLayers() (SummaryLayerInformations, SummaryLayerAggreInformations) {
+	model := SummaryModelInformation{SummaryBase: SummaryBase{ModelName: "ResNet50", BatchSize: 1}}
+	layers := SummaryLayerInformations{
+		{SummaryModelInformation: model, Index: 0, Name: "conv1", Type: "Conv2D", Durations: []int64{9000, 10000, 11000, 12000, 13000}},
+		{SummaryModelInformation: model, Index: 1, Name: "relu1", Type: "Relu", Durations: []int64{1000, 2000, 3000, 4000, 5000}},
+	}
+	aggre := SummaryLayerAggreInformations{
+		{SummaryModelInformation: model, Type: "Conv2D", DurationPercentage: 75},
+		{SummaryModelInformation: model, Type: "Relu", DurationPercentage: 25},
+	}
+	return layers, aggre
+}
+
+func TestWriteStaticPlotters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static_plots")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	layers, aggre := testPlotterLayers()
+	cases := []struct {
+		name     string
+		write    func(string) error
+		contains []string
+	}{
+		{"bar", SummaryLayerLatencyInformations(layers).WriteBarPlot, []string{"ResNet50 Batch Size = 1 Layer Latency", ">conv1</text>", ">relu1</text>"}},
+		{"box", SummaryLayerLatencyInformations(layers).WriteBoxPlot, []string{"ResNet50 Batch Size = 1 Layer Latency", ">conv1</text>", ">relu1</text>"}},
+		{"pie", SummaryLayerAggreDurationInformations(aggre).WritePiePlot, []string{"Layer Latency Percentage", "Conv2D (75.0%)", "Relu (25.0%)"}},
+	}
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name+".svg")
+		if !assert.NoError(t, c.write(path), c.name) {
+			continue
+		}
+		bts, err := ioutil.ReadFile(path)
+		assert.NoError(t, err, c.name)
+		assert.True(t, strings.HasPrefix(string(bts), "<svg"), c.name)
+		for _, s := range c.contains {
+			assert.Contains(t, string(bts), s, c.name)
+		}
+
+		path = filepath.Join(dir, c.name+".png")
+		if !assert.NoError(t, c.write(path), c.name) {
+			continue
+		}
+		f, err := os.Open(path)
+		assert.NoError(t, err, c.name)
+		img, err := png.Decode(f)
+		f.Close()
+		assert.NoError(t, err, c.name)
+		assert.True(t, img.Bounds().Dx() > 0 && img.Bounds().Dy() > 0, c.name)
+	}
+}
+
+func TestWritePlotPageEmbedsVendoredAssets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "html_plots")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assetDir, mode, vendored := DefaultAssetDir, DefaultPlotAssetMode, vendoredPlotAssets
+	defer func() {
+		DefaultAssetDir, DefaultPlotAssetMode, vendoredPlotAssets = assetDir, mode, vendored
+	}()
+	DefaultAssetDir = ""
+
+	_, aggre := testPlotterLayers()
+	pie := SummaryLayerAggreDurationInformations(aggre)
+
+	// vendor a stub of each asset the page loads from the asset host
+	DefaultPlotAssetMode = PlotAssetsRemote
+	path := filepath.Join(dir, "pie.html")
+	assert.NoError(t, pie.WritePiePlot(path))
+	html, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	vendoredPlotAssets = map[string][]byte{}
+	for _, m := range plotAssetTagRegexp.FindAllSubmatch(html, -1) {
+		url := string(m[1]) + string(m[2])
+		if strings.HasPrefix(url, DefaultAssetHost) {
+			vendoredPlotAssets[strings.TrimPrefix(url, DefaultAssetHost)] = []byte("/* vendored */")
+		}
+	}
+	assert.Contains(t, vendoredPlotAssets, "echarts.min.js")
+
+	DefaultPlotAssetMode = PlotAssetsEmbed
+	assert.NoError(t, pie.WritePiePlot(path))
+	html, err = ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(html), DefaultAssetHost)
+	assert.Contains(t, string(html), "/* vendored */")
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/fatih/structs"
 	"github.com/pkg/errors"
+	"github.com/rai-project/evaluation"
 	"github.com/rai-project/evaluation/writer"
 	"github.com/rai-project/go-echarts/charts"
 	"github.com/rai-project/utils/browser"
@@ -78,88 +79,69 @@ func NewBatchPlot(name string, os ...OptionModifier) (*batchPlot, error) {
 	}, nil
 }
 
-func (o batchPlot) BarPlotAdd(bar *charts.Bar) *charts.Bar {
-	labels := []int{}
-	for _, elem := range o.Durations {
-		// batchSize := int(math.Log2(float64(elem.BatchSize)))
-		batchSize := elem.BatchSize
-		if contains(labels, batchSize) {
-			continue
-		}
-		labels = append(labels, batchSize)
-	}
+func (o batchPlot) PlotName() string {
+	return o.Name
+}
 
-	sort.Sort(sort.IntSlice(labels))
-
-	// modelDurations := orderedmap.New()
-	// for _, elem := range o.Durations {
-	// 	var val []int64
-	// 	if e, ok := modelDurations.Get(elem.ModelName); ok {
-	// 		val = e.([]int64)
-	// 	} else {
-	// 		val = []int64{}
-	// 	}
-	// 	val = append(val, elem.Duration)
-	// 	modelDurations.Set(elem.ModelName, val)
-	// }
-
-	modelDurations := map[string]batchDurationSummaries{}
+// LinePlotAdd adds a line of the batch latency against the batch size for each model. The
+// latency of a batch size evaluated several times is the trimmed mean of its durations.
+func (o batchPlot) LinePlotAdd(line *charts.Line) *charts.Line {
+	modelDurations := map[string]map[int][]int64{}
 	for _, elem := range o.Durations {
-		var val []batchDurationSummary
-		if e, ok := modelDurations[elem.ModelName]; ok {
-			val = e
-		} else {
-			val = []batchDurationSummary{}
+		if _, ok := modelDurations[elem.ModelName]; !ok {
+			modelDurations[elem.ModelName] = map[int][]int64{}
 		}
-		val = append(val, elem)
-		modelDurations[elem.ModelName] = batchDurationSummaries(val)
+		modelDurations[elem.ModelName][elem.BatchSize] = append(modelDurations[elem.ModelName][elem.BatchSize], elem.Duration)
 	}
-	strLabels := make([]string, len(labels))
-	for ii, label := range labels {
-		strLabels[ii] = cast.ToString(label)
+	modelNames := []string{}
+	for modelName := range modelDurations {
+		modelNames = append(modelNames, modelName)
 	}
-	bar.AddXAxis(strLabels)
-	for key, durations := range modelDurations {
-		vals := make([]int64, len(labels))
-		for ii, label := range labels {
-			for _, duration := range durations {
-				if duration.BatchSize == label {
-					vals[ii] = duration.Duration / 1000
-					break
-				}
-			}
+	sort.Strings(modelNames)
+
+	for _, modelName := range modelNames {
+		batchSizes := []int{}
+		for batchSize := range modelDurations[modelName] {
+			batchSizes = append(batchSizes, batchSize)
+		}
+		sort.Ints(batchSizes)
+		data := [][]interface{}{}
+		for _, batchSize := range batchSizes {
+			duration := evaluation.TrimmedMeanInt64Slice(modelDurations[modelName][batchSize], evaluation.DefaultTrimmedMeanFraction)
+			data = append(data, []interface{}{batchSize, duration / 1000})
 		}
-		// pp.Println(key, " --", vals)
-		bar.AddYAxis(key, vals)
+		line.AddYAxis(modelName, data)
 	}
-	bar.SetSeriesOptions(
+	line.SetSeriesOptions(
 		charts.LabelTextOpts{Show: false},
-		charts.TextStyleOpts{FontSize: DefaultSeriesFontSize},
+		charts.TextStyleOpts{FontSize: evaluation.DefaultSeriesFontSize},
 	)
-	bar.SetGlobalOptions(
-		charts.XAxisOpts{Name: "BatchSize", Show: false, AxisLabel: charts.LabelTextOpts{Show: true}},
-		charts.YAxisOpts{Name: "Latency(ms)"},
+	line.SetGlobalOptions(
+		charts.XAxisOpts{Name: "Batch Size", Type: "log"},
+		charts.YAxisOpts{Name: "Latency(ms)", Type: "log"},
 	)
-	return bar
+	return line
 }
 
-func (o batchPlot) BarPlot() *charts.Bar {
-	bar := charts.NewBar()
-	bar.SetGlobalOptions(
-		charts.ToolboxOpts{Show: true, TBFeature{SaveAsImage: struct{pixelRatio: 5}}},
+func (o batchPlot) LinePlot() *charts.Line {
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.TitleOpts{Title: o.Name},
+		charts.TooltipOpts{Show: true},
+		charts.ToolboxOpts{Show: true, TBFeature: charts.TBFeature{SaveAsImage: charts.SaveAsImage{PixelRatio: 5}}},
 	)
-	bar = o.BarPlotAdd(bar)
-	return bar
+	line = o.LinePlotAdd(line)
+	return line
 }
 
 func (o batchPlot) Write(path string) error {
-	bar := o.BarPlot(o.Name)
+	line := o.LinePlot()
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
-	err = bar.Render(f)
+	err = line.Render(f)
 	if err != nil {
 		return err
 	}
@@ -167,7 +149,7 @@ func (o batchPlot) Write(path string) error {
 }
 
 func (o batchPlot) Open() error {
-	path := tempFile("", "batchPlot_*.html")
+	path := evaluation.TempFile("", "batchPlot_*.html")
 	if path == "" {
 		return errors.New("failed to create temporary file")
 	}
@@ -184,8 +166,8 @@ func (o batchPlot) Open() error {
 }
 
 func (o batchPlot) Handler(w http.ResponseWriter, _ *http.Request) {
-	bar := o.BarPlot(o.Name)
-	bar.Render(w)
+	line := o.LinePlot()
+	line.Render(w)
 }
 
 func (o batchPlot) Header(opts ...writer.Option) []string {
@@ -7,6 +7,7 @@ import (
 
 	"github.com/mattn/go-zglob"
 	"github.com/spf13/cast"
+	funk "github.com/thoas/go-funk"
 )
 
 func contains(lst interface{}, elem interface{}) bool {
@@ -7,6 +7,12 @@ import (
 	"github.com/rai-project/database/mongodb"
 	"github.com/rai-project/dlframework"
 	"gopkg.in/mgo.v2/bson"
+	db "upper.io/db.v3"
+)
+
+var (
+	// DefaultFindChunkSize is the number of ids looked up by each query
+	DefaultFindChunkSize = 1000
 )
 
 type InputPrediction struct {
@@ -50,6 +56,101 @@ func (c *InputPredictionCollection) Find(as ...interface{}) ([]InputPrediction,
 	return preds, nil
 }
 
+// FindInputIDs returns the input id of each of the input predictions. Only the ids are fetched
+// and the lookups are done in chunks of DefaultFindChunkSize ids.
+func (c *InputPredictionCollection) FindInputIDs(ids []bson.ObjectId) (map[bson.ObjectId]string, error) {
+	res := map[bson.ObjectId]string{}
+	collection := c.Session.Collection(c.Name())
+	for start := 0; start < len(ids); start += DefaultFindChunkSize {
+		end := minInt(start+DefaultFindChunkSize, len(ids))
+		preds := []InputPrediction{}
+		err := collection.Find(db.Cond{"_id IN": ids[start:end]}).Select("_id", "inputid").All(&preds)
+		if err != nil {
+			return nil, err
+		}
+		for _, pred := range preds {
+			res[pred.ID] = pred.InputID
+		}
+	}
+	return res, nil
+}
+
+// InputPredictionPair is the source and target input predictions of the same input
+type InputPredictionPair struct {
+	InputID  string
+	SourceID bson.ObjectId
+	TargetID bson.ObjectId
+}
+
+// InputAlignment pairs the input predictions of two evaluations by their input id
+type InputAlignment struct {
+	Pairs []InputPredictionPair
+	// SourceOnly and TargetOnly are the input ids that are only present in one of the evaluations
+	SourceOnly []string
+	TargetOnly []string
+	// NumMissing is the number of input prediction ids that were not found in the database
+	NumMissing int
+}
+
+// AlignInputPredictions pairs the source and target input predictions by input id.
+// The pairs follow the source order and only the first prediction of a repeated input id is used.
+func AlignInputPredictions(sourceIDs, targetIDs []bson.ObjectId, inputIDs map[bson.ObjectId]string) *InputAlignment {
+	res := &InputAlignment{}
+
+	index := func(ids []bson.ObjectId) ([]string, map[string]bson.ObjectId) {
+		order := []string{}
+		byInputID := map[string]bson.ObjectId{}
+		for _, id := range ids {
+			inputID, ok := inputIDs[id]
+			if !ok {
+				res.NumMissing++
+				continue
+			}
+			if _, ok := byInputID[inputID]; ok {
+				continue
+			}
+			byInputID[inputID] = id
+			order = append(order, inputID)
+		}
+		return order, byInputID
+	}
+
+	sourceOrder, sources := index(sourceIDs)
+	targetOrder, targets := index(targetIDs)
+
+	for _, inputID := range sourceOrder {
+		targetID, ok := targets[inputID]
+		if !ok {
+			res.SourceOnly = append(res.SourceOnly, inputID)
+			continue
+		}
+		res.Pairs = append(res.Pairs, InputPredictionPair{
+			InputID:  inputID,
+			SourceID: sources[inputID],
+			TargetID: targetID,
+		})
+	}
+	for _, inputID := range targetOrder {
+		if _, ok := sources[inputID]; !ok {
+			res.TargetOnly = append(res.TargetOnly, inputID)
+		}
+	}
+
+	return res
+}
+
+// AlignInputPredictions looks up the input ids of the evaluations and pairs their input predictions
+func (c *InputPredictionCollection) AlignInputPredictions(source, target Evaluation) (*InputAlignment, error) {
+	ids := make([]bson.ObjectId, 0, len(source.InputPredictionIDs)+len(target.InputPredictionIDs))
+	ids = append(ids, source.InputPredictionIDs...)
+	ids = append(ids, target.InputPredictionIDs...)
+	inputIDs, err := c.FindInputIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	return AlignInputPredictions(source.InputPredictionIDs, target.InputPredictionIDs, inputIDs), nil
+}
+
 func (m *InputPredictionCollection) Close() error {
 	return nil
 }
@@ -0,0 +1,73 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAlignInputPredictions(t *testing.T) {
+	ids := make([]bson.ObjectId, 8)
+	for ii := range ids {
+		ids[ii] = bson.NewObjectId()
+	}
+	// ids[7] is not in the database
+	inputIDs := map[bson.ObjectId]string{
+		ids[0]: "a", ids[1]: "b", ids[2]: "c",
+		ids[3]: "b", ids[4]: "a", ids[5]: "d", ids[6]: "a",
+	}
+
+	cases := []struct {
+		name       string
+		sources    []bson.ObjectId
+		targets    []bson.ObjectId
+		pairs      []InputPredictionPair
+		sourceOnly []string
+		targetOnly []string
+		numMissing int
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:       "empty target",
+			sources:    []bson.ObjectId{ids[0], ids[1]},
+			sourceOnly: []string{"a", "b"},
+		},
+		{
+			name:       "disjoint",
+			sources:    []bson.ObjectId{ids[2]},
+			targets:    []bson.ObjectId{ids[5]},
+			sourceOnly: []string{"c"},
+			targetOnly: []string{"d"},
+		},
+		{
+			name:    "source order",
+			sources: []bson.ObjectId{ids[0], ids[1], ids[2]},
+			targets: []bson.ObjectId{ids[3], ids[4], ids[5]},
+			pairs: []InputPredictionPair{
+				{InputID: "a", SourceID: ids[0], TargetID: ids[4]},
+				{InputID: "b", SourceID: ids[1], TargetID: ids[3]},
+			},
+			sourceOnly: []string{"c"},
+			targetOnly: []string{"d"},
+		},
+		{
+			name:    "duplicates and missing",
+			sources: []bson.ObjectId{ids[0], ids[0], ids[7]},
+			targets: []bson.ObjectId{ids[4], ids[6], ids[7]},
+			pairs: []InputPredictionPair{
+				{InputID: "a", SourceID: ids[0], TargetID: ids[4]},
+			},
+			numMissing: 2,
+		},
+	}
+	for _, c := range cases {
+		res := AlignInputPredictions(c.sources, c.targets, inputIDs)
+		assert.Equal(t, c.pairs, res.Pairs, c.name)
+		assert.Equal(t, c.sourceOnly, res.SourceOnly, c.name)
+		assert.Equal(t, c.targetOnly, res.TargetOnly, c.name)
+		assert.Equal(t, c.numMissing, res.NumMissing, c.name)
+	}
+}
@@ -0,0 +1,514 @@
+package evaluation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+var (
+	// DefaultReportTopLayers is the number of layers listed in a report
+	DefaultReportTopLayers = 10
+	// DefaultReportTopKernels is the number of kernels listed in a report
+	DefaultReportTopKernels = 10
+)
+
+// ReportEnvironment is the software and hardware stack an evaluation ran on
+type ReportEnvironment struct {
+	HostName                 string
+	MachineArchitecture      string
+	FrameworkName            string
+	FrameworkVersion         string
+	CPUName                  string
+	GPUName                  string
+	GPUDriver                string
+	InterconnectName         string
+	TheoreticalGFlops        int64
+	MemoryBandwidth          float64
+	IdealArithmeticIntensity float64
+	BatchSizes               []int
+}
+
+// ReportChart is a chart rendered as a self contained echarts html page of the width and height, and
+// as a svg image. The markdown reports show the svg, since the markdown renderers strip the iframes
+// and the scripts of the html page.
+type ReportChart struct {
+	Title  string
+	HTML   string
+	SVG    string
+	Width  int
+	Height int
+}
+
+// Report is the data the report templates are executed over
+type Report struct {
+	Title        string
+	CreatedAt    time.Time
+	Environments []ReportEnvironment
+	Models       SummaryModelInformations
+	Layers       SummaryLayerInformations
+	Kernels      SummaryGPUKernelNameAggreInformations
+	Charts       []ReportChart
+}
+
+func NewReport(title string) *Report {
+	return &Report{
+		Title:     title,
+		CreatedAt: time.Now(),
+	}
+}
+
+// AddEnvironment adds the environment of the summary unless an environment with the same
+// host, framework and GPU is already in the report
+func (r *Report) AddEnvironment(s SummaryBase) {
+	env := ReportEnvironment{
+		HostName:                 s.HostName,
+		MachineArchitecture:      s.MachineArchitecture,
+		FrameworkName:            s.FrameworkName,
+		FrameworkVersion:         s.FrameworkVersion,
+		InterconnectName:         s.InterconnectName,
+		TheoreticalGFlops:        s.TheoreticalGFlops,
+		MemoryBandwidth:          s.MemoryBandwidth,
+		IdealArithmeticIntensity: s.IdealArithmeticIntensity,
+	}
+	if s.MachineInformation != nil && len(s.MachineInformation.CPU) != 0 {
+		env.CPUName = s.MachineInformation.CPU[0].ModelName
+	}
+	if s.GPUInformation != nil {
+		env.GPUName = s.GPUInformation.ProductName
+	}
+	if s.GPUDriverVersion != nil {
+		env.GPUDriver = *s.GPUDriverVersion
+	}
+	for ii, e := range r.Environments {
+		if e.HostName != env.HostName || e.MachineArchitecture != env.MachineArchitecture ||
+			e.FrameworkName != env.FrameworkName || e.FrameworkVersion != env.FrameworkVersion ||
+			e.GPUName != env.GPUName {
+			continue
+		}
+		for _, batchSize := range e.BatchSizes {
+			if batchSize == s.BatchSize {
+				return
+			}
+		}
+		r.Environments[ii].BatchSizes = append(e.BatchSizes, s.BatchSize)
+		sort.Ints(r.Environments[ii].BatchSizes)
+		return
+	}
+	env.BatchSizes = []int{s.BatchSize}
+	r.Environments = append(r.Environments, env)
+}
+
+// newReportChart renders the chart as an image and as a html page. The assets of the html page are
+// embedded whatever the plot asset mode, since the page is inlined in the report. If the assets are
+// not vendored, the html is left empty and the html reports show the image of the chart instead.
+func newReportChart(title string, width, height int, render func(io.Writer) error, static func() (*staticPlot, error)) (ReportChart, error) {
+	chart := ReportChart{
+		Title:  strings.Join(strings.Fields(title), " "),
+		Width:  width,
+		Height: height,
+	}
+	plot, err := static()
+	if err != nil {
+		return chart, errors.Wrapf(err, "failed to render the %v chart", chart.Title)
+	}
+	buf := new(bytes.Buffer)
+	if err := plot.Write(buf, "svg"); err != nil {
+		return chart, errors.Wrapf(err, "failed to render the %v chart", chart.Title)
+	}
+	chart.SVG = buf.String()
+
+	buf = new(bytes.Buffer)
+	if err := render(buf); err != nil {
+		return chart, errors.Wrapf(err, "failed to render the %v chart", chart.Title)
+	}
+	html, err := localizePlotAssets(buf.Bytes(), PlotAssetsEmbed, "")
+	if err != nil {
+		log.WithError(err).WithField("chart", chart.Title).Warn("the chart is shown as an image in the html report")
+		return chart, nil
+	}
+	chart.HTML = string(html)
+	return chart, nil
+}
+
+func barReportChart(o BarPlotter) (ReportChart, error) {
+	return newReportChart(o.PlotName(), DefaultBarPlotWidth, DefaultBarPlotHeight,
+		func(w io.Writer) error { return renderBarPlot(o, w) },
+		func() (*staticPlot, error) { return staticBarPlot(o) })
+}
+
+func boxReportChart(o BoxPlotter) (ReportChart, error) {
+	return newReportChart(o.PlotName(), DefaultBarPlotWidth, DefaultBarPlotHeight,
+		func(w io.Writer) error { return renderBoxPlot(o, w) },
+		func() (*staticPlot, error) { return staticBoxPlot(o) })
+}
+
+func pieReportChart(o PiePlotter) (ReportChart, error) {
+	return newReportChart(o.PlotName(), DefaultPiePlotWidth, DefaultPiePlotHeight,
+		func(w io.Writer) error { return renderPiePlot(o, w) },
+		func() (*staticPlot, error) { return staticPiePlot(o) })
+}
+
+func scatterReportChart(o ScatterPlotter) (ReportChart, error) {
+	return newReportChart(o.PlotName(), DefaultBarPlotWidth, DefaultBarPlotHeight,
+		func(w io.Writer) error { return renderScatterPlot(o, w) },
+		func() (*staticPlot, error) { return staticScatterPlot(o) })
+}
+
+func (r *Report) addChart(chart ReportChart, err error) error {
+	if err != nil {
+		return err
+	}
+	r.Charts = append(r.Charts, chart)
+	return nil
+}
+
+func (r *Report) AddBarPlot(o BarPlotter) error {
+	return r.addChart(barReportChart(o))
+}
+
+func (r *Report) AddBoxPlot(o BoxPlotter) error {
+	return r.addChart(boxReportChart(o))
+}
+
+func (r *Report) AddPiePlot(o PiePlotter) error {
+	return r.addChart(pieReportChart(o))
+}
+
+func (r *Report) AddScatterPlot(o ScatterPlotter) error {
+	return r.addChart(scatterReportChart(o))
+}
+
+// AddModelInformations adds the model latency and throughput table and charts. Nothing is added
+// if a chart fails to render.
+func (r *Report) AddModelInformations(models SummaryModelInformations) error {
+	if len(models) == 0 {
+		return nil
+	}
+	sort.Sort(models)
+	latency, err := barReportChart(SummaryModelLatencyInformations(models))
+	if err != nil {
+		return err
+	}
+	throughput, err := barReportChart(SummaryModelThroughputInformations(models))
+	if err != nil {
+		return err
+	}
+
+	for _, model := range models {
+		r.AddEnvironment(model.SummaryBase)
+	}
+	r.Models = append(r.Models, models...)
+	r.Charts = append(r.Charts, latency, throughput)
+	return nil
+}
+
+// AddLayerInformations adds the top layers ranked by duration, the layer latency chart and the
+// breakdown of the layer types by duration and allocated memory. Nothing is added if a chart fails
+// to render.
+func (r *Report) AddLayerInformations(layers SummaryLayerInformations, aggre SummaryLayerAggreInformations, top int) error {
+	if len(layers) == 0 {
+		return nil
+	}
+	chart, err := barReportChart(SummaryLayerLatencyInformations(layers))
+	if err != nil {
+		return err
+	}
+	charts := []ReportChart{chart}
+	if len(aggre) != 0 {
+		for _, o := range []PiePlotter{
+			SummaryLayerAggreDurationInformations(aggre),
+			SummaryLayerAggreAllocatedMemoryInformations(aggre),
+		} {
+			chart, err := pieReportChart(o)
+			if err != nil {
+				return err
+			}
+			charts = append(charts, chart)
+		}
+	}
+
+	sorted := append(SummaryLayerInformations{}, layers...)
+	sort.SliceStable(sorted, func(ii, jj int) bool {
+		return sorted[ii].Duration > sorted[jj].Duration
+	})
+	if top >= 0 && top < len(sorted) {
+		sorted = sorted[:top]
+	}
+	r.AddEnvironment(layers[0].SummaryBase)
+	r.Layers = append(r.Layers, sorted...)
+	r.Charts = append(r.Charts, charts...)
+	return nil
+}
+
+// AddGPUKernelInformations adds the top kernels ranked by duration, the roofline of the layers and
+// the breakdown of the layer latency between the GPU and the CPU. Nothing is added if a chart fails
+// to render.
+func (r *Report) AddGPUKernelInformations(layers SummaryGPUKernelLayerAggreInformations, kernels SummaryGPUKernelNameAggreInformations, top int) error {
+	charts := []ReportChart{}
+	if len(layers) != 0 {
+		roofline, err := scatterReportChart(SummaryGPUKernelLayerRooflineInformations(layers))
+		if err != nil {
+			return err
+		}
+		gpuCPU, err := barReportChart(SummaryGPUKernelLayerGPUCPUInformations(layers))
+		if err != nil {
+			return err
+		}
+		charts = append(charts, roofline, gpuCPU)
+	}
+
+	if len(kernels) != 0 {
+		sorted := append(SummaryGPUKernelNameAggreInformations{}, kernels...)
+		sort.Sort(sorted)
+		if top >= 0 && top < len(sorted) {
+			sorted = sorted[:top]
+		}
+		r.Kernels = append(r.Kernels, sorted...)
+	}
+	if len(layers) != 0 {
+		r.AddEnvironment(layers[0].SummaryBase)
+	}
+	r.Charts = append(r.Charts, charts...)
+	return nil
+}
+
+func reportFixed(v interface{}) string {
+	return fmt.Sprintf("%.2f", cast.ToFloat64(v))
+}
+
+func reportPercent(v interface{}) string {
+	return fmt.Sprintf("%.2f%%", cast.ToFloat64(v)*100)
+}
+
+func reportInts(vs []int) string {
+	res := make([]string, len(vs))
+	for ii, v := range vs {
+		res[ii] = cast.ToString(v)
+	}
+	return strings.Join(res, ", ")
+}
+
+// reportCell escapes the characters which break a markdown table cell
+func reportCell(v interface{}) string {
+	s := strings.Replace(cast.ToString(v), "|", `\|`, -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+// reportChartImage embeds the svg of the chart as a markdown image
+func reportChartImage(chart ReportChart) string {
+	if chart.SVG == "" {
+		return reportChartHTML(chart)
+	}
+	title := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(chart.Title)
+	return fmt.Sprintf("![%s](data:image/svg+xml;base64,%s)", title, base64.StdEncoding.EncodeToString([]byte(chart.SVG)))
+}
+
+// reportChartHTML embeds the chart page in an iframe so the scripts of the charts do not clash.
+// The iframe leaves a margin around the chart for the page body. The charts without a html page
+// are embedded as svg images.
+func reportChartHTML(chart ReportChart) string {
+	if chart.HTML == "" && chart.SVG != "" {
+		return fmt.Sprintf(
+			`<img alt="%s" src="data:image/svg+xml;base64,%s" width="%d" height="%d">`,
+			htmltemplate.HTMLEscapeString(chart.Title),
+			base64.StdEncoding.EncodeToString([]byte(chart.SVG)),
+			chart.Width,
+			chart.Height,
+		)
+	}
+	return fmt.Sprintf(
+		`<iframe title="%s" srcdoc="%s" width="%d" height="%d" style="border:none"></iframe>`,
+		htmltemplate.HTMLEscapeString(chart.Title),
+		htmltemplate.HTMLEscapeString(chart.HTML),
+		chart.Width+50,
+		chart.Height+50,
+	)
+}
+
+func reportFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"fixed":   reportFixed,
+		"percent": reportPercent,
+		"ints":    reportInts,
+		"cell":    reportCell,
+		"join":    strings.Join,
+		"date":    func(t time.Time) string { return t.Format(time.RFC1123) },
+	}
+}
+
+// ReportTemplate returns the default template of the markdown ("md") or "html" report format
+func ReportTemplate(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "md", "markdown":
+		return DefaultMarkdownReportTemplate, nil
+	case "html", "htm":
+		return DefaultHTMLReportTemplate, nil
+	}
+	return "", errors.Errorf("the report format %v is not supported", format)
+}
+
+// ReadReportTemplate reads a template overriding the default one
+func ReadReportTemplate(path string) (string, error) {
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read the report template %v", path)
+	}
+	return string(bts), nil
+}
+
+// Write executes the template over the report. The default template of the format is used if tmpl is empty.
+// Html templates are executed with html/template, so the values are escaped, and markdown
+// templates with text/template. The `chart` function embeds a chart inline in both formats, as an
+// interactive html page in the html reports and as a svg image in the markdown reports.
+func (r *Report) Write(w io.Writer, format string, tmpl string) error {
+	var err error
+	if tmpl == "" {
+		tmpl, err = ReportTemplate(format)
+		if err != nil {
+			return err
+		}
+	}
+	switch strings.ToLower(format) {
+	case "md", "markdown":
+		funcs := template.FuncMap(reportFuncs())
+		funcs["chart"] = reportChartImage
+		t, err := template.New("report").Funcs(funcs).Parse(tmpl)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse the markdown report template")
+		}
+		return t.Execute(w, r)
+	case "html", "htm":
+		funcs := htmltemplate.FuncMap(reportFuncs())
+		funcs["chart"] = func(chart ReportChart) htmltemplate.HTML {
+			return htmltemplate.HTML(reportChartHTML(chart))
+		}
+		t, err := htmltemplate.New("report").Funcs(funcs).Parse(tmpl)
+		if err != nil {
+			return errors.Wrap(err, "failed to parse the html report template")
+		}
+		return t.Execute(w, r)
+	}
+	return errors.Errorf("the report format %v is not supported", format)
+}
+
+var DefaultMarkdownReportTemplate = `# {{.Title}}
+
+Generated on {{date .CreatedAt}}
+{{if .Environments}}
+## Environment
+
+| Host | Architecture | Framework | CPU | GPU | GPU Driver | Interconnect | Theoretical GFlops | Memory Bandwidth (GB/s) | Ideal Arithmetic Intensity (flops/byte) | Batch Sizes |
+|------|--------------|-----------|-----|-----|------------|--------------|--------------------|-------------------------|-----------------------------------------|-------------|
+{{- range .Environments}}
+| {{cell .HostName}} | {{cell .MachineArchitecture}} | {{cell .FrameworkName}} {{cell .FrameworkVersion}} | {{cell .CPUName}} | {{cell .GPUName}} | {{cell .GPUDriver}} | {{cell .InterconnectName}} | {{.TheoreticalGFlops}} | {{fixed .MemoryBandwidth}} | {{fixed .IdealArithmeticIntensity}} | {{ints .BatchSizes}} |
+{{- end}}
+{{end}}
+{{- if .Models}}
+## Model Latency and Throughput
+
+| Model | Framework | Batch Size | Latency (ms) | Throughput (input/s) |
+|-------|-----------|------------|--------------|----------------------|
+{{- range .Models}}
+| {{cell .ModelName}} {{cell .ModelVersion}} | {{cell .FrameworkName}} {{cell .FrameworkVersion}} | {{.BatchSize}} | {{fixed .Latency}} | {{fixed .Throughput}} |
+{{- end}}
+{{end}}
+{{- if .Layers}}
+## Top Layers
+
+| Index | Name | Type | Shape | Duration (us) |
+|-------|------|------|-------|---------------|
+{{- range .Layers}}
+| {{.Index}} | {{cell .Name}} | {{cell .Type}} | {{cell .Shape}} | {{fixed .Duration}} |
+{{- end}}
+{{end}}
+{{- if .Kernels}}
+## Top GPU Kernels
+
+| Name | Count | Duration (us) | Achieved Occupancy | Arithmetic Intensity (flops/byte) | Arithmetic Throughput (GFlops) | Memory Bound |
+|------|-------|---------------|--------------------|-----------------------------------|--------------------------------|--------------|
+{{- range .Kernels}}
+| {{cell .Name}} | {{.Count}} | {{fixed .Duration}} | {{percent .AchievedOccupancy}} | {{fixed .ArithmeticIntensity}} | {{fixed .ArithmeticThroughput}} | {{.MemoryBound}} |
+{{- end}}
+{{end}}
+{{- if .Charts}}
+## Charts
+{{range .Charts}}
+### {{.Title}}
+
+{{chart .}}
+{{end}}
+{{- end}}
+`
+
+var DefaultHTMLReportTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f0f0f0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Generated on {{date .CreatedAt}}</p>
+{{if .Environments}}
+<h2>Environment</h2>
+<table>
+<tr><th>Host</th><th>Architecture</th><th>Framework</th><th>CPU</th><th>GPU</th><th>GPU Driver</th><th>Interconnect</th><th>Theoretical GFlops</th><th>Memory Bandwidth (GB/s)</th><th>Ideal Arithmetic Intensity (flops/byte)</th><th>Batch Sizes</th></tr>
+{{- range .Environments}}
+<tr><td>{{.HostName}}</td><td>{{.MachineArchitecture}}</td><td>{{.FrameworkName}} {{.FrameworkVersion}}</td><td>{{.CPUName}}</td><td>{{.GPUName}}</td><td>{{.GPUDriver}}</td><td>{{.InterconnectName}}</td><td>{{.TheoreticalGFlops}}</td><td>{{fixed .MemoryBandwidth}}</td><td>{{fixed .IdealArithmeticIntensity}}</td><td>{{ints .BatchSizes}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+{{- if .Models}}
+<h2>Model Latency and Throughput</h2>
+<table>
+<tr><th>Model</th><th>Framework</th><th>Batch Size</th><th>Latency (ms)</th><th>Throughput (input/s)</th></tr>
+{{- range .Models}}
+<tr><td>{{.ModelName}} {{.ModelVersion}}</td><td>{{.FrameworkName}} {{.FrameworkVersion}}</td><td>{{.BatchSize}}</td><td>{{fixed .Latency}}</td><td>{{fixed .Throughput}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+{{- if .Layers}}
+<h2>Top Layers</h2>
+<table>
+<tr><th>Index</th><th>Name</th><th>Type</th><th>Shape</th><th>Duration (us)</th></tr>
+{{- range .Layers}}
+<tr><td>{{.Index}}</td><td>{{.Name}}</td><td>{{.Type}}</td><td>{{.Shape}}</td><td>{{fixed .Duration}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+{{- if .Kernels}}
+<h2>Top GPU Kernels</h2>
+<table>
+<tr><th>Name</th><th>Count</th><th>Duration (us)</th><th>Achieved Occupancy</th><th>Arithmetic Intensity (flops/byte)</th><th>Arithmetic Throughput (GFlops)</th><th>Memory Bound</th></tr>
+{{- range .Kernels}}
+<tr><td>{{.Name}}</td><td>{{.Count}}</td><td>{{fixed .Duration}}</td><td>{{percent .AchievedOccupancy}}</td><td>{{fixed .ArithmeticIntensity}}</td><td>{{fixed .ArithmeticThroughput}}</td><td>{{.MemoryBound}}</td></tr>
+{{- end}}
+</table>
+{{end}}
+{{- if .Charts}}
+<h2>Charts</h2>
+{{- range .Charts}}
+<h3>{{.Title}}</h3>
+{{chart .}}
+{{- end}}
+{{end}}
+</body>
+</html>
+`
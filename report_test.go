@@ -0,0 +1,185 @@
+package evaluation
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the tests")
+
+const testReportSVG = `<svg width="900" height="300"></svg>`
+
+func testReport() *Report {
+	driver := "418.67"
+	base := SummaryBase{
+		ModelName:                "ResNet50",
+		ModelVersion:             "1.0",
+		FrameworkName:            "MXNet",
+		FrameworkVersion:         "1.4.0",
+		MachineArchitecture:      "amd64",
+		HostName:                 "host|1",
+		GPUDriverVersion:         &driver,
+		InterconnectName:         "PCIe",
+		TheoreticalGFlops:        15700,
+		MemoryBandwidth:          900,
+		IdealArithmeticIntensity: 17.44,
+	}
+
+	r := NewReport("ResNet50 <Report>")
+	r.CreatedAt = time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	for _, batchSize := range []int{8, 1, 8} {
+		base.BatchSize = batchSize
+		r.AddEnvironment(base)
+	}
+	base.BatchSize = 1
+	r.Models = SummaryModelInformations{
+		{SummaryBase: base, Latency: 5.125, Throughput: 195.12},
+	}
+	r.Layers = SummaryLayerInformations{
+		{SummaryModelInformation: r.Models[0], Index: 3, Name: "conv1", Type: "Conv2D", Shape: "[1 64 112 112]", Duration: 512.5},
+	}
+	r.Kernels = SummaryGPUKernelNameAggreInformations{
+		{SummaryModelInformation: r.Models[0], Name: "sgemm", Count: 4, Duration: 100, AchievedOccupancy: 0.5, ArithmeticIntensity: 2, ArithmeticThroughput: 1000, MemoryBound: true},
+	}
+	r.Charts = []ReportChart{
+		{Title: "Layer Latency", HTML: `<div id="chart" title="a & b"></div>`, SVG: testReportSVG, Width: 900, Height: 300},
+	}
+	return r
+}
+
+func TestReportAddEnvironment(t *testing.T) {
+	r := testReport()
+	require.Len(t, r.Environments, 1)
+	assert.Equal(t, []int{1, 8}, r.Environments[0].BatchSizes)
+	assert.Equal(t, "418.67", r.Environments[0].GPUDriver)
+
+	r.AddEnvironment(SummaryBase{HostName: "host|1", FrameworkName: "TensorFlow", BatchSize: 2})
+	require.Len(t, r.Environments, 2)
+	assert.Equal(t, []int{2}, r.Environments[1].BatchSizes)
+}
+
+func TestReportWrite(t *testing.T) {
+	for _, format := range []string{"md", "html"} {
+		buf := new(bytes.Buffer)
+		require.NoError(t, testReport().Write(buf, format, ""), format)
+
+		golden := filepath.Join("testdata", "report."+format+".golden")
+		if *updateGolden {
+			require.NoError(t, ioutil.WriteFile(golden, buf.Bytes(), 0644))
+		}
+		expected, err := ioutil.ReadFile(golden)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), buf.String(), format)
+	}
+
+	assert.Error(t, testReport().Write(new(bytes.Buffer), "pdf", ""))
+}
+
+func TestReportWriteTemplate(t *testing.T) {
+	tmpl := `{{.Title}}: {{len .Models}} {{range .Charts}}{{chart .}}{{end}}`
+	chart := `<iframe title="Layer Latency" srcdoc="&lt;div id=&#34;chart&#34; title=&#34;a &amp; b&#34;&gt;&lt;/div&gt;" width="950" height="350" style="border:none"></iframe>`
+	image := "![Layer Latency](data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(testReportSVG)) + ")"
+
+	// the markdown reports show the images of the charts
+	buf := new(bytes.Buffer)
+	require.NoError(t, testReport().Write(buf, "md", tmpl))
+	assert.Equal(t, "ResNet50 <Report>: 1 "+image, buf.String())
+
+	// the values are escaped in html, but not the charts
+	buf.Reset()
+	require.NoError(t, testReport().Write(buf, "html", tmpl))
+	assert.Equal(t, "ResNet50 &lt;Report&gt;: 1 "+chart, buf.String())
+
+	// the charts without a html page are shown as images in html
+	r := testReport()
+	r.Charts[0].HTML = ""
+	buf.Reset()
+	require.NoError(t, r.Write(buf, "html", tmpl))
+	assert.Equal(t, `ResNet50 &lt;Report&gt;: 1 <img alt="Layer Latency" src="data:image/svg+xml;base64,`+
+		base64.StdEncoding.EncodeToString([]byte(testReportSVG))+`" width="900" height="300">`, buf.String())
+
+	assert.Error(t, testReport().Write(new(bytes.Buffer), "md", "{{.Title"))
+	assert.Error(t, testReport().Write(new(bytes.Buffer), "html", "{{.Missing}}"))
+}
+
+func TestReportAddSections(t *testing.T) {
+	assetDir, vendored := DefaultAssetDir, vendoredPlotAssets
+	defer func() { DefaultAssetDir, vendoredPlotAssets = assetDir, vendored }()
+	DefaultAssetDir = ""
+
+	sections := func() *Report {
+		layers, aggre := testPlotterLayers()
+		aggre[0].AllocatedMemoryPercentage = 40
+		aggre[1].AllocatedMemoryPercentage = 60
+		models := SummaryModelInformations{
+			{SummaryBase: SummaryBase{ModelName: "ResNet50", FrameworkName: "MXNet", BatchSize: 2}, Latency: 4, Throughput: 500},
+			{SummaryBase: SummaryBase{ModelName: "ResNet50", FrameworkName: "MXNet", BatchSize: 1}, Latency: 5, Throughput: 200},
+		}
+		kernelLayers := SummaryGPUKernelLayerAggreInformations{
+			{SummaryLayerInformation: layers[0], GPUDuration: 8, CPUDuration: 2, ArithmeticIntensity: 4, ArithmeticThroughput: 100},
+			{SummaryLayerInformation: layers[1], GPUDuration: 1, CPUDuration: 1, ArithmeticIntensity: 0.5, ArithmeticThroughput: 10, MemoryBound: true},
+		}
+		kernels := SummaryGPUKernelNameAggreInformations{
+			{SummaryModelInformation: models[1], Name: "relu", Duration: 10},
+			{SummaryModelInformation: models[1], Name: "sgemm", Duration: 100},
+		}
+
+		r := NewReport("ResNet50")
+		require.NoError(t, r.AddModelInformations(models))
+		require.NoError(t, r.AddLayerInformations(layers, aggre, 1))
+		require.NoError(t, r.AddGPUKernelInformations(kernelLayers, kernels, 1))
+		return r
+	}
+
+	// without the vendored assets, the charts are only rendered as images
+	vendoredPlotAssets = map[string][]byte{}
+	r := sections()
+	require.Len(t, r.Models, 2)
+	assert.Equal(t, 1, r.Models[0].BatchSize)
+	require.Len(t, r.Layers, 1)
+	assert.Equal(t, "conv1", r.Layers[0].Name)
+	require.Len(t, r.Kernels, 1)
+	assert.Equal(t, "sgemm", r.Kernels[0].Name)
+	require.Len(t, r.Environments, 1)
+	assert.Equal(t, []int{1, 2}, r.Environments[0].BatchSizes)
+
+	// the latency and throughput, the layer latency and the two layer type pies, the roofline and the gpu vs cpu latency
+	require.Len(t, r.Charts, 7)
+	for _, chart := range r.Charts {
+		assert.True(t, strings.HasPrefix(chart.SVG, "<svg"), chart.Title)
+		assert.Empty(t, chart.HTML, chart.Title)
+	}
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, r.Write(buf, "md", ""))
+	assert.NotContains(t, buf.String(), "<iframe")
+	assert.Equal(t, 7, strings.Count(buf.String(), "](data:image/svg+xml;base64,"))
+	buf.Reset()
+	require.NoError(t, r.Write(buf, "html", ""))
+	assert.NotContains(t, buf.String(), "<iframe")
+	assert.Equal(t, 7, strings.Count(buf.String(), `<img alt="`))
+
+	// with the vendored assets, the html reports embed the interactive charts
+	vendoredPlotAssets = map[string][]byte{}
+	for _, name := range []string{"echarts.min.js", "themes/shine.js", "bulma.min.css"} {
+		vendoredPlotAssets[name] = []byte("/* vendored */")
+	}
+	r = sections()
+	require.Len(t, r.Charts, 7)
+	buf.Reset()
+	require.NoError(t, r.Write(buf, "html", ""))
+	assert.Equal(t, 7, strings.Count(buf.String(), "<iframe"))
+	assert.NotContains(t, buf.String(), DefaultAssetHost)
+	buf.Reset()
+	require.NoError(t, r.Write(buf, "md", ""))
+	assert.NotContains(t, buf.String(), "<iframe")
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/rai-project/evaluation/writer"
 	"github.com/rai-project/machine"
 	nvidiasmi "github.com/rai-project/nvidia-smi"
@@ -96,6 +97,56 @@ func (s SummaryBase) FrameworkModel() string {
 	return s.FrameworkName + "::" + s.FrameworkVersion + "/" + s.ModelName + "::" + s.ModelVersion
 }
 
+// SeriesKeys are the keys the line plot series can be named by
+var SeriesKeys = []string{"model", "framework", "gpu", "host", "arch"}
+
+// ParseSeriesKeys parses comma separated series keys, e.g. "model,gpu"
+func ParseSeriesKeys(s string) ([]string, error) {
+	res := []string{}
+	for _, key := range strings.Split(s, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		valid := false
+		for _, k := range SeriesKeys {
+			valid = valid || k == key
+		}
+		if !valid {
+			return nil, errors.Errorf("invalid series key %v, expecting one of %v", key, strings.Join(SeriesKeys, ", "))
+		}
+		res = append(res, key)
+	}
+	if len(res) == 0 {
+		return nil, errors.New("missing series key")
+	}
+	return res, nil
+}
+
+// SeriesName names the line plot series of the summary by the series keys
+func (s SummaryBase) SeriesName(keys []string) string {
+	res := []string{}
+	for _, key := range keys {
+		switch key {
+		case "model":
+			res = append(res, s.ModelName+"::"+s.ModelVersion)
+		case "framework":
+			res = append(res, s.FrameworkName+"::"+s.FrameworkVersion)
+		case "gpu":
+			if s.UsingGPU && s.GPUInformation != nil {
+				res = append(res, s.GPUInformation.ProductName)
+			} else {
+				res = append(res, "CPU")
+			}
+		case "host":
+			res = append(res, s.HostName)
+		case "arch":
+			res = append(res, s.MachineArchitecture)
+		}
+	}
+	return strings.Join(res, ", ")
+}
+
 func (s SummaryBase) key() string {
 	return strings.Join(
 		[]string{
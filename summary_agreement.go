@@ -0,0 +1,359 @@
+package evaluation
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/spf13/cast"
+)
+
+// SummaryAgreementInformation is the agreement between the top k predictions of two evaluations of the same model
+type SummaryAgreementInformation struct {
+	ModelName                   string  `json:"model_name,omitempty"`
+	ModelVersion                string  `json:"model_version,omitempty"`
+	SourceFrameworkName         string  `json:"source_framework_name,omitempty"`
+	SourceFrameworkVersion      string  `json:"source_framework_version,omitempty"`
+	TargetFrameworkName         string  `json:"target_framework_name,omitempty"`
+	TargetFrameworkVersion      string  `json:"target_framework_version,omitempty"`
+	K                           int     `json:"k,omitempty"`
+	NumInputs                   int64   `json:"num_inputs,omitempty"`
+	Top1Agreement               float64 `json:"top1_agreement,omitempty"`
+	TopKOverlap                 float64 `json:"topk_overlap,omitempty"`
+	TopKRankCorrelation         float64 `json:"topk_rank_correlation,omitempty"`
+	NumLabeled                  int64   `json:"num_labeled,omitempty"`
+	SourceAccuracy              float64 `json:"source_accuracy,omitempty"`
+	TargetAccuracy              float64 `json:"target_accuracy,omitempty"`
+	NumBothCorrect              int64   `json:"num_both_correct,omitempty"`
+	NumBothWrongSameAnswer      int64   `json:"num_both_wrong_same_answer,omitempty"`
+	NumBothWrongDifferentAnswer int64   `json:"num_both_wrong_different_answer,omitempty"`
+}
+
+type SummaryAgreementInformations []SummaryAgreementInformation
+
+func (SummaryAgreementInformation) Header(opts ...writer.Option) []string {
+	return []string{
+		"model_name",
+		"model_version",
+		"source_framework_name",
+		"source_framework_version",
+		"target_framework_name",
+		"target_framework_version",
+		"k",
+		"num_inputs",
+		"top1_agreement",
+		"topk_overlap",
+		"topk_rank_correlation",
+		"num_labeled",
+		"source_accuracy",
+		"target_accuracy",
+		"num_both_correct",
+		"num_both_wrong_same_answer",
+		"num_both_wrong_different_answer",
+	}
+}
+
+func (s SummaryAgreementInformation) Row(opts ...writer.Option) []string {
+	return []string{
+		s.ModelName,
+		s.ModelVersion,
+		s.SourceFrameworkName,
+		s.SourceFrameworkVersion,
+		s.TargetFrameworkName,
+		s.TargetFrameworkVersion,
+		cast.ToString(s.K),
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.Top1Agreement),
+		cast.ToString(s.TopKOverlap),
+		cast.ToString(s.TopKRankCorrelation),
+		cast.ToString(s.NumLabeled),
+		cast.ToString(s.SourceAccuracy),
+		cast.ToString(s.TargetAccuracy),
+		cast.ToString(s.NumBothCorrect),
+		cast.ToString(s.NumBothWrongSameAnswer),
+		cast.ToString(s.NumBothWrongDifferentAnswer),
+	}
+}
+
+func (SummaryAgreementInformations) Header(opts ...writer.Option) []string {
+	return SummaryAgreementInformation{}.Header(opts...)
+}
+
+func (s SummaryAgreementInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// SummaryEnsembleInformation is the accuracy of the majority vote of the top1 predictions of
+// three or more evaluations of the same model
+type SummaryEnsembleInformation struct {
+	ModelName         string    `json:"model_name,omitempty"`
+	ModelVersion      string    `json:"model_version,omitempty"`
+	Frameworks        []string  `json:"frameworks,omitempty"`
+	NumInputs         int64     `json:"num_inputs,omitempty"`
+	NumTies           int64     `json:"num_ties,omitempty"`
+	EnsembleAccuracy  float64   `json:"ensemble_accuracy,omitempty"`
+	BestFramework     string    `json:"best_framework,omitempty"`
+	BestAccuracy      float64   `json:"best_accuracy,omitempty"`
+	FrameworkAccuracy []float64 `json:"framework_accuracy,omitempty"`
+}
+
+type SummaryEnsembleInformations []SummaryEnsembleInformation
+
+func (SummaryEnsembleInformation) Header(opts ...writer.Option) []string {
+	return []string{
+		"model_name",
+		"model_version",
+		"frameworks",
+		"num_inputs",
+		"num_ties",
+		"ensemble_accuracy",
+		"best_framework",
+		"best_accuracy",
+		"framework_accuracy",
+	}
+}
+
+func (s SummaryEnsembleInformation) Row(opts ...writer.Option) []string {
+	accuracies := make([]string, len(s.FrameworkAccuracy))
+	for ii, acc := range s.FrameworkAccuracy {
+		accuracies[ii] = cast.ToString(acc)
+	}
+	return []string{
+		s.ModelName,
+		s.ModelVersion,
+		strings.Join(s.Frameworks, DefaultDimiter),
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.NumTies),
+		cast.ToString(s.EnsembleAccuracy),
+		s.BestFramework,
+		cast.ToString(s.BestAccuracy),
+		strings.Join(accuracies, DefaultDimiter),
+	}
+}
+
+func (SummaryEnsembleInformations) Header(opts ...writer.Option) []string {
+	return SummaryEnsembleInformation{}.Header(opts...)
+}
+
+func (s SummaryEnsembleInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+type agreementPrediction struct {
+	labels   []int
+	expected int
+}
+
+// topKPredictions returns the k most probable labels predicted for each input id
+func (e Evaluation) topKPredictions(predCol *InputPredictionCollection, k int, labels *ClassificationLabels) map[string]agreementPrediction {
+	res := map[string]agreementPrediction{}
+	for _, id := range e.InputPredictionIDs {
+		var pred InputPrediction
+		err := predCol.FindOne(id, &pred)
+		if err != nil {
+			log.WithError(err).WithField("id", id.Hex()).Error("cannot find input prediction")
+			continue
+		}
+		if _, ok := res[pred.InputID]; ok {
+			continue
+		}
+		topK, err := metrics.ClassificationTopKIndices(&pred.Features, k)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			continue
+		}
+		expected := -1
+		if pred.ExpectedLabel != "" {
+			if idx, err := labels.Index(pred.ExpectedLabel); err == nil {
+				expected = idx
+			}
+		}
+		res[pred.InputID] = agreementPrediction{labels: topK, expected: expected}
+	}
+	return res
+}
+
+func frameworkString(e Evaluation) string {
+	return strings.TrimSpace(e.Framework.Name + " " + e.Framework.Version)
+}
+
+// agreementGroups groups the evaluations by model and keeps one evaluation for each framework, the one
+// with the most input predictions, so that repeated runs of a framework are not compared with each other.
+// Only the models evaluated by at least two frameworks are returned.
+func (es Evaluations) agreementGroups() ([]string, map[string]Evaluations) {
+	groups := map[string]Evaluations{}
+	keys := []string{}
+	for _, e := range es {
+		key := strings.ToLower(e.Model.Name) + "/" + e.Model.Version
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		group := groups[key]
+		found := false
+		for ii, other := range group {
+			if strings.ToLower(frameworkString(other)) != strings.ToLower(frameworkString(e)) {
+				continue
+			}
+			found = true
+			if len(e.InputPredictionIDs) > len(other.InputPredictionIDs) {
+				group[ii] = e
+			}
+			break
+		}
+		if !found {
+			group = append(group, e)
+		}
+		groups[key] = group
+	}
+
+	res := []string{}
+	for _, key := range keys {
+		if len(groups[key]) < 2 {
+			log.WithField("model", key).Debug("skipping model evaluated by a single framework")
+			delete(groups, key)
+			continue
+		}
+		res = append(res, key)
+	}
+	return res, groups
+}
+
+// AgreementInformationSummary compares the top k predictions of each pair of frameworks evaluating the same model
+// on the inputs they have in common (paired by input id). A majority vote ensemble summary is computed for
+// models evaluated by three or more frameworks.
+func (es Evaluations) AgreementInformationSummary(predCol *InputPredictionCollection, k int, labels *ClassificationLabels) (SummaryAgreementInformations, SummaryEnsembleInformations, error) {
+	keys, groups := es.agreementGroups()
+	if len(keys) == 0 {
+		return nil, nil, errors.New("the agreement requires evaluations of the same model by at least two frameworks")
+	}
+
+	agreements := SummaryAgreementInformations{}
+	ensembles := SummaryEnsembleInformations{}
+	for _, key := range keys {
+		group := groups[key]
+
+		predictions := make([]map[string]agreementPrediction, len(group))
+		for ii, e := range group {
+			predictions[ii] = e.topKPredictions(predCol, k, labels)
+		}
+
+		for ii := range group {
+			for jj := ii + 1; jj < len(group); jj++ {
+				agreement := metrics.NewAgreement(k)
+				for inputID, source := range predictions[ii] {
+					target, ok := predictions[jj][inputID]
+					if !ok {
+						continue
+					}
+					expected := source.expected
+					if expected < 0 {
+						expected = target.expected
+					}
+					agreement.Add(source.labels, target.labels, expected)
+				}
+				if agreement.NumInputs() == 0 {
+					log.WithField("model", key).Error("no common classification inputs between the evaluations")
+					continue
+				}
+				agreements = append(agreements, SummaryAgreementInformation{
+					ModelName:                   group[ii].Model.Name,
+					ModelVersion:                group[ii].Model.Version,
+					SourceFrameworkName:         group[ii].Framework.Name,
+					SourceFrameworkVersion:      group[ii].Framework.Version,
+					TargetFrameworkName:         group[jj].Framework.Name,
+					TargetFrameworkVersion:      group[jj].Framework.Version,
+					K:                           agreement.K,
+					NumInputs:                   agreement.NumInputs(),
+					Top1Agreement:               agreement.Top1Agreement(),
+					TopKOverlap:                 agreement.TopKOverlap(),
+					TopKRankCorrelation:         agreement.TopKRankCorrelation(),
+					NumLabeled:                  agreement.NumLabeled(),
+					SourceAccuracy:              agreement.FirstAccuracy(),
+					TargetAccuracy:              agreement.SecondAccuracy(),
+					NumBothCorrect:              agreement.NumBothCorrect(),
+					NumBothWrongSameAnswer:      agreement.NumBothWrongSameAnswer(),
+					NumBothWrongDifferentAnswer: agreement.NumBothWrongDifferentAnswer(),
+				})
+			}
+		}
+
+		if len(group) < 3 {
+			continue
+		}
+		ensemble, err := ensembleInformation(group, predictions)
+		if err != nil {
+			log.WithError(err).WithField("model", key).Error("failed to compute the ensemble information summary")
+			continue
+		}
+		ensembles = append(ensembles, *ensemble)
+	}
+
+	return agreements, ensembles, nil
+}
+
+// ensembleInformation computes the accuracy of the majority vote of the top1 predictions on the labeled
+// inputs predicted by all the evaluations. Ties are broken in favour of the earliest evaluation.
+func ensembleInformation(group Evaluations, predictions []map[string]agreementPrediction) (*SummaryEnsembleInformation, error) {
+	numInputs, numTies, ensembleCorrect := int64(0), int64(0), int64(0)
+	correct := make([]int64, len(group))
+	for inputID := range predictions[0] {
+		votes := make([]int, 0, len(group))
+		expected := -1
+		for _, preds := range predictions {
+			pred, ok := preds[inputID]
+			if !ok {
+				break
+			}
+			votes = append(votes, pred.labels[0])
+			if expected < 0 {
+				expected = pred.expected
+			}
+		}
+		if len(votes) != len(group) || expected < 0 {
+			continue
+		}
+		numInputs++
+		label, _, tie := metrics.MajorityVote(votes)
+		if tie {
+			numTies++
+		}
+		if label == expected {
+			ensembleCorrect++
+		}
+		for ii, vote := range votes {
+			if vote == expected {
+				correct[ii]++
+			}
+		}
+	}
+	if numInputs == 0 {
+		return nil, errors.New("no labeled inputs common to all the evaluations")
+	}
+
+	res := &SummaryEnsembleInformation{
+		ModelName:         group[0].Model.Name,
+		ModelVersion:      group[0].Model.Version,
+		NumInputs:         numInputs,
+		NumTies:           numTies,
+		EnsembleAccuracy:  float64(ensembleCorrect) / float64(numInputs),
+		FrameworkAccuracy: make([]float64, len(group)),
+	}
+	for ii, e := range group {
+		res.Frameworks = append(res.Frameworks, frameworkString(e))
+		res.FrameworkAccuracy[ii] = float64(correct[ii]) / float64(numInputs)
+		if ii == 0 || res.FrameworkAccuracy[ii] > res.BestAccuracy {
+			res.BestFramework = frameworkString(e)
+			res.BestAccuracy = res.FrameworkAccuracy[ii]
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,41 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAgreementGroups(t *testing.T) {
+	ids := map[string]bson.ObjectId{}
+	eval := func(name, model, framework string, numInputs int) Evaluation {
+		ids[name] = bson.NewObjectId()
+		return Evaluation{
+			ID:                 ids[name],
+			Model:              dlframework.ModelManifest{Name: model, Version: "1.0"},
+			Framework:          dlframework.FrameworkManifest{Name: framework, Version: "1.0"},
+			InputPredictionIDs: make([]bson.ObjectId, numInputs),
+		}
+	}
+	es := Evaluations{
+		eval("a", "ResNet50", "TensorFlow", 2),
+		eval("b", "ResNet50", "tensorflow", 5),
+		eval("c", "resnet50", "MXNet", 5),
+		eval("d", "ResNet50", "TensorFlow", 1),
+		eval("e", "VGG16", "TensorFlow", 5),
+		eval("f", "VGG16", "TensorFlow", 5),
+	}
+
+	keys, groups := es.agreementGroups()
+	assert.Equal(t, []string{"resnet50/1.0"}, keys)
+	group := groups["resnet50/1.0"]
+	if assert.Len(t, group, 2) {
+		assert.Equal(t, ids["b"], group[0].ID)
+		assert.Equal(t, ids["c"], group[1].ID)
+	}
+
+	keys, _ = es[4:].agreementGroups()
+	assert.Empty(t, keys)
+}
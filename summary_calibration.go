@@ -0,0 +1,287 @@
+package evaluation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/rai-project/go-echarts/charts"
+	"github.com/spf13/cast"
+)
+
+type SummaryCalibrationInformation struct {
+	SummaryBase              `json:",inline"`
+	NumInputs                int                      `json:"num_inputs,omitempty"`
+	NumSkipped               int                      `json:"num_skipped,omitempty"`
+	Accuracy                 float64                  `json:"accuracy,omitempty"`
+	ExpectedCalibrationError float64                  `json:"expected_calibration_error,omitempty"`
+	MaximumCalibrationError  float64                  `json:"maximum_calibration_error,omitempty"`
+	BrierScore               float64                  `json:"brier_score,omitempty"`
+	NegativeLogLikelihood    float64                  `json:"negative_log_likelihood,omitempty"`
+	Bins                     []metrics.CalibrationBin `json:"bins,omitempty"`
+}
+
+type SummaryCalibrationInformations []SummaryCalibrationInformation
+
+func (SummaryCalibrationInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"num_inputs",
+		"num_skipped",
+		"accuracy",
+		"expected_calibration_error",
+		"maximum_calibration_error",
+		"brier_score",
+		"negative_log_likelihood",
+		"bin_accuracies",
+		"bin_confidences",
+		"bin_counts",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryCalibrationInformation) Row(opts ...writer.Option) []string {
+	accuracies := make([]string, len(s.Bins))
+	confidences := make([]string, len(s.Bins))
+	counts := make([]string, len(s.Bins))
+	for ii, bin := range s.Bins {
+		accuracies[ii] = cast.ToString(bin.Accuracy)
+		confidences[ii] = cast.ToString(bin.Confidence)
+		counts[ii] = cast.ToString(bin.Count)
+	}
+	extra := []string{
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.NumSkipped),
+		cast.ToString(s.Accuracy),
+		cast.ToString(s.ExpectedCalibrationError),
+		cast.ToString(s.MaximumCalibrationError),
+		cast.ToString(s.BrierScore),
+		cast.ToString(s.NegativeLogLikelihood),
+		strings.Join(accuracies, DefaultDimiter),
+		strings.Join(confidences, DefaultDimiter),
+		strings.Join(counts, DefaultDimiter),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryCalibrationInformations) Header(opts ...writer.Option) []string {
+	return SummaryCalibrationInformation{}.Header(opts...)
+}
+
+func (s SummaryCalibrationInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// SummaryCalibrationComparisonInformation is the difference between the calibration of a
+// framework and the calibration of the reference framework for the same model
+type SummaryCalibrationComparisonInformation struct {
+	SummaryBase                        `json:",inline"`
+	ReferenceFrameworkName             string  `json:"reference_framework_name,omitempty"`
+	ReferenceFrameworkVersion          string  `json:"reference_framework_version,omitempty"`
+	AccuracyDifference                 float64 `json:"accuracy_difference,omitempty"`
+	ExpectedCalibrationErrorDifference float64 `json:"expected_calibration_error_difference,omitempty"`
+	MaximumCalibrationErrorDifference  float64 `json:"maximum_calibration_error_difference,omitempty"`
+	BrierScoreDifference               float64 `json:"brier_score_difference,omitempty"`
+	NegativeLogLikelihoodDifference    float64 `json:"negative_log_likelihood_difference,omitempty"`
+}
+
+type SummaryCalibrationComparisonInformations []SummaryCalibrationComparisonInformation
+
+func (SummaryCalibrationComparisonInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"reference_framework_name",
+		"reference_framework_version",
+		"accuracy_difference",
+		"expected_calibration_error_difference",
+		"maximum_calibration_error_difference",
+		"brier_score_difference",
+		"negative_log_likelihood_difference",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryCalibrationComparisonInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		s.ReferenceFrameworkName,
+		s.ReferenceFrameworkVersion,
+		cast.ToString(s.AccuracyDifference),
+		cast.ToString(s.ExpectedCalibrationErrorDifference),
+		cast.ToString(s.MaximumCalibrationErrorDifference),
+		cast.ToString(s.BrierScoreDifference),
+		cast.ToString(s.NegativeLogLikelihoodDifference),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryCalibrationComparisonInformations) Header(opts ...writer.Option) []string {
+	return SummaryCalibrationComparisonInformation{}.Header(opts...)
+}
+
+func (s SummaryCalibrationComparisonInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// Compare compares the calibration of each framework against the reference framework
+// evaluated on the same model and batch size. The first evaluation of the model is the
+// reference if referenceFrameworkName is empty.
+func (s SummaryCalibrationInformations) Compare(referenceFrameworkName string) SummaryCalibrationComparisonInformations {
+	key := func(e SummaryCalibrationInformation) string {
+		return strings.Join([]string{e.ModelName, e.ModelVersion, cast.ToString(e.BatchSize)}, "/")
+	}
+	references := map[string]SummaryCalibrationInformation{}
+	for _, e := range s {
+		if referenceFrameworkName != "" && !strings.EqualFold(e.FrameworkName, referenceFrameworkName) {
+			continue
+		}
+		if _, ok := references[key(e)]; !ok {
+			references[key(e)] = e
+		}
+	}
+
+	res := SummaryCalibrationComparisonInformations{}
+	for _, e := range s {
+		ref, ok := references[key(e)]
+		if !ok || ref.ID == e.ID {
+			continue
+		}
+		res = append(res, SummaryCalibrationComparisonInformation{
+			SummaryBase:                        e.SummaryBase,
+			ReferenceFrameworkName:             ref.FrameworkName,
+			ReferenceFrameworkVersion:          ref.FrameworkVersion,
+			AccuracyDifference:                 e.Accuracy - ref.Accuracy,
+			ExpectedCalibrationErrorDifference: e.ExpectedCalibrationError - ref.ExpectedCalibrationError,
+			MaximumCalibrationErrorDifference:  e.MaximumCalibrationError - ref.MaximumCalibrationError,
+			BrierScoreDifference:               e.BrierScore - ref.BrierScore,
+			NegativeLogLikelihoodDifference:    e.NegativeLogLikelihood - ref.NegativeLogLikelihood,
+		})
+	}
+	return res
+}
+
+func (o SummaryCalibrationInformations) PlotName() string {
+	if len(o) == 0 {
+		return ""
+	}
+	return o[0].ModelName + " Reliability Diagram"
+}
+
+func (o SummaryCalibrationInformations) BarPlot() *charts.Bar {
+	bar := charts.NewBar()
+	bar = o.BarPlotAdd(bar)
+	return bar
+}
+
+// BarPlotAdd adds the accuracy of each confidence bin of each evaluation (so frameworks
+// can be compared on the same reliability diagram) along with the perfect calibration
+func (o SummaryCalibrationInformations) BarPlotAdd(bar *charts.Bar) *charts.Bar {
+	if len(o) == 0 {
+		return bar
+	}
+	bins := o[0].Bins
+	labels := make([]string, len(bins))
+	perfect := make([]float64, len(bins))
+	for ii, bin := range bins {
+		labels[ii] = fmt.Sprintf("%.2f-%.2f", bin.Lower, bin.Upper)
+		perfect[ii] = (bin.Lower + bin.Upper) / 2
+	}
+	bar.AddXAxis(labels)
+
+	for _, elem := range o {
+		data := make([]float64, len(elem.Bins))
+		for ii, bin := range elem.Bins {
+			data[ii] = bin.Accuracy
+		}
+		name := fmt.Sprintf("%s %s (ECE=%.4f)", elem.FrameworkName, elem.FrameworkVersion, elem.ExpectedCalibrationError)
+		bar.AddYAxis(name, data)
+	}
+	bar.AddYAxis("Perfect Calibration", perfect)
+
+	bar.SetSeriesOptions(
+		charts.LabelTextOpts{Show: false},
+		charts.TextStyleOpts{FontSize: DefaultSeriesFontSize},
+	)
+	bar.SetGlobalOptions(
+		charts.XAxisOpts{Name: "Confidence"},
+		charts.YAxisOpts{Name: "Accuracy", Min: 0, Max: 1},
+	)
+	return bar
+}
+
+func (o SummaryCalibrationInformations) WriteBarPlot(path string) error {
+	return writeBarPlot(o, path)
+}
+
+func (o SummaryCalibrationInformations) OpenBarPlot() error {
+	return openBarPlot(o)
+}
+
+// CalibrationInformationSummary computes the calibration of the top1 classification confidence
+// of the evaluation from the stored input predictions
+func (e Evaluation) CalibrationInformationSummary(predCol *InputPredictionCollection, numBins int, labels *ClassificationLabels) (*SummaryCalibrationInformation, error) {
+	if len(e.InputPredictionIDs) == 0 {
+		return nil, errors.New("no input predictions found for the evaluation")
+	}
+
+	calibration := metrics.NewCalibration(numBins)
+
+	numSkipped := 0
+	for _, id := range e.InputPredictionIDs {
+		var pred InputPrediction
+		err := predCol.FindOne(id, &pred)
+		if err != nil {
+			log.WithError(err).WithField("id", id.Hex()).Error("cannot find input prediction")
+			numSkipped++
+			continue
+		}
+		expected, err := labels.Index(pred.ExpectedLabel)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		err = calibration.AddFeatures(&pred.Features, expected)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			numSkipped++
+			continue
+		}
+	}
+
+	if calibration.Total() == 0 {
+		return nil, errors.New("no classification input predictions found for the evaluation")
+	}
+
+	return &SummaryCalibrationInformation{
+		SummaryBase:              e.summaryBase(),
+		NumInputs:                int(calibration.Total()),
+		NumSkipped:               numSkipped,
+		Accuracy:                 calibration.Accuracy(),
+		ExpectedCalibrationError: calibration.ExpectedCalibrationError(),
+		MaximumCalibrationError:  calibration.MaximumCalibrationError(),
+		BrierScore:               calibration.BrierScore(),
+		NegativeLogLikelihood:    calibration.NegativeLogLikelihood(),
+		Bins:                     calibration.Bins(),
+	}, nil
+}
+
+func (es Evaluations) CalibrationInformationSummary(predCol *InputPredictionCollection, numBins int, labels *ClassificationLabels) (SummaryCalibrationInformations, error) {
+	res := SummaryCalibrationInformations{}
+	for _, e := range es {
+		s, err := e.CalibrationInformationSummary(predCol, numBins, labels)
+		if err != nil {
+			log.WithError(err).Error("failed to compute calibration information summary")
+			continue
+		}
+		res = append(res, *s)
+	}
+	return res, nil
+}
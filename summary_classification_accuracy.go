@@ -0,0 +1,420 @@
+package evaluation
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/rai-project/dlframework"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/spf13/cast"
+)
+
+// ClassificationLabels maps a class index to its label.
+// It is used to recompute the accuracy using a label mapping that differs
+// from the one used when the evaluation was run.
+type ClassificationLabels struct {
+	Labels []string
+	// Offset is added to the expected label index, whether it is given as a number or as a
+	// label name (e.g. 1 for models which have a background class)
+	Offset  int
+	indices map[string]int
+}
+
+// ReadClassificationLabels reads a label file with one label per line
+func ReadClassificationLabels(path string, offset int) (*ClassificationLabels, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	labels := []string{}
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		labels = append(labels, line)
+	}
+	return NewClassificationLabels(labels, offset), nil
+}
+
+// NewClassificationLabels ...
+func NewClassificationLabels(labels []string, offset int) *ClassificationLabels {
+	indices := map[string]int{}
+	for ii, label := range labels {
+		indices[label] = ii
+		// synset files have the form "n01440764 tench, Tinca tinca"
+		if fields := strings.Fields(label); len(fields) > 1 {
+			if _, ok := indices[fields[0]]; !ok {
+				indices[fields[0]] = ii
+			}
+		}
+	}
+	return &ClassificationLabels{
+		Labels:  labels,
+		Offset:  offset,
+		indices: indices,
+	}
+}
+
+// Index returns the class index of the expected label. The expected label is either
+// the class index or a label in the label mapping, and the offset is added to both.
+func (l *ClassificationLabels) Index(expectedLabel string) (int, error) {
+	expectedLabel = strings.TrimSpace(expectedLabel)
+	if l == nil {
+		idx, err := cast.ToIntE(expectedLabel)
+		if err != nil {
+			return -1, fmt.Errorf("unable to find the label index for %v", expectedLabel)
+		}
+		return idx, nil
+	}
+	idx, ok := l.indices[expectedLabel]
+	if !ok {
+		var err error
+		idx, err = cast.ToIntE(expectedLabel)
+		if err != nil {
+			return -1, fmt.Errorf("unable to find the label index for %v", expectedLabel)
+		}
+	}
+	return idx + l.Offset, nil
+}
+
+// NumClasses is the number of labels including the offset classes
+func (l *ClassificationLabels) NumClasses() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.Labels) + l.Offset
+}
+
+// Label returns the label of the class index, which includes the offset
+func (l *ClassificationLabels) Label(idx int) string {
+	if l == nil {
+		return ""
+	}
+	idx -= l.Offset
+	if idx < 0 || idx >= len(l.Labels) {
+		return ""
+	}
+	return l.Labels[idx]
+}
+
+type SummaryClassificationAccuracyInformation struct {
+	SummaryBase     `json:",inline"`
+	NumInputs       int                      `json:"num_inputs,omitempty"`
+	NumSkipped      int                      `json:"num_skipped,omitempty"`
+	TopK            []int                    `json:"top_k,omitempty"`
+	TopKAccuracy    []float64                `json:"top_k_accuracy,omitempty"`
+	Labels          []string                 `json:"labels,omitempty"`
+	ConfusionMatrix *metrics.ConfusionMatrix `json:"confusion_matrix,omitempty"`
+}
+
+type SummaryClassificationAccuracyInformations []SummaryClassificationAccuracyInformation
+
+func (s SummaryClassificationAccuracyInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"num_inputs",
+		"num_skipped",
+	}
+	for _, k := range s.TopK {
+		extra = append(extra, fmt.Sprintf("top%d_accuracy", k))
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryClassificationAccuracyInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.NumSkipped),
+	}
+	for _, acc := range s.TopKAccuracy {
+		extra = append(extra, cast.ToString(acc))
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (s SummaryClassificationAccuracyInformation) label(idx int) string {
+	if idx < 0 || idx >= len(s.Labels) {
+		return ""
+	}
+	return s.Labels[idx]
+}
+
+type SummaryClassAccuracyInformation struct {
+	SummaryBase  `json:",inline"`
+	ClassIndex   int     `json:"class_index"`
+	ClassLabel   string  `json:"class_label,omitempty"`
+	NumInputs    int64   `json:"num_inputs,omitempty"`
+	NumCorrect   int64   `json:"num_correct,omitempty"`
+	Top1Accuracy float64 `json:"top1_accuracy,omitempty"`
+}
+
+type SummaryClassAccuracyInformations []SummaryClassAccuracyInformation
+
+func (SummaryClassAccuracyInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"class_index",
+		"class_label",
+		"num_inputs",
+		"num_correct",
+		"top1_accuracy",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryClassAccuracyInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		cast.ToString(s.ClassIndex),
+		s.ClassLabel,
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.NumCorrect),
+		cast.ToString(s.Top1Accuracy),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryClassAccuracyInformations) Header(opts ...writer.Option) []string {
+	return SummaryClassAccuracyInformation{}.Header(opts...)
+}
+
+func (s SummaryClassAccuracyInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// PerClass returns the top1 accuracy of each class that appears in the expected labels
+func (s SummaryClassificationAccuracyInformation) PerClass() SummaryClassAccuracyInformations {
+	res := SummaryClassAccuracyInformations{}
+	if s.ConfusionMatrix == nil {
+		return res
+	}
+	for ii := 0; ii < s.ConfusionMatrix.NumClasses(); ii++ {
+		total := s.ConfusionMatrix.ExpectedTotal(ii)
+		if total == 0 {
+			continue
+		}
+		res = append(res, SummaryClassAccuracyInformation{
+			SummaryBase:  s.SummaryBase,
+			ClassIndex:   ii,
+			ClassLabel:   s.label(ii),
+			NumInputs:    total,
+			NumCorrect:   s.ConfusionMatrix.Counts[ii][ii],
+			Top1Accuracy: s.ConfusionMatrix.ClassAccuracy(ii),
+		})
+	}
+	return res
+}
+
+type SummaryConfusionInformation struct {
+	SummaryBase   `json:",inline"`
+	ExpectedIndex int    `json:"expected_index"`
+	ExpectedLabel string `json:"expected_label,omitempty"`
+	ActualIndex   int    `json:"actual_index"`
+	ActualLabel   string `json:"actual_label,omitempty"`
+	Count         int64  `json:"count,omitempty"`
+}
+
+type SummaryConfusionInformations []SummaryConfusionInformation
+
+func (SummaryConfusionInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"expected_index",
+		"expected_label",
+		"actual_index",
+		"actual_label",
+		"count",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryConfusionInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		cast.ToString(s.ExpectedIndex),
+		s.ExpectedLabel,
+		cast.ToString(s.ActualIndex),
+		s.ActualLabel,
+		cast.ToString(s.Count),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryConfusionInformations) Header(opts ...writer.Option) []string {
+	return SummaryConfusionInformation{}.Header(opts...)
+}
+
+func (s SummaryConfusionInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+func summaryConfusionInformations(base SummaryBase, confusionMatrix *metrics.ConfusionMatrix, label func(int) string) SummaryConfusionInformations {
+	res := SummaryConfusionInformations{}
+	if confusionMatrix == nil {
+		return res
+	}
+	for expected, row := range confusionMatrix.Counts {
+		for actual, count := range row {
+			if count == 0 {
+				continue
+			}
+			res = append(res, SummaryConfusionInformation{
+				SummaryBase:   base,
+				ExpectedIndex: expected,
+				ExpectedLabel: label(expected),
+				ActualIndex:   actual,
+				ActualLabel:   label(actual),
+				Count:         count,
+			})
+		}
+	}
+	return res
+}
+
+// Confusion returns the non zero entries of the top1 confusion matrix
+func (s SummaryClassificationAccuracyInformation) Confusion() SummaryConfusionInformations {
+	return summaryConfusionInformations(s.SummaryBase, s.ConfusionMatrix, s.label)
+}
+
+func classificationTop1(features dlframework.Features) (*dlframework.Classification, error) {
+	var top1 *dlframework.Feature
+	for _, feature := range features {
+		if feature == nil {
+			continue
+		}
+		if _, ok := feature.Feature.(*dlframework.Feature_Classification); !ok {
+			return nil, errors.New("expecting classification features")
+		}
+		if top1 == nil || feature.Probability > top1.Probability {
+			top1 = feature
+		}
+	}
+	if top1 == nil {
+		return nil, errors.New("no features found")
+	}
+	return top1.Feature.(*dlframework.Feature_Classification).Classification, nil
+}
+
+func addClassificationAccumulators(accumulators []metrics.Accumulator, features *dlframework.Features, expected int) error {
+	for _, acc := range accumulators {
+		if err := acc.Add(features, expected); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClassificationAccuracyInformationSummary recomputes the top k accuracy of the evaluation from
+// the stored input predictions using the registered top k metrics.
+// The predictions are read one at a time, so the evaluation does not have to fit in memory.
+func (e Evaluation) ClassificationAccuracyInformationSummary(predCol *InputPredictionCollection, topK []int, labels *ClassificationLabels) (*SummaryClassificationAccuracyInformation, error) {
+	if len(e.InputPredictionIDs) == 0 {
+		return nil, errors.New("no input predictions found for the evaluation")
+	}
+	if len(topK) == 0 {
+		topK = []int{1, 5}
+	}
+
+	accumulators := make([]metrics.Accumulator, len(topK))
+	for ii, k := range topK {
+		accumulators[ii] = metrics.GetTopKMetric(k).Accumulator()
+	}
+
+	confusionMatrix := metrics.NewConfusionMatrix(labels.NumClasses())
+	seenLabels := map[int]string{}
+
+	numInputs := 0
+	numSkipped := 0
+	for _, id := range e.InputPredictionIDs {
+		var pred InputPrediction
+		err := predCol.FindOne(id, &pred)
+		if err != nil {
+			log.WithError(err).WithField("id", id.Hex()).Error("cannot find input prediction")
+			numSkipped++
+			continue
+		}
+		expected, err := labels.Index(pred.ExpectedLabel)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		top1, err := classificationTop1(pred.Features)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		// the features are checked by classificationTop1, so the accumulators only fail on
+		// unexpected inputs, which are counted as skipped
+		if err := addClassificationAccumulators(accumulators, &pred.Features, expected); err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Error("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		for _, feature := range pred.Features {
+			if feature == nil {
+				continue
+			}
+			classification := feature.Feature.(*dlframework.Feature_Classification).Classification
+			seenLabels[int(classification.Index)] = classification.Label
+		}
+		confusionMatrix.Add(expected, int(top1.Index))
+		numInputs++
+	}
+
+	if numInputs == 0 {
+		return nil, errors.New("no classification input predictions found for the evaluation")
+	}
+	if numSkipped != 0 {
+		log.WithField("num_skipped", numSkipped).WithField("num_inputs", numInputs).Warn("skipped input predictions while computing the classification accuracy")
+	}
+
+	topKAccuracy := make([]float64, len(topK))
+	for ii, acc := range accumulators {
+		accuracy, err := acc.Finalize()
+		if err != nil {
+			return nil, err
+		}
+		topKAccuracy[ii] = accuracy
+	}
+
+	classLabels := make([]string, confusionMatrix.NumClasses())
+	for ii := range classLabels {
+		if label := labels.Label(ii); label != "" {
+			classLabels[ii] = label
+			continue
+		}
+		classLabels[ii] = seenLabels[ii]
+	}
+
+	return &SummaryClassificationAccuracyInformation{
+		SummaryBase:     e.summaryBase(),
+		NumInputs:       numInputs,
+		NumSkipped:      numSkipped,
+		TopK:            topK,
+		TopKAccuracy:    topKAccuracy,
+		Labels:          classLabels,
+		ConfusionMatrix: confusionMatrix,
+	}, nil
+}
+
+func (es Evaluations) ClassificationAccuracyInformationSummary(predCol *InputPredictionCollection, topK []int, labels *ClassificationLabels) (SummaryClassificationAccuracyInformations, error) {
+	res := SummaryClassificationAccuracyInformations{}
+	for _, e := range es {
+		s, err := e.ClassificationAccuracyInformationSummary(predCol, topK, labels)
+		if err != nil {
+			log.WithError(err).Error("failed to compute classification accuracy information summary")
+			continue
+		}
+		res = append(res, *s)
+	}
+	return res, nil
+}
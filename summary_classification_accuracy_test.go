@@ -0,0 +1,39 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassificationLabelsIndex(t *testing.T) {
+	labels := NewClassificationLabels([]string{"n01440764 tench, Tinca tinca", "goldfish"}, 1)
+	cases := []struct {
+		label    string
+		expected int
+	}{
+		{"0", 1},
+		{"1", 2},
+		{"n01440764", 1},
+		{"n01440764 tench, Tinca tinca", 1},
+		{" goldfish ", 2},
+	}
+	for _, c := range cases {
+		idx, err := labels.Index(c.label)
+		assert.NoError(t, err, c.label)
+		assert.Equal(t, c.expected, idx, c.label)
+		assert.NotEmpty(t, labels.Label(idx), c.label)
+	}
+	assert.Equal(t, 3, labels.NumClasses())
+	assert.Equal(t, "", labels.Label(0))
+	assert.Equal(t, "goldfish", labels.Label(2))
+
+	_, err := labels.Index("shark")
+	assert.Error(t, err)
+
+	var noLabels *ClassificationLabels
+	idx, err := noLabels.Index("3")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, idx)
+	assert.Equal(t, 0, noLabels.NumClasses())
+}
@@ -0,0 +1,458 @@
+package evaluation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/rai-project/go-echarts/charts"
+	"github.com/spf13/cast"
+)
+
+var (
+	// DefaultDivergenceHistogramBins is the number of equal width bins of the divergence histogram
+	DefaultDivergenceHistogramBins = 20
+)
+
+// DivergenceAboveTolerance returns true if the divergence is non zero and its magnitude is at least the tolerance
+func DivergenceAboveTolerance(divergence, tolerance float64) bool {
+	return math.Abs(divergence) >= tolerance && divergence != 0
+}
+
+// DivergenceValue is the divergence between the source and target predictions of an input.
+// Diverged is the pass/fail predicate of the method, which is DivergenceAboveTolerance for the
+// distribution divergences.
+type DivergenceValue struct {
+	Method        string  `json:"method,omitempty"`
+	SourceInputID string  `json:"source_input_id,omitempty"`
+	TargetInputID string  `json:"target_input_id,omitempty"`
+	Value         float64 `json:"value,omitempty"`
+	Diverged      bool    `json:"diverged,omitempty"`
+}
+
+// DivergenceValues collects the divergence values computed between a source and a target evaluation.
+// Values can be added concurrently.
+type DivergenceValues struct {
+	Source Evaluation
+	Target Evaluation
+	// Alignment is the pairing of the source and target input predictions
+	Alignment *InputAlignment
+
+	values []DivergenceValue
+	sync.Mutex
+}
+
+func NewDivergenceValues(source, target Evaluation, alignment *InputAlignment) *DivergenceValues {
+	return &DivergenceValues{
+		Source:    source,
+		Target:    target,
+		Alignment: alignment,
+	}
+}
+
+func (d *DivergenceValues) Add(method, sourceInputID, targetInputID string, value float64, diverged bool) {
+	d.Lock()
+	defer d.Unlock()
+	d.values = append(d.values, DivergenceValue{
+		Method:        method,
+		SourceInputID: sourceInputID,
+		TargetInputID: targetInputID,
+		Value:         value,
+		Diverged:      diverged,
+	})
+}
+
+// Methods returns the sorted names of the divergence methods that have values
+func (d *DivergenceValues) Methods() []string {
+	d.Lock()
+	defer d.Unlock()
+	seen := map[string]bool{}
+	res := []string{}
+	for _, v := range d.values {
+		if !seen[v.Method] {
+			seen[v.Method] = true
+			res = append(res, v.Method)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Values returns the values of the divergence method
+func (d *DivergenceValues) Values(method string) []DivergenceValue {
+	d.Lock()
+	defer d.Unlock()
+	res := []DivergenceValue{}
+	for _, v := range d.values {
+		if v.Method == method {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+type SummaryDivergenceInformation struct {
+	SummaryBase            `json:",inline"`
+	TargetID               string    `json:"target_id,omitempty"`
+	TargetFrameworkName    string    `json:"target_framework_name,omitempty"`
+	TargetFrameworkVersion string    `json:"target_framework_version,omitempty"`
+	Method                 string    `json:"method,omitempty"`
+	NumPaired              int       `json:"num_paired,omitempty"`
+	NumSourceOnly          int       `json:"num_source_only,omitempty"`
+	NumTargetOnly          int       `json:"num_target_only,omitempty"`
+	Count                  int       `json:"count,omitempty"`
+	NumNonFinite           int       `json:"num_non_finite,omitempty"`
+	Mean                   float64   `json:"mean,omitempty"`
+	Median                 float64   `json:"median,omitempty"`
+	Percentile95           float64   `json:"percentile_95,omitempty"`
+	Max                    float64   `json:"max,omitempty"`
+	Tolerance              float64   `json:"tolerance,omitempty"`
+	NumAboveTolerance      int       `json:"num_above_tolerance,omitempty"`
+	HistogramEdges         []float64 `json:"histogram_edges,omitempty"`
+	HistogramCounts        []uint    `json:"histogram_counts,omitempty"`
+}
+
+type SummaryDivergenceInformations []SummaryDivergenceInformation
+
+func (SummaryDivergenceInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"target_id",
+		"target_framework_name",
+		"target_framework_version",
+		"method",
+		"num_paired",
+		"num_source_only",
+		"num_target_only",
+		"count",
+		"num_non_finite",
+		"mean",
+		"median",
+		"p95",
+		"max",
+		"tolerance",
+		"num_above_tolerance",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryDivergenceInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		s.TargetID,
+		s.TargetFrameworkName,
+		s.TargetFrameworkVersion,
+		s.Method,
+		cast.ToString(s.NumPaired),
+		cast.ToString(s.NumSourceOnly),
+		cast.ToString(s.NumTargetOnly),
+		cast.ToString(s.Count),
+		cast.ToString(s.NumNonFinite),
+		cast.ToString(s.Mean),
+		cast.ToString(s.Median),
+		cast.ToString(s.Percentile95),
+		cast.ToString(s.Max),
+		cast.ToString(s.Tolerance),
+		cast.ToString(s.NumAboveTolerance),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryDivergenceInformations) Header(opts ...writer.Option) []string {
+	return SummaryDivergenceInformation{}.Header(opts...)
+}
+
+func (s SummaryDivergenceInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// SummaryDivergenceInputInformation is one of the most divergent inputs of a divergence method
+type SummaryDivergenceInputInformation struct {
+	ModelName           string  `json:"model_name,omitempty"`
+	SourceFrameworkName string  `json:"source_framework_name,omitempty"`
+	TargetFrameworkName string  `json:"target_framework_name,omitempty"`
+	Method              string  `json:"method,omitempty"`
+	Rank                int     `json:"rank,omitempty"`
+	SourceInputID       string  `json:"source_input_id,omitempty"`
+	TargetInputID       string  `json:"target_input_id,omitempty"`
+	Value               float64 `json:"value,omitempty"`
+}
+
+type SummaryDivergenceInputInformations []SummaryDivergenceInputInformation
+
+func (SummaryDivergenceInputInformation) Header(opts ...writer.Option) []string {
+	return []string{
+		"model_name",
+		"source_framework_name",
+		"target_framework_name",
+		"method",
+		"rank",
+		"source_input_id",
+		"target_input_id",
+		"value",
+	}
+}
+
+func (s SummaryDivergenceInputInformation) Row(opts ...writer.Option) []string {
+	return []string{
+		s.ModelName,
+		s.SourceFrameworkName,
+		s.TargetFrameworkName,
+		s.Method,
+		cast.ToString(s.Rank),
+		s.SourceInputID,
+		s.TargetInputID,
+		cast.ToString(s.Value),
+	}
+}
+
+func (SummaryDivergenceInputInformations) Header(opts ...writer.Option) []string {
+	return SummaryDivergenceInputInformation{}.Header(opts...)
+}
+
+func (s SummaryDivergenceInputInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// Summary computes the distribution of the magnitude of the divergence values of each method.
+// The histogram has numBins equal width bins between the smallest and largest magnitude. The number
+// above tolerance is the number of diverged values, the tolerance is the one they were added with.
+// The infinite and NaN values are counted apart and left out of the statistics and the histogram.
+func (d *DivergenceValues) Summary(tolerance float64, numBins int) SummaryDivergenceInformations {
+	if numBins <= 0 {
+		numBins = DefaultDivergenceHistogramBins
+	}
+	base := d.Source.summaryBase()
+	numPaired, numSourceOnly, numTargetOnly := 0, 0, 0
+	if d.Alignment != nil {
+		numPaired = len(d.Alignment.Pairs)
+		numSourceOnly = len(d.Alignment.SourceOnly)
+		numTargetOnly = len(d.Alignment.TargetOnly)
+	}
+	res := SummaryDivergenceInformations{}
+	for _, method := range d.Methods() {
+		vals := d.Values(method)
+		magnitudes := []float64{}
+		numAboveTolerance, numNonFinite := 0, 0
+		sum := 0.0
+		for _, v := range vals {
+			if v.Diverged {
+				numAboveTolerance++
+			}
+			if math.IsInf(v.Value, 0) || math.IsNaN(v.Value) {
+				numNonFinite++
+				continue
+			}
+			magnitudes = append(magnitudes, math.Abs(v.Value))
+			sum += math.Abs(v.Value)
+		}
+		mean := 0.0
+		if len(magnitudes) != 0 {
+			mean = sum / float64(len(magnitudes))
+		}
+		edges := divergenceHistogramEdges(magnitudes, numBins)
+		counts, _ := metrics.Histogram(magnitudes, edges)
+		res = append(res, SummaryDivergenceInformation{
+			SummaryBase:            base,
+			TargetID:               d.Target.ID.Hex(),
+			TargetFrameworkName:    d.Target.Framework.Name,
+			TargetFrameworkVersion: d.Target.Framework.Version,
+			Method:                 method,
+			NumPaired:              numPaired,
+			NumSourceOnly:          numSourceOnly,
+			NumTargetOnly:          numTargetOnly,
+			Count:                  len(vals),
+			NumNonFinite:           numNonFinite,
+			Mean:                   mean,
+			Median:                 Median(magnitudes),
+			Percentile95:           Percentile(magnitudes, 95),
+			Max:                    Percentile(magnitudes, 100),
+			Tolerance:              tolerance,
+			NumAboveTolerance:      numAboveTolerance,
+			HistogramEdges:         edges,
+			HistogramCounts:        counts,
+		})
+	}
+	return res
+}
+
+// TopInputs returns the n inputs with the largest divergence magnitude for each method
+func (d *DivergenceValues) TopInputs(n int) SummaryDivergenceInputInformations {
+	res := SummaryDivergenceInputInformations{}
+	for _, method := range d.Methods() {
+		inputs := d.rankInputs(method, d.Values(method))
+		if n >= 0 && len(inputs) > n {
+			inputs = inputs[:n]
+		}
+		res = append(res, inputs...)
+	}
+	return res
+}
+
+// DivergedInputs returns the inputs whose value for the method diverged, the most divergent input first
+func (d *DivergenceValues) DivergedInputs(method string) SummaryDivergenceInputInformations {
+	vals := []DivergenceValue{}
+	for _, v := range d.Values(method) {
+		if v.Diverged {
+			vals = append(vals, v)
+		}
+	}
+	return d.rankInputs(method, vals)
+}
+
+func (d *DivergenceValues) rankInputs(method string, vals []DivergenceValue) SummaryDivergenceInputInformations {
+	sort.SliceStable(vals, func(ii, jj int) bool {
+		return math.Abs(vals[ii].Value) > math.Abs(vals[jj].Value)
+	})
+	res := make(SummaryDivergenceInputInformations, len(vals))
+	for ii, v := range vals {
+		res[ii] = SummaryDivergenceInputInformation{
+			ModelName:           d.Source.Model.Name,
+			SourceFrameworkName: d.Source.Framework.Name,
+			TargetFrameworkName: d.Target.Framework.Name,
+			Method:              method,
+			Rank:                ii + 1,
+			SourceInputID:       v.SourceInputID,
+			TargetInputID:       v.TargetInputID,
+			Value:               v.Value,
+		}
+	}
+	return res
+}
+
+// SummaryDivergenceUnpairedInputInformation is an input that was only processed by one of the evaluations
+type SummaryDivergenceUnpairedInputInformation struct {
+	ModelName           string `json:"model_name,omitempty"`
+	SourceFrameworkName string `json:"source_framework_name,omitempty"`
+	TargetFrameworkName string `json:"target_framework_name,omitempty"`
+	PresentIn           string `json:"present_in,omitempty"`
+	InputID             string `json:"input_id,omitempty"`
+}
+
+type SummaryDivergenceUnpairedInputInformations []SummaryDivergenceUnpairedInputInformation
+
+func (SummaryDivergenceUnpairedInputInformation) Header(opts ...writer.Option) []string {
+	return []string{
+		"model_name",
+		"source_framework_name",
+		"target_framework_name",
+		"present_in",
+		"input_id",
+	}
+}
+
+func (s SummaryDivergenceUnpairedInputInformation) Row(opts ...writer.Option) []string {
+	return []string{
+		s.ModelName,
+		s.SourceFrameworkName,
+		s.TargetFrameworkName,
+		s.PresentIn,
+		s.InputID,
+	}
+}
+
+func (SummaryDivergenceUnpairedInputInformations) Header(opts ...writer.Option) []string {
+	return SummaryDivergenceUnpairedInputInformation{}.Header(opts...)
+}
+
+func (s SummaryDivergenceUnpairedInputInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// UnpairedInputs returns the inputs that are only present in the source or in the target evaluation
+func (d *DivergenceValues) UnpairedInputs() SummaryDivergenceUnpairedInputInformations {
+	res := SummaryDivergenceUnpairedInputInformations{}
+	if d.Alignment == nil {
+		return res
+	}
+	add := func(presentIn string, inputIDs []string) {
+		for _, inputID := range inputIDs {
+			res = append(res, SummaryDivergenceUnpairedInputInformation{
+				ModelName:           d.Source.Model.Name,
+				SourceFrameworkName: d.Source.Framework.Name,
+				TargetFrameworkName: d.Target.Framework.Name,
+				PresentIn:           presentIn,
+				InputID:             inputID,
+			})
+		}
+	}
+	add("source", d.Alignment.SourceOnly)
+	add("target", d.Alignment.TargetOnly)
+	return res
+}
+
+// divergenceHistogramEdges returns the edges of the numBins equal width bins between the smallest and
+// largest finite values of the data
+func divergenceHistogramEdges(data []float64, numBins int) []float64 {
+	lower, upper := math.Inf(1), math.Inf(-1)
+	for _, v := range data {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			continue
+		}
+		lower = math.Min(lower, v)
+		upper = math.Max(upper, v)
+	}
+	if lower > upper {
+		return []float64{0, 1}
+	}
+	if lower == upper {
+		return []float64{lower, math.Nextafter(upper, math.Inf(1))}
+	}
+	edges := make([]float64, numBins+1)
+	for ii := range edges {
+		edges[ii] = lower + float64(ii)*(upper-lower)/float64(numBins)
+	}
+	// the largest value belongs to the last bin
+	edges[numBins] = math.Nextafter(upper, math.Inf(1))
+	return edges
+}
+
+func (o SummaryDivergenceInformation) PlotName() string {
+	return fmt.Sprintf("%s %s Divergence between %s and %s", o.ModelName, o.Method, o.FrameworkName, o.TargetFrameworkName)
+}
+
+func (o SummaryDivergenceInformation) BarPlot() *charts.Bar {
+	bar := charts.NewBar()
+	bar = o.BarPlotAdd(bar)
+	return bar
+}
+
+// BarPlotAdd adds the histogram of the divergence magnitudes
+func (o SummaryDivergenceInformation) BarPlotAdd(bar *charts.Bar) *charts.Bar {
+	labels := make([]string, len(o.HistogramCounts))
+	for ii := range o.HistogramCounts {
+		labels[ii] = fmt.Sprintf("%.3g-%.3g", o.HistogramEdges[ii], o.HistogramEdges[ii+1])
+	}
+	bar.AddXAxis(labels)
+	bar.AddYAxis(o.Method, o.HistogramCounts)
+	bar.SetSeriesOptions(
+		charts.LabelTextOpts{Show: false},
+		charts.TextStyleOpts{FontSize: DefaultSeriesFontSize},
+	)
+	bar.SetGlobalOptions(
+		charts.XAxisOpts{Name: o.Method},
+		charts.YAxisOpts{Name: "Number of Inputs"},
+	)
+	return bar
+}
+
+func (o SummaryDivergenceInformation) WriteBarPlot(path string) error {
+	return writeBarPlot(o, path)
+}
+
+func (o SummaryDivergenceInformation) OpenBarPlot() error {
+	return openBarPlot(o)
+}
@@ -0,0 +1,158 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func testDivergenceValues(values map[string][]float64) *DivergenceValues {
+	source := Evaluation{ID: bson.NewObjectId(), Model: dlframework.ModelManifest{Name: "resnet"}, Framework: dlframework.FrameworkManifest{Name: "mxnet"}}
+	target := Evaluation{ID: bson.NewObjectId(), Model: dlframework.ModelManifest{Name: "resnet"}, Framework: dlframework.FrameworkManifest{Name: "tensorflow"}}
+	d := NewDivergenceValues(source, target, &InputAlignment{
+		Pairs:      make([]InputPredictionPair, 4),
+		SourceOnly: []string{"a"},
+	})
+	for method, vals := range values {
+		for ii, v := range vals {
+			id := string(rune('a' + ii))
+			d.Add(method, id, id, v, DivergenceAboveTolerance(v, 0.1))
+		}
+	}
+	return d
+}
+
+func TestDivergenceValuesSummary(t *testing.T) {
+	d := testDivergenceValues(map[string][]float64{
+		"KullbackLeibler": {0.5, -2, 0.01, 0},
+		"JensenShannon":   {0.3},
+	})
+
+	summaries := d.Summary(0.1, 2)
+	require.Len(t, summaries, 2)
+
+	js := summaries[0]
+	assert.Equal(t, "JensenShannon", js.Method)
+	assert.Equal(t, 1, js.Count)
+	assert.Equal(t, 0.3, js.Mean)
+	assert.Equal(t, 0.3, js.Median)
+	assert.Equal(t, 0.3, js.Max)
+	assert.Equal(t, 1, js.NumAboveTolerance)
+	assert.Equal(t, []uint{1}, js.HistogramCounts)
+
+	kl := summaries[1]
+	assert.Equal(t, "KullbackLeibler", kl.Method)
+	assert.Equal(t, "tensorflow", kl.TargetFrameworkName)
+	assert.Equal(t, 4, kl.NumPaired)
+	assert.Equal(t, 1, kl.NumSourceOnly)
+	assert.Equal(t, 0, kl.NumTargetOnly)
+	assert.Equal(t, 4, kl.Count)
+	assert.InDelta(t, 2.51/4, kl.Mean, 1e-12)
+	assert.InDelta(t, 0.255, kl.Median, 1e-12)
+	assert.InDelta(t, 1.775, kl.Percentile95, 1e-12)
+	assert.Equal(t, 2.0, kl.Max)
+	assert.Equal(t, 2, kl.NumAboveTolerance)
+	assert.Equal(t, []uint{3, 1}, kl.HistogramCounts)
+
+	assert.Empty(t, testDivergenceValues(nil).Summary(0.1, 2))
+}
+
+func TestDivergenceValuesSummaryNonFinite(t *testing.T) {
+	d := testDivergenceValues(map[string][]float64{
+		"KullbackLeibler": {0.5, math.Inf(1), 1.5, math.NaN()},
+		"Hellinger":       {math.Inf(-1)},
+	})
+
+	summaries := d.Summary(0.1, 2)
+	require.Len(t, summaries, 2)
+
+	// the infinite and NaN values are counted, but left out of the statistics and the histogram
+	kl := summaries[1]
+	assert.Equal(t, "KullbackLeibler", kl.Method)
+	assert.Equal(t, 4, kl.Count)
+	assert.Equal(t, 2, kl.NumNonFinite)
+	assert.Equal(t, 1.0, kl.Mean)
+	assert.Equal(t, 1.5, kl.Max)
+	assert.Equal(t, 3, kl.NumAboveTolerance)
+	assert.Equal(t, []float64{0.5, 1, math.Nextafter(1.5, math.Inf(1))}, kl.HistogramEdges)
+	assert.Equal(t, []uint{1, 1}, kl.HistogramCounts)
+	assert.Equal(t, "2", kl.Row()[len(SummaryBase{}.Header())+8])
+
+	hellinger := summaries[0]
+	assert.Equal(t, 1, hellinger.NumNonFinite)
+	assert.Equal(t, 0.0, hellinger.Mean)
+	assert.Equal(t, []float64{0, 1}, hellinger.HistogramEdges)
+	assert.Equal(t, []uint{0}, hellinger.HistogramCounts)
+}
+
+func TestDivergenceValuesDivergedInputs(t *testing.T) {
+	d := testDivergenceValues(nil)
+	// the allclose values of an input diverge together, whatever the direction of the value
+	for ii, notClose := range []float64{0, 3, 1} {
+		id := string(rune('a' + ii))
+		d.Add("NotCloseElements", id, id, notClose, notClose > 0)
+		d.Add("FractionWithinTolerance", id, id, 1-notClose/4, notClose > 0)
+	}
+
+	for _, summary := range d.Summary(0.1, 2) {
+		assert.Equal(t, 2, summary.NumAboveTolerance, summary.Method)
+	}
+	inputs := d.DivergedInputs("NotCloseElements")
+	require.Len(t, inputs, 2)
+	assert.Equal(t, "b", inputs[0].SourceInputID)
+	assert.Equal(t, "c", inputs[1].SourceInputID)
+	assert.Len(t, d.DivergedInputs("FractionWithinTolerance"), 2)
+}
+
+func TestDivergenceValuesTopInputs(t *testing.T) {
+	d := testDivergenceValues(map[string][]float64{
+		"KullbackLeibler": {0.5, -2, 0.01},
+		"JensenShannon":   {0.3},
+	})
+
+	cases := []struct {
+		n        int
+		expected []string
+	}{
+		{0, []string{}},
+		{1, []string{"JensenShannon/a", "KullbackLeibler/b"}},
+		{2, []string{"JensenShannon/a", "KullbackLeibler/b", "KullbackLeibler/a"}},
+		{-1, []string{"JensenShannon/a", "KullbackLeibler/b", "KullbackLeibler/a", "KullbackLeibler/c"}},
+	}
+	for _, c := range cases {
+		inputs := d.TopInputs(c.n)
+		names := []string{}
+		for ii, input := range inputs {
+			names = append(names, input.Method+"/"+input.SourceInputID)
+			if ii > 0 && inputs[ii-1].Method == input.Method {
+				assert.Equal(t, inputs[ii-1].Rank+1, input.Rank)
+			}
+		}
+		assert.Equal(t, c.expected, names, "n = %v", c.n)
+	}
+	assert.Equal(t, -2.0, d.TopInputs(1)[1].Value)
+	assert.Empty(t, testDivergenceValues(nil).TopInputs(10))
+}
+
+func TestDivergenceHistogramEdges(t *testing.T) {
+	cases := []struct {
+		name    string
+		data    []float64
+		numBins int
+		edges   []float64
+	}{
+		{"empty", nil, 4, []float64{0, 1}},
+		{"single value", []float64{3}, 4, []float64{3, math.Nextafter(3, math.Inf(1))}},
+		{"same values", []float64{2, 2}, 4, []float64{2, math.Nextafter(2, math.Inf(1))}},
+		{"range", []float64{4, 0, 2}, 2, []float64{0, 2, math.Nextafter(4, math.Inf(1))}},
+		{"non finite", []float64{math.Inf(1), 4, math.NaN(), 0}, 2, []float64{0, 2, math.Nextafter(4, math.Inf(1))}},
+		{"only non finite", []float64{math.Inf(1), math.NaN()}, 2, []float64{0, 1}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.edges, divergenceHistogramEdges(c.data, c.numBins), c.name)
+	}
+}
@@ -293,7 +293,9 @@ func CUDALaunchSpantoGPUInformation(span model.Span) SummaryGPUKernelInformation
 // 	}
 // }
 
-func (es Evaluations) SummaryGPUKernelLayerInformations(perfCol *PerformanceCollection) (SummaryGPUKernelLayerInformations, error) {
+// SummaryGPUKernelLayerInformations returns the gpu kernels launched by each layer. The metrics, when not
+// nil, replace the ones captured by CUPTI (see ImportGPUKernelMetrics).
+func (es Evaluations) SummaryGPUKernelLayerInformations(perfCol *PerformanceCollection, metrics GPUKernelMetrics) (SummaryGPUKernelLayerInformations, error) {
 	summary := SummaryGPUKernelLayerInformations{}
 	if len(es) == 0 {
 		return summary, errors.New("no evaluation is found in the database")
@@ -453,21 +455,6 @@ func (es Evaluations) SummaryGPUKernelLayerInformations(perfCol *PerformanceColl
 					}
 				}
 			}
-			trimmedMeanFraction := DefaultTrimmedMeanFraction
-			cki.MeanDuration = TrimmedMeanInt64Slice(cki.Durations, trimmedMeanFraction)
-			cki.MeanFlops = GetMeanLogValue(cki, "flop_count_sp", trimmedMeanFraction)
-			cki.MeanDramReadBytes = GetMeanLogValue(cki, "dram_read_bytes", trimmedMeanFraction)
-			cki.MeanDramWriteBytes = GetMeanLogValue(cki, "dram_write_bytes", trimmedMeanFraction)
-			cki.MeanAchievedOccupancy = GetMeanLogValue(cki, "achieved_occupancy", trimmedMeanFraction)
-			cki.ArithmeticIntensity = 0
-			if (cki.MeanDramReadBytes + cki.MeanDramWriteBytes) != 0 {
-				cki.ArithmeticIntensity = cki.MeanFlops / (cki.MeanDramReadBytes + cki.MeanDramWriteBytes)
-			}
-			cki.MemoryBound = false
-			if cki.ArithmeticIntensity < layerGPUInfo.IdealArithmeticIntensity {
-				cki.MemoryBound = true
-			}
-			cki.ArithmeticThroughput = cki.MeanFlops / cki.MeanDuration / float64(1000)
 			layerGPUInfo.SummaryGPUKernelInformations[ii] = cki
 		}
 		summary = append(summary, layerGPUInfo)
@@ -475,9 +462,35 @@ func (es Evaluations) SummaryGPUKernelLayerInformations(perfCol *PerformanceColl
 
 	sort.Sort(summary)
 
+	summary = summary.ImportGPUKernelMetrics(metrics)
+
+	for _, layerGPUInfo := range summary {
+		for ii := range layerGPUInfo.SummaryGPUKernelInformations {
+			layerGPUInfo.SummaryGPUKernelInformations[ii].computeMeans(layerGPUInfo.IdealArithmeticIntensity)
+		}
+	}
+
 	return summary, nil
 }
 
+func (cki *SummaryGPUKernelInformation) computeMeans(idealArithmeticIntensity float64) {
+	trimmedMeanFraction := DefaultTrimmedMeanFraction
+	cki.MeanDuration = TrimmedMeanInt64Slice(cki.Durations, trimmedMeanFraction)
+	cki.MeanFlops = GetMeanLogValue(*cki, "flop_count_sp", trimmedMeanFraction)
+	cki.MeanDramReadBytes = GetMeanLogValue(*cki, "dram_read_bytes", trimmedMeanFraction)
+	cki.MeanDramWriteBytes = GetMeanLogValue(*cki, "dram_write_bytes", trimmedMeanFraction)
+	cki.MeanAchievedOccupancy = GetMeanLogValue(*cki, "achieved_occupancy", trimmedMeanFraction)
+	cki.ArithmeticIntensity = 0
+	if (cki.MeanDramReadBytes + cki.MeanDramWriteBytes) != 0 {
+		cki.ArithmeticIntensity = cki.MeanFlops / (cki.MeanDramReadBytes + cki.MeanDramWriteBytes)
+	}
+	cki.MemoryBound = false
+	if cki.ArithmeticIntensity < idealArithmeticIntensity {
+		cki.MemoryBound = true
+	}
+	cki.ArithmeticThroughput = cki.MeanFlops / cki.MeanDuration / float64(1000)
+}
+
 func dummyPP() {
 	// for importing pp
 	pp.Println("dummy")
@@ -4,6 +4,7 @@ import (
 	json "encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/rai-project/evaluation/writer"
@@ -84,9 +85,9 @@ func (s SummaryGPUKernelLayerAggreInformation) Row(iopts ...writer.Option) []str
 	return extra
 }
 
-func (es Evaluations) SummaryGPUKernelLayerAggreInformations(perfCol *PerformanceCollection) (SummaryGPUKernelLayerAggreInformations, error) {
+func (es Evaluations) SummaryGPUKernelLayerAggreInformations(perfCol *PerformanceCollection, metrics GPUKernelMetrics) (SummaryGPUKernelLayerAggreInformations, error) {
 	summary := SummaryGPUKernelLayerAggreInformations{}
-	gpuLayerInfos, err := es.SummaryGPUKernelLayerInformations(perfCol)
+	gpuLayerInfos, err := es.SummaryGPUKernelLayerInformations(perfCol, metrics)
 	if err != nil {
 		return summary, errors.New("no span is found for the evaluation")
 	}
@@ -408,3 +409,83 @@ func (o SummaryGPUKernelLayerAchievedOccupancyInformations) OpenBarPlot() error
 func (o SummaryGPUKernelLayerGPUCPUInformations) OpenBarPlot() error {
 	return openBarPlot(o)
 }
+
+type SummaryGPUKernelLayerRooflineInformations SummaryGPUKernelLayerAggreInformations
+
+func (o SummaryGPUKernelLayerRooflineInformations) PlotName() string {
+	if len(o) == 0 {
+		return ""
+	}
+	return o[0].ModelName + `
+  Batch Size = ` + cast.ToString(o[0].BatchSize) + " Roofline"
+}
+
+func (o SummaryGPUKernelLayerRooflineInformations) ScatterPlot() *charts.Scatter {
+	scatter := charts.NewScatter()
+	scatter = o.ScatterPlotAdd(scatter)
+	return scatter
+}
+
+// ScatterPlotAdd places each layer by its arithmetic intensity and throughput on log scale axes. The memory
+// bound and compute bound layers are separate series and the roof given by the theoretical flops and the
+// memory bandwidth of the GPU is sampled as its own series.
+func (o SummaryGPUKernelLayerRooflineInformations) ScatterPlotAdd(scatter *charts.Scatter) *charts.Scatter {
+	memoryBound := [][]interface{}{}
+	computeBound := [][]interface{}{}
+	minIntensity, maxIntensity := math.MaxFloat64, float64(0)
+	for _, elem := range o {
+		// zeros cannot be placed on a log scale
+		if elem.ArithmeticIntensity <= 0 || elem.ArithmeticThroughput <= 0 {
+			continue
+		}
+		minIntensity = math.Min(minIntensity, elem.ArithmeticIntensity)
+		maxIntensity = math.Max(maxIntensity, elem.ArithmeticIntensity)
+		point := []interface{}{elem.ArithmeticIntensity, elem.ArithmeticThroughput, elem.Name}
+		if elem.MemoryBound {
+			memoryBound = append(memoryBound, point)
+			continue
+		}
+		computeBound = append(computeBound, point)
+	}
+	scatter.AddYAxis("Memory Bound", memoryBound)
+	scatter.AddYAxis("Compute Bound", computeBound)
+
+	if len(o) != 0 && o[0].TheoreticalGFlops != 0 && o[0].MemoryBandwidth != 0 {
+		ideal := o[0].IdealArithmeticIntensity
+		if maxIntensity == 0 {
+			minIntensity, maxIntensity = ideal, ideal
+		}
+		lo := math.Min(minIntensity, ideal) / 10
+		hi := math.Max(maxIntensity, ideal) * 10
+		roof := make([][]interface{}, DefaultRooflineSamples)
+		for ii := range roof {
+			intensity := lo * math.Pow(hi/lo, float64(ii)/float64(DefaultRooflineSamples-1))
+			gflops := math.Min(float64(o[0].TheoreticalGFlops), o[0].MemoryBandwidth*intensity)
+			roof[ii] = []interface{}{intensity, gflops, "roofline"}
+		}
+		scatter.AddYAxis("Roofline", roof)
+	}
+
+	scatter.SetSeriesOptions(
+		charts.LabelTextOpts{Show: false},
+		charts.TextStyleOpts{FontSize: DefaultSeriesFontSize},
+	)
+
+	jsFun := `function (params) {
+	  return params.value[2] + '<br/>' + params.value[0].toFixed(2) + ' flops/byte, ' + params.value[1].toFixed(2) + ' GFlops';
+  }`
+	scatter.SetGlobalOptions(
+		charts.TooltipOpts{Show: true, Formatter: charts.FuncOpts(jsFun)},
+		charts.XAxisOpts{Name: "Arithmetic Intensity (flops/byte)", Type: "log"},
+		charts.YAxisOpts{Name: "GFlops", Type: "log"},
+	)
+	return scatter
+}
+
+func (o SummaryGPUKernelLayerRooflineInformations) WriteScatterPlot(path string) error {
+	return writeScatterPlot(o, path)
+}
+
+func (o SummaryGPUKernelLayerRooflineInformations) OpenScatterPlot() error {
+	return openScatterPlot(o)
+}
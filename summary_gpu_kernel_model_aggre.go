@@ -61,9 +61,9 @@ func (info SummaryGPUKernelModelAggreInformation) Row(opts ...writer.Option) []s
 	}
 }
 
-func (es Evaluations) SummaryGPUKernelModelAggreInformations(perfCol *PerformanceCollection) (SummaryGPUKernelModelAggreInformations, error) {
+func (es Evaluations) SummaryGPUKernelModelAggreInformations(perfCol *PerformanceCollection, metrics GPUKernelMetrics) (SummaryGPUKernelModelAggreInformations, error) {
 	summary := SummaryGPUKernelModelAggreInformations{}
-	gpuLayerInfos, err := es.SummaryGPUKernelLayerInformations(perfCol)
+	gpuLayerInfos, err := es.SummaryGPUKernelLayerInformations(perfCol, metrics)
 	if err != nil {
 		return summary, errors.New("no span is found for the evaluation")
 	}
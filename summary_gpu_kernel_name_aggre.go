@@ -66,10 +66,10 @@ func (info SummaryGPUKernelNameAggreInformation) Row(opts ...writer.Option) []st
 	}
 }
 
-func (es Evaluations) SummaryGPUKernelNameAggreInformations(perfCol *PerformanceCollection) (SummaryGPUKernelNameAggreInformations, error) {
+func (es Evaluations) SummaryGPUKernelNameAggreInformations(perfCol *PerformanceCollection, metrics GPUKernelMetrics) (SummaryGPUKernelNameAggreInformations, error) {
 	summary := SummaryGPUKernelNameAggreInformations{}
 	infos := SummaryGPUKernelInformations{}
-	gpuKernelLayerInfos, err := es.SummaryGPUKernelLayerInformations(perfCol)
+	gpuKernelLayerInfos, err := es.SummaryGPUKernelLayerInformations(perfCol, metrics)
 	if err != nil {
 		return summary, err
 	}
@@ -0,0 +1,221 @@
+package evaluation
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/spf13/cast"
+)
+
+// ImageQualityOptions describes how the output images of super-resolution and enhancement models are evaluated
+type ImageQualityOptions struct {
+	// ReferencesDirectory contains the reference (high resolution) image of each input
+	ReferencesDirectory string
+	// DataRange is the range of the pixel values (e.g. 255 for 8 bit images)
+	DataRange float64
+	// PerceptualIndex computes the PIRM perceptual index using the registered NIQE and Ma functions
+	PerceptualIndex bool
+}
+
+type SummaryImageQualityInformation struct {
+	SummaryBase                    `json:",inline"`
+	NumInputs                      int       `json:"num_inputs,omitempty"`
+	NumSkipped                     int       `json:"num_skipped,omitempty"`
+	PeakSignalToNoiseRatio         float64   `json:"peak_signal_to_noise_ratio,omitempty"`
+	ChannelPeakSignalToNoiseRatio  []float64 `json:"channel_peak_signal_to_noise_ratio,omitempty"`
+	StructuralSimilarity           float64   `json:"structural_similarity,omitempty"`
+	MultiScaleStructuralSimilarity float64   `json:"multi_scale_structural_similarity,omitempty"`
+	NIQE                           float64   `json:"niqe,omitempty"`
+	Ma                             float64   `json:"ma,omitempty"`
+	PerceptualIndex                float64   `json:"perceptual_index,omitempty"`
+}
+
+type SummaryImageQualityInformations []SummaryImageQualityInformation
+
+func (SummaryImageQualityInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"num_inputs",
+		"num_skipped",
+		"psnr",
+		"channel_psnr",
+		"ssim",
+		"ms_ssim",
+		"niqe",
+		"ma",
+		"perceptual_index",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryImageQualityInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.NumSkipped),
+		cast.ToString(s.PeakSignalToNoiseRatio),
+		strings.Join(float64SliceToStringSlice(s.ChannelPeakSignalToNoiseRatio), DefaultDimiter),
+		cast.ToString(s.StructuralSimilarity),
+		cast.ToString(s.MultiScaleStructuralSimilarity),
+		cast.ToString(s.NIQE),
+		cast.ToString(s.Ma),
+		cast.ToString(s.PerceptualIndex),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryImageQualityInformations) Header(opts ...writer.Option) []string {
+	return SummaryImageQualityInformation{}.Header(opts...)
+}
+
+func (s SummaryImageQualityInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+func readReferenceImage(dir, inputID string, dataRange float64) (*metrics.Image, error) {
+	name := filepath.Base(inputID)
+	img, err := metrics.ReadImageWithDataRange(filepath.Join(dir, name), dataRange)
+	if err == nil {
+		return img, nil
+	}
+	return metrics.ReadImageWithDataRange(filepath.Join(dir, strings.TrimSuffix(name, filepath.Ext(name))+".png"), dataRange)
+}
+
+// ImageQualityInformationSummary compares the raw image outputs of the evaluation against the reference images.
+// The metrics are averaged over the inputs, the psnr of identical images or channels (which is infinite) is not averaged.
+// The reference images are rescaled from [0, 255] to the data range.
+func (e Evaluation) ImageQualityInformationSummary(predCol *InputPredictionCollection, opts ImageQualityOptions) (*SummaryImageQualityInformation, error) {
+	if len(e.InputPredictionIDs) == 0 {
+		return nil, errors.New("no input predictions found for the evaluation")
+	}
+	if opts.ReferencesDirectory == "" {
+		return nil, errors.New("no reference images directory provided")
+	}
+	if opts.DataRange == 0 {
+		opts.DataRange = metrics.DefaultImageDataRange
+	}
+
+	psnrs := []float64{}
+	channelPSNRs := [][]float64{}
+	ssims := []float64{}
+	msssims := []float64{}
+	niqes := []float64{}
+	mas := []float64{}
+
+	numInputs := 0
+	numSkipped := 0
+	for _, id := range e.InputPredictionIDs {
+		var pred InputPrediction
+		err := predCol.FindOne(id, &pred)
+		if err != nil {
+			log.WithError(err).WithField("id", id.Hex()).Error("cannot find input prediction")
+			numSkipped++
+			continue
+		}
+		expected, err := readReferenceImage(opts.ReferencesDirectory, pred.InputID, opts.DataRange)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction without a reference image")
+			numSkipped++
+			continue
+		}
+		actual, err := metrics.ImageFromFeatures(&pred.Features)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		actual.DataRange = opts.DataRange
+		channelPSNR, err := metrics.ImagePeakSignalToNoiseRatio(actual, expected, opts.DataRange)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Error("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		numInputs++
+
+		psnr := metrics.PeakSignalToNoiseRatioWithDataRange(actual.Pixels, expected.Pixels, opts.DataRange)
+		if !math.IsInf(psnr, 1) {
+			psnrs = append(psnrs, psnr)
+		}
+		channelPSNRs = append(channelPSNRs, channelPSNR)
+		if ssim, err := metrics.StructuralSimilarity(actual, expected, opts.DataRange); err == nil {
+			ssims = append(ssims, ssim)
+		}
+		if msssim, err := metrics.MultiScaleStructuralSimilarity(actual, expected, opts.DataRange); err == nil {
+			msssims = append(msssims, msssim)
+		}
+		if opts.PerceptualIndex {
+			ma, err := metrics.MaScore(actual)
+			if err != nil {
+				return nil, err
+			}
+			niqe, err := metrics.NaturalnessImageQualityEvaluator(actual)
+			if err != nil {
+				return nil, err
+			}
+			mas = append(mas, ma)
+			niqes = append(niqes, niqe)
+		}
+	}
+
+	if numInputs == 0 {
+		return nil, errors.New("no image input predictions found for the evaluation")
+	}
+
+	mean := func(xs []float64) float64 {
+		if len(xs) == 0 {
+			return 0
+		}
+		return metrics.Mean(xs)
+	}
+
+	var channelPSNR []float64
+	if len(channelPSNRs) > 0 {
+		channelPSNR = make([]float64, len(channelPSNRs[0]))
+		for c := range channelPSNR {
+			xs := []float64{}
+			for _, psnr := range channelPSNRs {
+				if c < len(psnr) && !math.IsInf(psnr[c], 1) {
+					xs = append(xs, psnr[c])
+				}
+			}
+			channelPSNR[c] = mean(xs)
+		}
+	}
+
+	res := &SummaryImageQualityInformation{
+		SummaryBase:                    e.summaryBase(),
+		NumInputs:                      numInputs,
+		NumSkipped:                     numSkipped,
+		PeakSignalToNoiseRatio:         mean(psnrs),
+		ChannelPeakSignalToNoiseRatio:  channelPSNR,
+		StructuralSimilarity:           mean(ssims),
+		MultiScaleStructuralSimilarity: mean(msssims),
+	}
+	if opts.PerceptualIndex {
+		res.NIQE = mean(niqes)
+		res.Ma = mean(mas)
+		res.PerceptualIndex = metrics.PerceptualIndexFromScores(res.Ma, res.NIQE)
+	}
+
+	return res, nil
+}
+
+func (es Evaluations) ImageQualityInformationSummary(predCol *InputPredictionCollection, opts ImageQualityOptions) (SummaryImageQualityInformations, error) {
+	res := SummaryImageQualityInformations{}
+	for _, e := range es {
+		s, err := e.ImageQualityInformationSummary(predCol, opts)
+		if err != nil {
+			log.WithError(err).Error("failed to compute image quality information summary")
+			continue
+		}
+		res = append(res, *s)
+	}
+	return res, nil
+}
@@ -0,0 +1,205 @@
+package evaluation
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rai-project/dlframework"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/spf13/cast"
+)
+
+// KITTIOptions describes where the KITTI ground truth is found
+type KITTIOptions struct {
+	// LabelsDirectory contains a label file (e.g. 000123.txt) for each input
+	LabelsDirectory string
+	// ImagesDirectory is used to read the image size when the predicted boxes are normalized
+	ImagesDirectory string
+	// ImageWidth and ImageHeight are used when the image size cannot be read
+	ImageWidth     int
+	ImageHeight    int
+	RecallPoints40 bool
+	// LabelMapping maps the predicted labels to the KITTI object types (e.g. metrics.COCOToKITTILabels).
+	// The labels which are not in the mapping are used as is.
+	LabelMapping map[string]string
+}
+
+type SummaryKITTIAccuracyInformation struct {
+	SummaryBase         `json:",inline"`
+	NumInputs           int `json:"num_inputs,omitempty"`
+	NumSkipped          int `json:"num_skipped,omitempty"`
+	metrics.KITTIResult `json:",inline"`
+}
+
+type SummaryKITTIAccuracyInformations []SummaryKITTIAccuracyInformation
+
+func (SummaryKITTIAccuracyInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"num_inputs",
+		"num_skipped",
+	}
+	for _, class := range metrics.KITTIClasses {
+		for _, difficulty := range metrics.KITTIDifficulties {
+			extra = append(extra, fmt.Sprintf("%s_AP_%s", class, difficulty))
+		}
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryKITTIAccuracyInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.NumSkipped),
+	}
+	for _, class := range metrics.KITTIClasses {
+		classResult, ok := s.Class(class)
+		for ii := range metrics.KITTIDifficulties {
+			if !ok {
+				extra = append(extra, "")
+				continue
+			}
+			extra = append(extra, formatAveragePrecision(classResult.AP[ii]))
+		}
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryKITTIAccuracyInformations) Header(opts ...writer.Option) []string {
+	return SummaryKITTIAccuracyInformation{}.Header(opts...)
+}
+
+func (s SummaryKITTIAccuracyInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+func (opts KITTIOptions) imageSize(name string) (float64, float64) {
+	if opts.ImagesDirectory != "" {
+		for _, ext := range []string{".png", ".jpg", ".jpeg"} {
+			f, err := os.Open(filepath.Join(opts.ImagesDirectory, name+ext))
+			if err != nil {
+				continue
+			}
+			cfg, _, err := image.DecodeConfig(f)
+			f.Close()
+			if err == nil {
+				return float64(cfg.Width), float64(cfg.Height)
+			}
+		}
+	}
+	return float64(opts.ImageWidth), float64(opts.ImageHeight)
+}
+
+// detections converts the predicted bounding boxes to KITTI objects. The labels which are not
+// KITTI classes once mapped are added to unmatched.
+func (opts KITTIOptions) detections(boxes dlframework.Features, width, height float64, unmatched map[string]bool) []metrics.KITTIObject {
+	classes := map[string]bool{}
+	for _, class := range metrics.KITTIClasses {
+		classes[class] = true
+	}
+	res := make([]metrics.KITTIObject, len(boxes))
+	for ii, feature := range boxes {
+		box := feature.Feature.(*dlframework.Feature_BoundingBox).BoundingBox
+		label := metrics.NormalizeCategory(box.GetLabel())
+		if mapped, ok := opts.LabelMapping[label]; ok {
+			label = mapped
+		}
+		if !classes[label] {
+			unmatched[label] = true
+		}
+		res[ii] = metrics.KITTIObject{
+			Type:  label,
+			Box:   metrics.DetectionBoxFromBoundingBox(box, width, height),
+			Score: float64(feature.GetProbability()),
+		}
+	}
+	return res
+}
+
+// KITTIAccuracyInformationSummary computes the KITTI 2D average precision of the evaluation
+// from the bounding boxes in the stored input predictions.
+func (e Evaluation) KITTIAccuracyInformationSummary(predCol *InputPredictionCollection, opts KITTIOptions) (*SummaryKITTIAccuracyInformation, error) {
+	if len(e.InputPredictionIDs) == 0 {
+		return nil, errors.New("no input predictions found for the evaluation")
+	}
+	if opts.LabelsDirectory == "" {
+		return nil, errors.New("no kitti labels directory provided")
+	}
+
+	evaluator := metrics.NewKITTIEvaluator()
+	evaluator.RecallPoints40 = opts.RecallPoints40
+
+	unmatched := map[string]bool{}
+	numInputs := 0
+	numSkipped := 0
+	for _, id := range e.InputPredictionIDs {
+		var pred InputPrediction
+		err := predCol.FindOne(id, &pred)
+		if err != nil {
+			log.WithError(err).WithField("id", id.Hex()).Error("cannot find input prediction")
+			numSkipped++
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(pred.InputID), filepath.Ext(pred.InputID))
+		groundTruths, err := metrics.ReadKITTILabels(filepath.Join(opts.LabelsDirectory, name+".txt"))
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction without labels")
+			numSkipped++
+			continue
+		}
+		boxes, err := boundingBoxesOf(pred.Features)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		width, height := opts.imageSize(name)
+		evaluator.Add(groundTruths, opts.detections(boxes, width, height, unmatched))
+		numInputs++
+	}
+
+	if len(unmatched) != 0 {
+		labels := []string{}
+		for label := range unmatched {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		log.WithField("labels", strings.Join(labels, ",")).
+			Warn("the predicted labels are not kitti classes and are not evaluated, they can be mapped using the label mapping")
+	}
+
+	if numInputs == 0 {
+		return nil, errors.New("no labeled input predictions found for the evaluation")
+	}
+
+	return &SummaryKITTIAccuracyInformation{
+		SummaryBase: e.summaryBase(),
+		NumInputs:   numInputs,
+		NumSkipped:  numSkipped,
+		KITTIResult: evaluator.Evaluate(),
+	}, nil
+}
+
+func (es Evaluations) KITTIAccuracyInformationSummary(predCol *InputPredictionCollection, opts KITTIOptions) (SummaryKITTIAccuracyInformations, error) {
+	res := SummaryKITTIAccuracyInformations{}
+	for _, e := range es {
+		s, err := e.KITTIAccuracyInformationSummary(predCol, opts)
+		if err != nil {
+			log.WithError(err).Error("failed to compute kitti accuracy information summary")
+			continue
+		}
+		res = append(res, *s)
+	}
+	return res, nil
+}
@@ -0,0 +1,35 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/rai-project/dlframework"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKITTIDetections(t *testing.T) {
+	box := func(label string, probability float32) *dlframework.Feature {
+		return &dlframework.Feature{
+			Feature:     &dlframework.Feature_BoundingBox{BoundingBox: &dlframework.BoundingBox{Label: label, Xmin: 0.5, Ymin: 0.5, Xmax: 1, Ymax: 1}},
+			Probability: probability,
+		}
+	}
+	boxes := dlframework.Features{box("Person", 0.9), box("Car", 0.8), box("dog", 0.7), box("bicycle", 0.6)}
+
+	unmatched := map[string]bool{}
+	detections := KITTIOptions{}.detections(boxes, 100, 50, unmatched)
+	require.Len(t, detections, 4)
+	assert.Equal(t, "car", detections[1].Type)
+	assert.Equal(t, metrics.DetectionBox{Xmin: 50, Ymin: 25, Xmax: 100, Ymax: 50}, detections[1].Box)
+	assert.InDelta(t, 0.8, detections[1].Score, 1e-6)
+	assert.Equal(t, map[string]bool{"person": true, "dog": true, "bicycle": true}, unmatched)
+
+	// the coco labels are mapped to the kitti classes
+	unmatched = map[string]bool{}
+	detections = KITTIOptions{LabelMapping: metrics.COCOToKITTILabels}.detections(boxes, 100, 50, unmatched)
+	assert.Equal(t, "pedestrian", detections[0].Type)
+	assert.Equal(t, "cyclist", detections[3].Type)
+	assert.Equal(t, map[string]bool{"dog": true}, unmatched)
+}
@@ -0,0 +1,358 @@
+package evaluation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/rai-project/evaluation/writer"
+	"github.com/rai-project/go-echarts/charts"
+	"github.com/spf13/cast"
+)
+
+// SummaryLayerBatchInformation is the mean latency of a layer at each of the evaluated batch sizes.
+// The durations of the batch sizes at which the layer is not found are nil.
+type SummaryLayerBatchInformation struct {
+	SummaryBase `json:",inline"`
+	Index       int        `json:"index,omitempty"`
+	Name        string     `json:"layer_name,omitempty"`
+	Type        string     `json:"type,omitempty"`
+	BatchSizes  []int      `json:"batch_sizes,omitempty"`
+	Durations   []*float64 `json:"durations,omitempty"`
+}
+
+// SummaryLayerBatchSampleInformation is the layer latency of each input at each batch size
+type SummaryLayerBatchSampleInformation SummaryLayerBatchInformation
+
+type SummaryLayerBatchInformations []SummaryLayerBatchInformation
+
+type SummaryLayerBatchSampleInformations []SummaryLayerBatchSampleInformation
+
+// the rows start with the environment of the layer, since the layers of several environments are aligned
+func (s SummaryLayerBatchInformation) header(suffix string) []string {
+	res := []string{
+		"model_name",
+		"model_version",
+		"framework_name",
+		"framework_version",
+		"hostname",
+		"machine_architecture",
+		"using_gpu",
+		"GPU_name",
+		"layer_index",
+		"layer_name",
+		"layer_type",
+	}
+	for _, batchSize := range s.BatchSizes {
+		res = append(res, "batch_"+cast.ToString(batchSize)+suffix+" (us)")
+	}
+	return res
+}
+
+func (s SummaryLayerBatchInformation) row(perSample bool) []string {
+	res := append(layerBatchEnvironmentRow(s.SummaryBase),
+		cast.ToString(s.Index),
+		s.Name,
+		s.Type,
+	)
+	for ii := range s.BatchSizes {
+		duration, ok := s.duration(ii, perSample)
+		if !ok {
+			res = append(res, "")
+			continue
+		}
+		res = append(res, cast.ToString(duration))
+	}
+	return res
+}
+
+// duration is the latency at the ii-th batch size, and is not ok if the layer is not found at that batch size
+func (s SummaryLayerBatchInformation) duration(ii int, perSample bool) (float64, bool) {
+	if ii >= len(s.Durations) || s.Durations[ii] == nil {
+		return 0, false
+	}
+	if perSample && s.BatchSizes[ii] > 0 {
+		return *s.Durations[ii] / float64(s.BatchSizes[ii]), true
+	}
+	return *s.Durations[ii], true
+}
+
+func (s SummaryLayerBatchInformation) Header(opts ...writer.Option) []string {
+	return s.header("")
+}
+
+func (s SummaryLayerBatchInformation) Row(opts ...writer.Option) []string {
+	return s.row(false)
+}
+
+func (s SummaryLayerBatchSampleInformation) Header(opts ...writer.Option) []string {
+	return SummaryLayerBatchInformation(s).header("_per_sample")
+}
+
+func (s SummaryLayerBatchSampleInformation) Row(opts ...writer.Option) []string {
+	return SummaryLayerBatchInformation(s).row(true)
+}
+
+func (o SummaryLayerBatchInformations) Header(opts ...writer.Option) []string {
+	if len(o) == 0 {
+		return SummaryLayerBatchInformation{}.Header(opts...)
+	}
+	return o[0].Header(opts...)
+}
+
+func (o SummaryLayerBatchInformations) Rows(opts ...writer.Option) [][]string {
+	res := make([][]string, len(o))
+	for ii, elem := range o {
+		res[ii] = elem.Row(opts...)
+	}
+	return res
+}
+
+func (o SummaryLayerBatchSampleInformations) Header(opts ...writer.Option) []string {
+	if len(o) == 0 {
+		return SummaryLayerBatchSampleInformation{}.Header(opts...)
+	}
+	return o[0].Header(opts...)
+}
+
+func (o SummaryLayerBatchSampleInformations) Rows(opts ...writer.Option) [][]string {
+	res := make([][]string, len(o))
+	for ii, elem := range o {
+		res[ii] = elem.Row(opts...)
+	}
+	return res
+}
+
+// PerSample returns the summaries with the latencies divided by the batch sizes
+func (o SummaryLayerBatchInformations) PerSample() SummaryLayerBatchSampleInformations {
+	res := make(SummaryLayerBatchSampleInformations, len(o))
+	for ii, elem := range o {
+		res[ii] = SummaryLayerBatchSampleInformation(elem)
+	}
+	return res
+}
+
+// SummaryLayerBatchInformations runs the layer analysis of each environment and batch size, and
+// aligns the layers of each environment across its batch sizes.
+func (es Evaluations) SummaryLayerBatchInformations(perfCol *PerformanceCollection) (SummaryLayerBatchInformations, error) {
+	if len(es) == 0 {
+		return SummaryLayerBatchInformations{}, errors.New("no evaluation is found in the database")
+	}
+
+	layerInfos := []SummaryLayerInformations{}
+	for _, evals := range es.GroupByEnvironmentAndBatchSize() {
+		infos, err := evals.SummaryLayerInformations(perfCol)
+		if err != nil {
+			log.WithError(err).WithField("batch_size", evals[0].BatchSize).Error("failed to get the layer information of the batch size")
+			continue
+		}
+		layerInfos = append(layerInfos, infos)
+	}
+	summary := alignLayerBatchInformations(layerInfos)
+	if len(summary) == 0 {
+		return summary, errors.New("no layer information is found for any batch size")
+	}
+	return summary, nil
+}
+
+// layerBatchEnvironmentRow is the model, framework and machine of a layer summary
+func layerBatchEnvironmentRow(base SummaryBase) []string {
+	gpuName := ""
+	if base.GPUInformation != nil {
+		gpuName = base.GPUInformation.ProductName
+	}
+	return []string{
+		base.ModelName,
+		base.ModelVersion,
+		base.FrameworkName,
+		base.FrameworkVersion,
+		base.HostName,
+		base.MachineArchitecture,
+		cast.ToString(base.UsingGPU),
+		gpuName,
+	}
+}
+
+func layerBatchEnvironment(base SummaryBase) string {
+	return strings.Join(layerBatchEnvironmentRow(base), "/")
+}
+
+// alignLayerBatchInformations aligns the layer summaries, each of one environment and batch size, by
+// layer name. The batch sizes are those of all the environments, and the durations of a layer are nil
+// at the batch sizes at which it is not found. The environments are in the order of the summaries,
+// the layers of an environment are in the order of its smallest batch size, and the layers which are
+// repeated within the model are aligned by their occurrence.
+func alignLayerBatchInformations(layerInfos []SummaryLayerInformations) SummaryLayerBatchInformations {
+	batchSizes := []int{}
+	envs := []string{}
+	envInfos := map[string][]SummaryLayerInformations{}
+	for _, infos := range layerInfos {
+		if len(infos) == 0 {
+			continue
+		}
+		env := layerBatchEnvironment(infos[0].SummaryBase)
+		if _, ok := envInfos[env]; !ok {
+			envs = append(envs, env)
+		}
+		envInfos[env] = append(envInfos[env], infos)
+		batchSizes = append(batchSizes, infos[0].BatchSize)
+	}
+	sort.Ints(batchSizes)
+	uniqueBatchSizes := []int{}
+	columns := map[int]int{}
+	for _, batchSize := range batchSizes {
+		if _, ok := columns[batchSize]; !ok {
+			columns[batchSize] = len(uniqueBatchSizes)
+			uniqueBatchSizes = append(uniqueBatchSizes, batchSize)
+		}
+	}
+
+	summary := SummaryLayerBatchInformations{}
+	for _, env := range envs {
+		infosList := envInfos[env]
+		sort.SliceStable(infosList, func(ii, jj int) bool {
+			return infosList[ii][0].BatchSize < infosList[jj][0].BatchSize
+		})
+		byKey := map[string]int{}
+		for _, infos := range infosList {
+			occurrences := map[string]int{}
+			for _, info := range infos {
+				key := fmt.Sprintf("%s#%d", info.Name, occurrences[info.Name])
+				occurrences[info.Name]++
+				ii, ok := byKey[key]
+				if !ok {
+					ii = len(summary)
+					byKey[key] = ii
+					summary = append(summary, SummaryLayerBatchInformation{
+						SummaryBase: info.SummaryBase,
+						Index:       info.Index,
+						Name:        info.Name,
+						Type:        info.Type,
+						BatchSizes:  uniqueBatchSizes,
+						Durations:   make([]*float64, len(uniqueBatchSizes)),
+					})
+				}
+				duration := info.Duration
+				summary[ii].Durations[columns[info.BatchSize]] = &duration
+			}
+		}
+	}
+	return summary
+}
+
+// heatMapPlotAdd adds a cell for each layer and batch size, colored by the latency
+func (o SummaryLayerBatchInformations) heatMapPlotAdd(heatMap *charts.HeatMap, perSample bool) *charts.HeatMap {
+	if len(o) == 0 {
+		return heatMap
+	}
+	batchLabels := []string{}
+	for _, batchSize := range o[0].BatchSizes {
+		batchLabels = append(batchLabels, cast.ToString(batchSize))
+	}
+	// the labels are prefixed with the environment if the layers are of several environments
+	multipleEnvironments := false
+	for _, elem := range o {
+		if layerBatchEnvironment(elem.SummaryBase) != layerBatchEnvironment(o[0].SummaryBase) {
+			multipleEnvironments = true
+			break
+		}
+	}
+	layerLabels := make([]string, len(o))
+	data := [][]interface{}{}
+	min, max := math.Inf(1), math.Inf(-1)
+	for ii, elem := range o {
+		layerLabels[ii] = cast.ToString(elem.Index) + " " + elem.Name
+		if multipleEnvironments {
+			layerLabels[ii] = elem.FrameworkName + " " + elem.FrameworkVersion + "@" + elem.HostName + " " + layerLabels[ii]
+		}
+		for jj := range elem.BatchSizes {
+			duration, ok := elem.duration(jj, perSample)
+			if !ok {
+				continue
+			}
+			min, max = math.Min(min, duration), math.Max(max, duration)
+			data = append(data, []interface{}{jj, ii, duration})
+		}
+	}
+	if len(data) == 0 {
+		min, max = 0, 0
+	}
+	heatMap.AddXAxis(batchLabels)
+	heatMap.AddYAxis("", data)
+	heatMap.SetGlobalOptions(
+		charts.TooltipOpts{Show: true},
+		charts.XAxisOpts{Name: "Batch Size", Type: "category", SplitArea: charts.SplitAreaOpts{Show: true}},
+		charts.YAxisOpts{Type: "category", Data: layerLabels, SplitArea: charts.SplitAreaOpts{Show: true}},
+		charts.VisualMapOpts{
+			Calculable: true,
+			Min:        float32(min),
+			Max:        float32(max),
+			InRange:    charts.VMInRange{Color: []string{"#50a3ba", "#eac736", "#d94e5d"}},
+		},
+		charts.DataZoomOpts{
+			Type:       "slider",
+			YAxisIndex: []int{0},
+			Start:      0,
+			End:        100,
+		},
+	)
+	return heatMap
+}
+
+func (o SummaryLayerBatchInformations) PlotName() string {
+	if len(o) == 0 {
+		return ""
+	}
+	return o[0].ModelName + `
+  Layer Latency across Batch Sizes`
+}
+
+func (o SummaryLayerBatchSampleInformations) PlotName() string {
+	if len(o) == 0 {
+		return ""
+	}
+	return o[0].ModelName + `
+  Layer Latency per Input across Batch Sizes`
+}
+
+func (o SummaryLayerBatchInformations) HeatMapPlot() *charts.HeatMap {
+	heatMap := charts.NewHeatMap()
+	heatMap = o.HeatMapPlotAdd(heatMap)
+	return heatMap
+}
+
+func (o SummaryLayerBatchSampleInformations) HeatMapPlot() *charts.HeatMap {
+	heatMap := charts.NewHeatMap()
+	heatMap = o.HeatMapPlotAdd(heatMap)
+	return heatMap
+}
+
+func (o SummaryLayerBatchInformations) HeatMapPlotAdd(heatMap *charts.HeatMap) *charts.HeatMap {
+	return o.heatMapPlotAdd(heatMap, false)
+}
+
+func (o SummaryLayerBatchSampleInformations) HeatMapPlotAdd(heatMap *charts.HeatMap) *charts.HeatMap {
+	infos := make(SummaryLayerBatchInformations, len(o))
+	for ii, elem := range o {
+		infos[ii] = SummaryLayerBatchInformation(elem)
+	}
+	return infos.heatMapPlotAdd(heatMap, true)
+}
+
+func (o SummaryLayerBatchInformations) WriteHeatMapPlot(path string) error {
+	return writeHeatMapPlot(o, path)
+}
+
+func (o SummaryLayerBatchSampleInformations) WriteHeatMapPlot(path string) error {
+	return writeHeatMapPlot(o, path)
+}
+
+func (o SummaryLayerBatchInformations) OpenHeatMapPlot() error {
+	return openHeatMapPlot(o)
+}
+
+func (o SummaryLayerBatchSampleInformations) OpenHeatMapPlot() error {
+	return openHeatMapPlot(o)
+}
@@ -0,0 +1,60 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLayerInformations(framework string, batchSize int, layers ...string) SummaryLayerInformations {
+	res := SummaryLayerInformations{}
+	for ii, name := range layers {
+		info := SummaryLayerInformation{Index: ii, Name: name, Type: "Conv2D", Duration: float64(batchSize*10 + ii)}
+		info.ModelName = "ResNet50"
+		info.FrameworkName = framework
+		info.BatchSize = batchSize
+		res = append(res, info)
+	}
+	return res
+}
+
+func TestAlignLayerBatchInformations(t *testing.T) {
+	summary := alignLayerBatchInformations([]SummaryLayerInformations{
+		testLayerInformations("MXNet", 4, "conv", "fc", "conv"),
+		testLayerInformations("TensorFlow", 2, "conv"),
+		testLayerInformations("MXNet", 1, "conv", "relu", "conv"),
+		{},
+	})
+	require.Len(t, summary, 5)
+
+	names := []string{}
+	for _, elem := range summary {
+		names = append(names, elem.FrameworkName+"/"+elem.Name)
+		assert.Equal(t, []int{1, 2, 4}, elem.BatchSizes)
+	}
+	// the layers of an environment are in the order of its smallest batch size
+	assert.Equal(t, []string{"MXNet/conv", "MXNet/relu", "MXNet/conv", "MXNet/fc", "TensorFlow/conv"}, names)
+	assert.Equal(t, 1, summary[0].BatchSize)
+	assert.Equal(t, 2, summary[2].Index)
+
+	// the repeated layers are aligned by their occurrence, and the missing layers are null
+	for ii, expected := range []string{"[10,null,40]", "[11,null,null]", "[12,null,42]", "[null,null,41]", "[null,20,null]"} {
+		bts, err := json.Marshal(summary[ii].Durations)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(bts), names[ii])
+	}
+
+	// the rows of the environments are told apart by their first columns
+	env := []string{"ResNet50", "", "MXNet", "", "", "", "false", ""}
+	assert.Equal(t, append(env, "0", "conv", "Conv2D", "10", "", "40"), summary[0].Row())
+	assert.Equal(t, append(env, "0", "conv", "Conv2D", "10", "", "10"), summary.PerSample()[0].Row())
+	assert.Equal(t, "TensorFlow", summary[4].Row()[2])
+	header := summary.PerSample().Header()
+	require.Len(t, header, len(summary[0].Row()))
+	assert.Equal(t, "framework_name", header[2])
+	assert.Equal(t, "batch_4_per_sample (us)", header[13])
+
+	assert.Empty(t, alignLayerBatchInformations(nil))
+}
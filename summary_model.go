@@ -3,6 +3,7 @@ package evaluation
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/rai-project/evaluation/writer"
@@ -55,12 +56,27 @@ func (s SummaryModelInformation) Row(opts ...writer.Option) []string {
 }
 
 func (es Evaluations) SummaryModelInformations(perfCol *PerformanceCollection) (SummaryModelInformations, error) {
-	summary := SummaryModelInformations{}
 	if len(es) == 0 {
-		return summary, errors.New("no evaluation is found in the database")
+		return SummaryModelInformations{}, errors.New("no evaluation is found in the database")
+	}
+	groupedEvals := []Evaluations{}
+	for _, evals := range es.GroupByBatchSize() {
+		groupedEvals = append(groupedEvals, evals)
+	}
+	return summaryModelInformations(groupedEvals, perfCol)
+}
+
+// SummaryModelSeriesInformations summarizes the evaluations of each model, framework, machine and
+// batch size separately, so that the series of a line plot do not merge different environments
+func (es Evaluations) SummaryModelSeriesInformations(perfCol *PerformanceCollection) (SummaryModelInformations, error) {
+	if len(es) == 0 {
+		return SummaryModelInformations{}, errors.New("no evaluation is found in the database")
 	}
-	groupedEvals := es.GroupByBatchSize()
+	return summaryModelInformations(es.GroupByEnvironmentAndBatchSize(), perfCol)
+}
 
+func summaryModelInformations(groupedEvals []Evaluations, perfCol *PerformanceCollection) (SummaryModelInformations, error) {
+	summary := SummaryModelInformations{}
 	for _, evals := range groupedEvals {
 		spans, err := evals.GetSpansFromPerformanceCollection(perfCol)
 		if err != nil {
@@ -94,20 +110,26 @@ func (es Evaluations) SummaryModelInformations(perfCol *PerformanceCollection) (
 	return summary, nil
 }
 
-func (o SummaryModelThroughputInformations) PlotName() string {
+// plotName prefixes the name with the model name if the summaries are all of the same model
+func (o SummaryModelInformations) plotName(name string) string {
 	if len(o) == 0 {
 		return ""
 	}
+	for _, elem := range o {
+		if elem.ModelName != o[0].ModelName {
+			return name
+		}
+	}
 	return o[0].ModelName + `
-  Throughput`
+  ` + name
+}
+
+func (o SummaryModelThroughputInformations) PlotName() string {
+	return SummaryModelInformations(o).plotName("Throughput")
 }
 
 func (o SummaryModelLatencyInformations) PlotName() string {
-	if len(o) == 0 {
-		return ""
-	}
-	return o[0].ModelName + `
-  Batch Latency`
+	return SummaryModelInformations(o).plotName("Batch Latency")
 }
 
 func (o SummaryModelThroughputInformations) BarPlot() *charts.Bar {
@@ -168,6 +190,123 @@ func (o SummaryModelLatencyInformations) BarPlotAdd(bar0 *charts.Bar) *charts.Ba
 	return bar
 }
 
+// lineSeries returns the names of the series, by the DefaultLineSeriesBy keys, and their points. The point
+// of a batch size is the mean of the selected values of the evaluations of the series at that batch size,
+// such as those of the environments which the series keys do not tell apart.
+func (o SummaryModelInformations) lineSeries(elemSelector SummaryModelInformationsSelector) ([]string, map[string][][]interface{}) {
+	names := []string{}
+	series := map[string]SummaryModelInformations{}
+	for _, elem := range o {
+		name := elem.SeriesName(DefaultLineSeriesBy)
+		if _, ok := series[name]; !ok {
+			names = append(names, name)
+		}
+		series[name] = append(series[name], elem)
+	}
+	res := map[string][][]interface{}{}
+	for _, name := range names {
+		elems := series[name]
+		sort.Stable(elems)
+		data := [][]interface{}{}
+		for ii := 0; ii < len(elems); {
+			sum, count := 0.0, 0
+			for _, elem := range elems[ii:] {
+				if elem.BatchSize != elems[ii].BatchSize {
+					break
+				}
+				sum += elemSelector(elem)
+				count++
+			}
+			data = append(data, []interface{}{elems[ii].BatchSize, sum / float64(count)})
+			ii += count
+		}
+		res[name] = data
+	}
+	return names, res
+}
+
+// linePlotAdd adds a line of the selected value against the batch size for each series, named by
+// the DefaultLineSeriesBy keys, so any selection of evaluations can be compared in one plot
+func (o SummaryModelInformations) linePlotAdd(line *charts.Line, elemSelector SummaryModelInformationsSelector) *charts.Line {
+	names, series := o.lineSeries(elemSelector)
+	for _, name := range names {
+		line.AddYAxis(name, series[name])
+	}
+	line.SetSeriesOptions(
+		charts.LabelTextOpts{Show: false},
+		charts.TextStyleOpts{FontSize: DefaultSeriesFontSize},
+	)
+
+	axisType := "value"
+	if DefaultLineLogScale {
+		axisType = "log"
+	}
+	jsFun := `function (params) {
+	  return params.seriesName + '<br/>batch size ' + params.value[0] + ': ' + params.value[1].toFixed(2);
+  }`
+	line.SetGlobalOptions(
+		charts.TooltipOpts{Show: true, Formatter: charts.FuncOpts(jsFun)},
+		charts.XAxisOpts{Name: "Batch Size", Type: axisType},
+	)
+	return line
+}
+
+func (o SummaryModelThroughputInformations) LinePlot() *charts.Line {
+	line := charts.NewLine()
+	line = o.LinePlotAdd(line)
+	return line
+}
+
+func (o SummaryModelLatencyInformations) LinePlot() *charts.Line {
+	line := charts.NewLine()
+	line = o.LinePlotAdd(line)
+	return line
+}
+
+func (o SummaryModelThroughputInformations) LinePlotAdd(line0 *charts.Line) *charts.Line {
+	line := SummaryModelInformations(o).linePlotAdd(line0, func(elem SummaryModelInformation) float64 {
+		return elem.Throughput
+	})
+	axisType := "value"
+	if DefaultLineLogScale {
+		axisType = "log"
+	}
+	line.SetGlobalOptions(
+		charts.YAxisOpts{Name: "Throughput (inputs/second)", Type: axisType},
+	)
+	return line
+}
+
+func (o SummaryModelLatencyInformations) LinePlotAdd(line0 *charts.Line) *charts.Line {
+	line := SummaryModelInformations(o).linePlotAdd(line0, func(elem SummaryModelInformation) float64 {
+		return float64(elem.Duration) / float64(1000)
+	})
+	axisType := "value"
+	if DefaultLineLogScale {
+		axisType = "log"
+	}
+	line.SetGlobalOptions(
+		charts.YAxisOpts{Name: "Batch Latency (ms)", Type: axisType},
+	)
+	return line
+}
+
+func (o SummaryModelThroughputInformations) WriteLinePlot(path string) error {
+	return writeLinePlot(o, path)
+}
+
+func (o SummaryModelLatencyInformations) WriteLinePlot(path string) error {
+	return writeLinePlot(o, path)
+}
+
+func (o SummaryModelThroughputInformations) OpenLinePlot() error {
+	return openLinePlot(o)
+}
+
+func (o SummaryModelLatencyInformations) OpenLinePlot() error {
+	return openLinePlot(o)
+}
+
 func (o SummaryModelThroughputInformations) WriteBarPlot(path string) error {
 	return writeBarPlot(o, path)
 }
@@ -0,0 +1,39 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryModelLineSeries(t *testing.T) {
+	model := func(framework, host string, batchSize int, throughput float64) SummaryModelInformation {
+		return SummaryModelInformation{
+			SummaryBase: SummaryBase{ModelName: "ResNet50", ModelVersion: "1.0", FrameworkName: framework, HostName: host, BatchSize: batchSize},
+			Throughput:  throughput,
+		}
+	}
+	models := SummaryModelInformations{
+		model("MXNet", "a", 2, 200),
+		model("MXNet", "b", 1, 50),
+		model("MXNet", "a", 1, 100),
+		model("TensorFlow", "a", 4, 300),
+		model("MXNet", "b", 2, 400),
+	}
+	throughput := func(elem SummaryModelInformation) float64 { return elem.Throughput }
+
+	defer func(seriesBy []string) { DefaultLineSeriesBy = seriesBy }(DefaultLineSeriesBy)
+
+	// the evaluations of the hosts are averaged at each batch size, rather than joined in a zig-zag
+	DefaultLineSeriesBy = []string{"model"}
+	names, series := models.lineSeries(throughput)
+	assert.Equal(t, []string{"ResNet50::1.0"}, names)
+	assert.Equal(t, [][]interface{}{{1, 75.0}, {2, 300.0}, {4, 300.0}}, series["ResNet50::1.0"])
+
+	DefaultLineSeriesBy = []string{"framework", "host"}
+	names, series = models.lineSeries(throughput)
+	assert.Equal(t, []string{"MXNet::, a", "MXNet::, b", "TensorFlow::, a"}, names)
+	assert.Equal(t, [][]interface{}{{1, 100.0}, {2, 200.0}}, series["MXNet::, a"])
+	assert.Equal(t, [][]interface{}{{1, 50.0}, {2, 400.0}}, series["MXNet::, b"])
+	assert.Equal(t, [][]interface{}{{4, 300.0}}, series["TensorFlow::, a"])
+}
@@ -0,0 +1,220 @@
+package evaluation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rai-project/dlframework"
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/spf13/cast"
+)
+
+type SummaryObjectDetectionAccuracyInformation struct {
+	SummaryBase        `json:",inline"`
+	NumInputs          int `json:"num_inputs,omitempty"`
+	NumSkipped         int `json:"num_skipped,omitempty"`
+	metrics.COCOResult `json:",inline"`
+}
+
+type SummaryObjectDetectionAccuracyInformations []SummaryObjectDetectionAccuracyInformation
+
+func formatAveragePrecision(ap float64) string {
+	if ap < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.4f", ap)
+}
+
+func (SummaryObjectDetectionAccuracyInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"num_inputs",
+		"num_skipped",
+		"AP",
+		"AP50",
+		"AP75",
+		"AP_small",
+		"AP_medium",
+		"AP_large",
+		"AR",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryObjectDetectionAccuracyInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.NumSkipped),
+		formatAveragePrecision(s.AP),
+		formatAveragePrecision(s.AP50),
+		formatAveragePrecision(s.AP75),
+		formatAveragePrecision(s.APSmall),
+		formatAveragePrecision(s.APMedium),
+		formatAveragePrecision(s.APLarge),
+		formatAveragePrecision(s.AR),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryObjectDetectionAccuracyInformations) Header(opts ...writer.Option) []string {
+	return SummaryObjectDetectionAccuracyInformation{}.Header(opts...)
+}
+
+func (s SummaryObjectDetectionAccuracyInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+type SummaryObjectDetectionClassInformation struct {
+	SummaryBase                `json:",inline"`
+	metrics.COCOCategoryResult `json:",inline"`
+}
+
+type SummaryObjectDetectionClassInformations []SummaryObjectDetectionClassInformation
+
+func (SummaryObjectDetectionClassInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"category",
+		"num_ground_truths",
+		"num_detections",
+		"AP",
+		"AP50",
+		"AP75",
+		"precision@IoU=0.5",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryObjectDetectionClassInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		s.Category,
+		cast.ToString(s.NumGroundTruths),
+		cast.ToString(s.NumDetections),
+		formatAveragePrecision(s.AP),
+		formatAveragePrecision(s.AP50),
+		formatAveragePrecision(s.AP75),
+		strings.Join(float64SliceToStringSlice(s.Precision), DefaultDimiter),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryObjectDetectionClassInformations) Header(opts ...writer.Option) []string {
+	return SummaryObjectDetectionClassInformation{}.Header(opts...)
+}
+
+func (s SummaryObjectDetectionClassInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// PerClass returns the accuracy of each category
+func (s SummaryObjectDetectionAccuracyInformation) PerClass() SummaryObjectDetectionClassInformations {
+	res := SummaryObjectDetectionClassInformations{}
+	for _, category := range s.PerCategory {
+		res = append(res, SummaryObjectDetectionClassInformation{
+			SummaryBase:        s.SummaryBase,
+			COCOCategoryResult: category,
+		})
+	}
+	return res
+}
+
+// boundingBoxesOf returns the bounding box features of the prediction
+func boundingBoxesOf(features dlframework.Features) (dlframework.Features, error) {
+	res := dlframework.Features{}
+	for _, feature := range features {
+		if _, ok := feature.Feature.(*dlframework.Feature_BoundingBox); !ok {
+			continue
+		}
+		res = append(res, feature)
+	}
+	if len(features) != 0 && len(res) == 0 {
+		return nil, errors.New("expecting bounding box features")
+	}
+	return res, nil
+}
+
+// COCOAccuracyInformationSummary computes the COCO detection metrics of the evaluation from
+// the stored input predictions. Detections are matched to the ground truth categories by label,
+// falling back to the COCO category id if the label is empty.
+func (e Evaluation) COCOAccuracyInformationSummary(predCol *InputPredictionCollection, annotations *metrics.COCOAnnotations) (*SummaryObjectDetectionAccuracyInformation, error) {
+	if len(e.InputPredictionIDs) == 0 {
+		return nil, errors.New("no input predictions found for the evaluation")
+	}
+	if annotations == nil {
+		return nil, errors.New("no coco annotations provided")
+	}
+
+	evaluator := metrics.NewCOCOEvaluator()
+
+	// the ground truths of an image are added once, however many predictions there are for the image
+	annotated := map[string]bool{}
+	numInputs := 0
+	numSkipped := 0
+	for _, id := range e.InputPredictionIDs {
+		var pred InputPrediction
+		err := predCol.FindOne(id, &pred)
+		if err != nil {
+			log.WithError(err).WithField("id", id.Hex()).Error("cannot find input prediction")
+			numSkipped++
+			continue
+		}
+		imageID, ok := annotations.ImageID(pred.InputID)
+		if !ok {
+			log.WithField("input_id", pred.InputID).Debug("skipping input prediction without annotations")
+			numSkipped++
+			continue
+		}
+		boxes, err := boundingBoxesOf(pred.Features)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		image := annotations.Images[imageID]
+		sample := &metrics.COCOSample{
+			ImageID:      imageID,
+			Width:        float64(image.Width),
+			Height:       float64(image.Height),
+			GroundTruths: annotations.GroundTruths[imageID],
+			Categories:   annotations.Categories,
+		}
+		if !annotated[imageID] {
+			evaluator.AddGroundTruth(sample.GroundTruths...)
+			annotated[imageID] = true
+		}
+		evaluator.AddDetection(sample.Detections(&boxes)...)
+		numInputs++
+	}
+
+	if numInputs == 0 {
+		return nil, errors.New("no annotated input predictions found for the evaluation")
+	}
+
+	return &SummaryObjectDetectionAccuracyInformation{
+		SummaryBase: e.summaryBase(),
+		NumInputs:   numInputs,
+		NumSkipped:  numSkipped,
+		COCOResult:  evaluator.Evaluate(),
+	}, nil
+}
+
+func (es Evaluations) COCOAccuracyInformationSummary(predCol *InputPredictionCollection, annotations *metrics.COCOAnnotations) (SummaryObjectDetectionAccuracyInformations, error) {
+	res := SummaryObjectDetectionAccuracyInformations{}
+	for _, e := range es {
+		s, err := e.COCOAccuracyInformationSummary(predCol, annotations)
+		if err != nil {
+			log.WithError(err).Error("failed to compute coco accuracy information summary")
+			continue
+		}
+		res = append(res, *s)
+	}
+	return res, nil
+}
@@ -0,0 +1,307 @@
+package evaluation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/rai-project/go-echarts/charts"
+	"github.com/spf13/cast"
+)
+
+// ParetoObjective is the performance measure traded off against the accuracy
+type ParetoObjective string
+
+const (
+	ParetoLatency    ParetoObjective = "latency"
+	ParetoThroughput ParetoObjective = "throughput"
+)
+
+type SummaryParetoInformation struct {
+	SummaryBase    `json:",inline"`
+	AccuracyMetric string  `json:"accuracy_metric,omitempty"`
+	Accuracy       float64 `json:"accuracy,omitempty"`
+	Latency        float64 `json:"latency,omitempty"`
+	Throughput     float64 `json:"throughput,omitempty"`
+	ParetoOptimal  bool    `json:"pareto_optimal,omitempty"`
+}
+
+type SummaryParetoInformations []SummaryParetoInformation
+
+func (SummaryParetoInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"accuracy_metric",
+		"accuracy",
+		"latency (ms)",
+		"throughput (input/s)",
+		"pareto_optimal",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummaryParetoInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		s.AccuracyMetric,
+		cast.ToString(s.Accuracy),
+		fmt.Sprintf("%.2f", s.Latency),
+		fmt.Sprintf("%.2f", s.Throughput),
+		cast.ToString(s.ParetoOptimal),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummaryParetoInformations) Header(opts ...writer.Option) []string {
+	return SummaryParetoInformation{}.Header(opts...)
+}
+
+func (s SummaryParetoInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+func (p SummaryParetoInformations) Len() int { return len(p) }
+func (p SummaryParetoInformations) Less(i, j int) bool {
+	if p[i].hardwareKey() != p[j].hardwareKey() {
+		return p[i].hardwareKey() < p[j].hardwareKey()
+	}
+	return p[i].Latency < p[j].Latency
+}
+func (p SummaryParetoInformations) Swap(i, j int) {
+	p[i], p[j] = p[j], p[i]
+}
+
+// hardwareKey identifies the hardware and batch size of the evaluation, with the GPU named as in the
+// "gpu" series so evaluations on different GPUs of the same host are told apart
+func (s SummaryParetoInformation) hardwareKey() string {
+	return strings.Join(
+		[]string{
+			s.HostName,
+			s.MachineArchitecture,
+			s.SeriesName([]string{"gpu"}),
+			cast.ToString(s.BatchSize),
+		},
+		",",
+	)
+}
+
+func (s SummaryParetoInformation) hardwareName() string {
+	return fmt.Sprintf("%s, batch size %d", s.SeriesName([]string{"host", "arch", "gpu"}), s.BatchSize)
+}
+
+func (s SummaryParetoInformation) label() string {
+	return fmt.Sprintf("%s %s (%s %s)", s.ModelName, s.ModelVersion, s.FrameworkName, s.FrameworkVersion)
+}
+
+func accuracyJoinKey(s SummaryBase) string {
+	return strings.ToLower(strings.Join(
+		[]string{
+			s.ModelName,
+			s.ModelVersion,
+			s.FrameworkName,
+			s.FrameworkVersion,
+		},
+		",",
+	))
+}
+
+// JoinParetoInformations joins the model performance with the model accuracy evaluated using the
+// same model and framework. Accuracies measured on the same machine architecture and device are
+// preferred since the accuracy does not depend on the batch size or host.
+func JoinParetoInformations(models SummaryModelInformations, accs SummaryModelAccuracyInformations, accuracyMetric string) SummaryParetoInformations {
+	candidates := map[string]SummaryModelAccuracyInformations{}
+	for _, acc := range accs {
+		k := accuracyJoinKey(acc.SummaryBase)
+		candidates[k] = append(candidates[k], acc)
+	}
+
+	accuracyOf := func(acc SummaryModelAccuracyInformation) float64 {
+		if strings.ToLower(accuracyMetric) == "top5" {
+			return acc.Top5Accuracy
+		}
+		return acc.Top1Accuracy
+	}
+
+	res := SummaryParetoInformations{}
+	for _, model := range models {
+		cs, ok := candidates[accuracyJoinKey(model.SummaryBase)]
+		if !ok || len(cs) == 0 {
+			log.WithField("model", model.ModelName).
+				WithField("framework", model.FrameworkName).
+				Debug("no accuracy found for the model")
+			continue
+		}
+		acc := cs[0]
+		for _, c := range cs {
+			if c.MachineArchitecture == model.MachineArchitecture && c.UsingGPU == model.UsingGPU {
+				acc = c
+				break
+			}
+		}
+		res = append(res, SummaryParetoInformation{
+			SummaryBase:    model.SummaryBase,
+			AccuracyMetric: accuracyMetric,
+			Accuracy:       accuracyOf(acc),
+			Latency:        model.Latency,
+			Throughput:     model.Throughput,
+		})
+	}
+	return res
+}
+
+// ParetoFrontier marks the pareto optimal points trading off the accuracy against the objective.
+// If perHardware is true, the frontier is computed separately for each hardware and batch size.
+func (s SummaryParetoInformations) ParetoFrontier(objective ParetoObjective, perHardware bool) SummaryParetoInformations {
+	groups := map[string][]int{}
+	for ii, e := range s {
+		k := ""
+		if perHardware {
+			k = e.hardwareKey()
+		}
+		groups[k] = append(groups[k], ii)
+	}
+	for _, idxs := range groups {
+		costs := make([]float64, len(idxs))
+		benefits := make([]float64, len(idxs))
+		for ii, idx := range idxs {
+			costs[ii] = s[idx].Latency
+			if objective == ParetoThroughput {
+				costs[ii] = -s[idx].Throughput
+			}
+			benefits[ii] = s[idx].Accuracy
+		}
+		for ii, optimal := range metrics.ParetoFrontier(costs, benefits) {
+			s[idxs[ii]].ParetoOptimal = optimal
+		}
+	}
+	return s
+}
+
+type SummaryParetoLatencyInformations SummaryParetoInformations
+
+type SummaryParetoThroughputInformations SummaryParetoInformations
+
+func (o SummaryParetoLatencyInformations) PlotName() string {
+	return "Accuracy vs Latency"
+}
+
+func (o SummaryParetoThroughputInformations) PlotName() string {
+	return "Accuracy vs Throughput"
+}
+
+func (o SummaryParetoLatencyInformations) ScatterPlot() *charts.Scatter {
+	scatter := charts.NewScatter()
+	scatter = o.ScatterPlotAdd(scatter)
+	return scatter
+}
+
+func (o SummaryParetoThroughputInformations) ScatterPlot() *charts.Scatter {
+	scatter := charts.NewScatter()
+	scatter = o.ScatterPlotAdd(scatter)
+	return scatter
+}
+
+type SummaryParetoInformationSelector func(elem SummaryParetoInformation) float64
+
+// scatterSeries returns the names of the series and their points. The dominated points are in one
+// series and the pareto frontier of each hardware is in its own series, so the frontiers are
+// highlighted and told apart. Each point is labeled with the model and framework.
+func (o SummaryParetoInformations) scatterSeries(elemSelector SummaryParetoInformationSelector) ([]string, map[string][][]interface{}) {
+	sorted := append(SummaryParetoInformations{}, o...)
+	sort.SliceStable(sorted, func(ii, jj int) bool {
+		return elemSelector(sorted[ii]) < elemSelector(sorted[jj])
+	})
+	hardwareNames := map[string]string{}
+	for _, elem := range sorted {
+		hardwareNames[elem.hardwareKey()] = elem.hardwareName()
+	}
+
+	names := []string{"Dominated"}
+	series := map[string][][]interface{}{"Dominated": {}}
+	for _, elem := range sorted {
+		point := []interface{}{elemSelector(elem), elem.Accuracy, elem.label()}
+		name := "Dominated"
+		if elem.ParetoOptimal {
+			name = "Pareto Frontier"
+			if len(hardwareNames) > 1 {
+				name += " (" + hardwareNames[elem.hardwareKey()] + ")"
+			}
+		}
+		if _, ok := series[name]; !ok {
+			names = append(names, name)
+		}
+		series[name] = append(series[name], point)
+	}
+	sort.Strings(names[1:])
+	return names, series
+}
+
+// scatterPlotAdd adds the dominated points and the pareto frontiers as separate series so the
+// frontiers are highlighted. Each point is labeled with the model and framework in the tooltip.
+func (o SummaryParetoInformations) scatterPlotAdd(scatter *charts.Scatter, elemSelector SummaryParetoInformationSelector) *charts.Scatter {
+	names, series := o.scatterSeries(elemSelector)
+	for _, name := range names {
+		scatter.AddYAxis(name, series[name])
+	}
+	scatter.SetSeriesOptions(
+		charts.LabelTextOpts{Show: false},
+		charts.TextStyleOpts{FontSize: DefaultSeriesFontSize},
+	)
+
+	jsFun := `function (params) {
+	  return params.value[2] + '<br/>' + params.value[0] + ', ' + params.value[1];
+  }`
+	scatter.SetGlobalOptions(
+		charts.TooltipOpts{Show: true, Formatter: charts.FuncOpts(jsFun)},
+		charts.YAxisOpts{Name: "Accuracy", Type: "value"},
+	)
+	return scatter
+}
+
+func (o SummaryParetoLatencyInformations) ScatterPlotAdd(scatter0 *charts.Scatter) *charts.Scatter {
+	scatter := SummaryParetoInformations(o).scatterPlotAdd(scatter0, func(elem SummaryParetoInformation) float64 {
+		return elem.Latency
+	})
+	scatter.SetGlobalOptions(
+		charts.XAxisOpts{Name: "Latency(ms)", Type: "value"},
+	)
+	return scatter
+}
+
+func (o SummaryParetoThroughputInformations) ScatterPlotAdd(scatter0 *charts.Scatter) *charts.Scatter {
+	scatter := SummaryParetoInformations(o).scatterPlotAdd(scatter0, func(elem SummaryParetoInformation) float64 {
+		return elem.Throughput
+	})
+	scatter.SetGlobalOptions(
+		charts.XAxisOpts{Name: "Throughput(input/s)", Type: "value"},
+	)
+	return scatter
+}
+
+func (o SummaryParetoLatencyInformations) WriteScatterPlot(path string) error {
+	return writeScatterPlot(o, path)
+}
+
+func (o SummaryParetoThroughputInformations) WriteScatterPlot(path string) error {
+	return writeScatterPlot(o, path)
+}
+
+func (o SummaryParetoLatencyInformations) OpenScatterPlot() error {
+	return openScatterPlot(o)
+}
+
+func (o SummaryParetoThroughputInformations) OpenScatterPlot() error {
+	return openScatterPlot(o)
+}
+
+// ScatterPlotter returns the scatter plotter of the objective
+func (o SummaryParetoInformations) ScatterPlotter(objective ParetoObjective) ScatterPlotter {
+	if objective == ParetoThroughput {
+		return SummaryParetoThroughputInformations(o)
+	}
+	return SummaryParetoLatencyInformations(o)
+}
@@ -0,0 +1,61 @@
+package evaluation
+
+import (
+	"testing"
+
+	nvidiasmi "github.com/rai-project/nvidia-smi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testParetoBase(model, arch, gpu string) SummaryBase {
+	base := SummaryBase{ModelName: model, FrameworkName: "MXNet", HostName: "host", MachineArchitecture: arch, BatchSize: 1}
+	if gpu != "" {
+		base.UsingGPU = true
+		base.GPUInformation = &nvidiasmi.GPU{ProductName: gpu}
+	}
+	return base
+}
+
+func TestJoinParetoInformations(t *testing.T) {
+	models := SummaryModelInformations{
+		{SummaryBase: testParetoBase("ResNet50", "amd64", "Tesla V100"), Latency: 10, Throughput: 100},
+		{SummaryBase: testParetoBase("ResNet50", "amd64", "Tesla T4"), Latency: 20, Throughput: 50},
+		{SummaryBase: testParetoBase("VGG16", "amd64", "Tesla V100"), Latency: 30, Throughput: 30},
+		{SummaryBase: testParetoBase("AlexNet", "amd64", "Tesla V100"), Latency: 5, Throughput: 200},
+	}
+	accs := SummaryModelAccuracyInformations{
+		{SummaryBase: testParetoBase("resnet50", "ppc64le", ""), Top1Accuracy: 0.70, Top5Accuracy: 0.90},
+		{SummaryBase: testParetoBase("ResNet50", "amd64", "Tesla P100"), Top1Accuracy: 0.75, Top5Accuracy: 0.92},
+		{SummaryBase: testParetoBase("VGG16", "ppc64le", ""), Top1Accuracy: 0.72, Top5Accuracy: 0.91},
+	}
+
+	// the models without an accuracy are left out, and the accuracies on the same architecture and device are preferred
+	summary := JoinParetoInformations(models, accs, "top1")
+	require.Len(t, summary, 3)
+	assert.Equal(t, []float64{0.75, 0.75, 0.72}, []float64{summary[0].Accuracy, summary[1].Accuracy, summary[2].Accuracy})
+	assert.Equal(t, "Tesla T4", summary[1].GPUInformation.ProductName)
+	assert.Equal(t, 20.0, summary[1].Latency)
+
+	summary = JoinParetoInformations(models, accs, "Top5")
+	require.Len(t, summary, 3)
+	assert.Equal(t, 0.92, summary[0].Accuracy)
+	assert.Equal(t, "Top5", summary[0].AccuracyMetric)
+
+	// the evaluations on the two GPUs of the host are different hardware
+	assert.NotEqual(t, summary[0].hardwareKey(), summary[1].hardwareKey())
+	summary = summary.ParetoFrontier(ParetoLatency, true)
+	assert.Equal(t, []bool{true, true, false}, []bool{summary[0].ParetoOptimal, summary[1].ParetoOptimal, summary[2].ParetoOptimal})
+
+	names, series := summary.scatterSeries(func(elem SummaryParetoInformation) float64 { return elem.Latency })
+	assert.Equal(t, []string{
+		"Dominated",
+		"Pareto Frontier (host, amd64, Tesla T4, batch size 1)",
+		"Pareto Frontier (host, amd64, Tesla V100, batch size 1)",
+	}, names)
+	assert.Equal(t, [][]interface{}{{30.0, 0.91, "VGG16  (MXNet )"}}, series["Dominated"])
+	assert.Equal(t, [][]interface{}{{20.0, 0.92, "ResNet50  (MXNet )"}}, series[names[1]])
+
+	names, _ = summary[:1].scatterSeries(func(elem SummaryParetoInformation) float64 { return elem.Latency })
+	assert.Equal(t, []string{"Dominated", "Pareto Frontier"}, names)
+}
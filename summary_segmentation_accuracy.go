@@ -0,0 +1,236 @@
+package evaluation
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/rai-project/evaluation/metrics"
+	"github.com/rai-project/evaluation/writer"
+	"github.com/spf13/cast"
+)
+
+// SegmentationOptions describes where the ground truth masks are found
+type SegmentationOptions struct {
+	// MasksDirectory contains a PNG mask (e.g. 2007_000033.png) for each input
+	MasksDirectory string
+	// NumClasses is the number of classes of the dataset (e.g. 21 for PASCAL VOC)
+	NumClasses  int
+	IgnoreLabel int
+	Labels      *ClassificationLabels
+}
+
+type SummarySegmentationAccuracyInformation struct {
+	SummaryBase                            `json:",inline"`
+	NumInputs                              int                      `json:"num_inputs,omitempty"`
+	NumSkipped                             int                      `json:"num_skipped,omitempty"`
+	PixelAccuracy                          float64                  `json:"pixel_accuracy,omitempty"`
+	MeanIntersectionOverUnion              float64                  `json:"mean_intersection_over_union,omitempty"`
+	FrequencyWeightedIntersectionOverUnion float64                  `json:"frequency_weighted_intersection_over_union,omitempty"`
+	ClassIntersectionOverUnion             []float64                `json:"class_intersection_over_union,omitempty"`
+	Labels                                 []string                 `json:"labels,omitempty"`
+	ConfusionMatrix                        *metrics.ConfusionMatrix `json:"confusion_matrix,omitempty"`
+}
+
+type SummarySegmentationAccuracyInformations []SummarySegmentationAccuracyInformation
+
+func (SummarySegmentationAccuracyInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"num_inputs",
+		"num_skipped",
+		"pixel_accuracy",
+		"mean_iou",
+		"frequency_weighted_iou",
+		"class_iou",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummarySegmentationAccuracyInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		cast.ToString(s.NumInputs),
+		cast.ToString(s.NumSkipped),
+		cast.ToString(s.PixelAccuracy),
+		cast.ToString(s.MeanIntersectionOverUnion),
+		cast.ToString(s.FrequencyWeightedIntersectionOverUnion),
+		strings.Join(float64SliceToStringSlice(s.ClassIntersectionOverUnion), DefaultDimiter),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummarySegmentationAccuracyInformations) Header(opts ...writer.Option) []string {
+	return SummarySegmentationAccuracyInformation{}.Header(opts...)
+}
+
+func (s SummarySegmentationAccuracyInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+func (s SummarySegmentationAccuracyInformation) label(idx int) string {
+	if idx < 0 || idx >= len(s.Labels) {
+		return ""
+	}
+	return s.Labels[idx]
+}
+
+type SummarySegmentationClassInformation struct {
+	SummaryBase           `json:",inline"`
+	ClassIndex            int     `json:"class_index"`
+	ClassLabel            string  `json:"class_label,omitempty"`
+	NumPixels             int64   `json:"num_pixels,omitempty"`
+	PixelAccuracy         float64 `json:"pixel_accuracy,omitempty"`
+	IntersectionOverUnion float64 `json:"intersection_over_union,omitempty"`
+}
+
+type SummarySegmentationClassInformations []SummarySegmentationClassInformation
+
+func (SummarySegmentationClassInformation) Header(opts ...writer.Option) []string {
+	extra := []string{
+		"class_index",
+		"class_label",
+		"num_pixels",
+		"pixel_accuracy",
+		"iou",
+	}
+	return append(SummaryBase{}.Header(opts...), extra...)
+}
+
+func (s SummarySegmentationClassInformation) Row(opts ...writer.Option) []string {
+	extra := []string{
+		cast.ToString(s.ClassIndex),
+		s.ClassLabel,
+		cast.ToString(s.NumPixels),
+		cast.ToString(s.PixelAccuracy),
+		cast.ToString(s.IntersectionOverUnion),
+	}
+	return append(s.SummaryBase.Row(opts...), extra...)
+}
+
+func (SummarySegmentationClassInformations) Header(opts ...writer.Option) []string {
+	return SummarySegmentationClassInformation{}.Header(opts...)
+}
+
+func (s SummarySegmentationClassInformations) Rows(opts ...writer.Option) [][]string {
+	rows := [][]string{}
+	for _, e := range s {
+		rows = append(rows, e.Row(opts...))
+	}
+	return rows
+}
+
+// PerClass returns the accuracy of each class which appears in the labels
+func (s SummarySegmentationAccuracyInformation) PerClass() SummarySegmentationClassInformations {
+	res := SummarySegmentationClassInformations{}
+	if s.ConfusionMatrix == nil {
+		return res
+	}
+	for ii, iou := range s.ClassIntersectionOverUnion {
+		if iou < 0 {
+			continue
+		}
+		res = append(res, SummarySegmentationClassInformation{
+			SummaryBase:           s.SummaryBase,
+			ClassIndex:            ii,
+			ClassLabel:            s.label(ii),
+			NumPixels:             s.ConfusionMatrix.ExpectedTotal(ii),
+			PixelAccuracy:         s.ConfusionMatrix.ClassAccuracy(ii),
+			IntersectionOverUnion: iou,
+		})
+	}
+	return res
+}
+
+// Confusion returns the non zero entries of the pixel confusion matrix
+func (s SummarySegmentationAccuracyInformation) Confusion() SummaryConfusionInformations {
+	return summaryConfusionInformations(s.SummaryBase, s.ConfusionMatrix, s.label)
+}
+
+// SegmentationAccuracyInformationSummary accumulates the pixels of the stored semantic segment
+// predictions against the ground truth masks and computes the segmentation metrics.
+func (e Evaluation) SegmentationAccuracyInformationSummary(predCol *InputPredictionCollection, opts SegmentationOptions) (*SummarySegmentationAccuracyInformation, error) {
+	if len(e.InputPredictionIDs) == 0 {
+		return nil, errors.New("no input predictions found for the evaluation")
+	}
+	if opts.MasksDirectory == "" {
+		return nil, errors.New("no ground truth masks directory provided")
+	}
+
+	confusionMatrix := metrics.NewConfusionMatrix(opts.NumClasses)
+
+	numInputs := 0
+	numSkipped := 0
+	for _, id := range e.InputPredictionIDs {
+		var pred InputPrediction
+		err := predCol.FindOne(id, &pred)
+		if err != nil {
+			log.WithError(err).WithField("id", id.Hex()).Error("cannot find input prediction")
+			numSkipped++
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(pred.InputID), filepath.Ext(pred.InputID))
+		expected, err := metrics.ReadSegmentationMask(filepath.Join(opts.MasksDirectory, name+".png"))
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction without a ground truth mask")
+			numSkipped++
+			continue
+		}
+		actual, err := metrics.SegmentationMaskFromFeatures(&pred.Features)
+		if err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Debug("skipping input prediction")
+			numSkipped++
+			continue
+		}
+		if err := expected.CheckLabels(opts.NumClasses, opts.IgnoreLabel); err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Error("skipping input prediction with an invalid ground truth mask")
+			numSkipped++
+			continue
+		}
+		if err := actual.CheckLabels(opts.NumClasses, opts.IgnoreLabel); err != nil {
+			log.WithError(err).WithField("input_id", pred.InputID).Error("skipping input prediction with an invalid predicted mask")
+			numSkipped++
+			continue
+		}
+		confusionMatrix.AddSegmentation(actual, expected, opts.IgnoreLabel)
+		numInputs++
+	}
+
+	if numInputs == 0 {
+		return nil, errors.New("no segmentation input predictions found for the evaluation")
+	}
+
+	classIoU := make([]float64, confusionMatrix.NumClasses())
+	labels := make([]string, confusionMatrix.NumClasses())
+	for ii := range classIoU {
+		classIoU[ii] = confusionMatrix.IntersectionOverUnion(ii)
+		labels[ii] = opts.Labels.Label(ii)
+	}
+
+	return &SummarySegmentationAccuracyInformation{
+		SummaryBase:                            e.summaryBase(),
+		NumInputs:                              numInputs,
+		NumSkipped:                             numSkipped,
+		PixelAccuracy:                          confusionMatrix.Accuracy(),
+		MeanIntersectionOverUnion:              confusionMatrix.MeanIntersectionOverUnion(),
+		FrequencyWeightedIntersectionOverUnion: confusionMatrix.FrequencyWeightedIntersectionOverUnion(),
+		ClassIntersectionOverUnion:             classIoU,
+		Labels:                                 labels,
+		ConfusionMatrix:                        confusionMatrix,
+	}, nil
+}
+
+func (es Evaluations) SegmentationAccuracyInformationSummary(predCol *InputPredictionCollection, opts SegmentationOptions) (SummarySegmentationAccuracyInformations, error) {
+	res := SummarySegmentationAccuracyInformations{}
+	for _, e := range es {
+		s, err := e.SegmentationAccuracyInformationSummary(predCol, opts)
+		if err != nil {
+			log.WithError(err).Error("failed to compute segmentation accuracy information summary")
+			continue
+		}
+		res = append(res, *s)
+	}
+	return res, nil
+}
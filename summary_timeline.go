@@ -0,0 +1,342 @@
+package evaluation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/rai-project/tracer"
+	"github.com/rai-project/utils/browser"
+	"github.com/spf13/cast"
+	model "github.com/uber/jaeger/model/json"
+)
+
+// SummaryTimelineInformation is a layer or a gpu kernel run during one predict step. The start
+// is relative to the start of the predict step.
+type SummaryTimelineInformation struct {
+	SummaryBase `json:",inline"`
+	Row         string            `json:"row,omitempty"`
+	Kind        string            `json:"kind,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	Start       float64           `json:"start,omitempty"`
+	Duration    float64           `json:"duration,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+type SummaryTimelineInformations []SummaryTimelineInformation
+
+// maximum length of the tag values shown when hovering the timeline
+const timelineTagMaxLength = 80
+
+func spanTimelineTags(span model.Span) map[string]string {
+	res := map[string]string{}
+	for _, tag := range span.Tags {
+		value := cast.ToString(tag.Value)
+		if len(value) > timelineTagMaxLength {
+			value = value[:timelineTagMaxLength] + "..."
+		}
+		res[tag.Key] = value
+	}
+	return res
+}
+
+// timelineRow is the thread of the layers, or the cuda stream of the gpu kernels
+func timelineRow(span model.Span, kind string) string {
+	if kind == "gpu_kernel" {
+		for _, key := range []string{"stream_id", "stream"} {
+			if stream, ok := spanTagValue(span, key); ok {
+				return "stream " + cast.ToString(stream)
+			}
+		}
+		return "stream"
+	}
+	if thread, ok := spanTagValue(span, "thread_id"); ok {
+		return "thread " + cast.ToString(thread)
+	}
+	return "framework"
+}
+
+// SummaryTimelineInformations returns the layers and the gpu kernels of the predict step at the
+// iteration, in the order of the predict steps. The predict steps are the c_predict spans of the
+// evaluations at the trace level.
+func (es Evaluations) SummaryTimelineInformations(perfCol *PerformanceCollection, traceLevel tracer.Level, iteration int) (SummaryTimelineInformations, error) {
+	if len(es) == 0 {
+		return SummaryTimelineInformations{}, errors.New("no evaluation is found in the database")
+	}
+	if len(es.GroupByBatchSize()) != 1 {
+		return SummaryTimelineInformations{}, errors.New("evaluations are not with the same batch size")
+	}
+
+	spans, err := es.GetSpansFromPerformanceCollection(perfCol)
+	if err != nil {
+		return SummaryTimelineInformations{}, err
+	}
+	if len(spans) == 0 {
+		return SummaryTimelineInformations{}, errors.New("no span is found for the evaluation")
+	}
+	return timelineInformations(es[0].summaryBase(), spans, traceLevel, iteration)
+}
+
+// timelineInformations returns the layers and the gpu kernels which are children of the predict step
+// at the iteration, ordered by their start
+func timelineInformations(base SummaryBase, spans Spans, traceLevel tracer.Level, iteration int) (SummaryTimelineInformations, error) {
+	summary := SummaryTimelineInformations{}
+	cPredictSpans := spans.FilterByOperationNameAndEvalTraceLevel("c_predict", traceLevel.String())
+	sort.SliceStable(cPredictSpans, func(ii, jj int) bool {
+		return cPredictSpans[ii].StartTime < cPredictSpans[jj].StartTime
+	})
+	if iteration < 0 || iteration >= len(cPredictSpans) {
+		return summary, errors.Errorf("the iteration %v is out of range, there are %v predict steps", iteration, len(cPredictSpans))
+	}
+	predictSpan := cPredictSpans[iteration]
+	groupedSpans, err := getGroupedSpansFromSpans(Spans{predictSpan}, spans)
+	if err != nil {
+		return summary, err
+	}
+
+	for _, span := range groupedSpans[0] {
+		spanTraceLevel, err := getTagValueAsString(span, "trace_level")
+		if err != nil || spanTraceLevel == "" {
+			continue
+		}
+		info := SummaryTimelineInformation{
+			SummaryBase: base,
+			Name:        span.OperationName,
+			Start:       float64(span.StartTime) - float64(predictSpan.StartTime),
+			Duration:    float64(span.Duration),
+			Tags:        spanTimelineTags(span),
+		}
+		switch {
+		case tracer.LevelFromName(spanTraceLevel) == tracer.FRAMEWORK_TRACE:
+			if strings.HasPrefix(span.OperationName, "_") {
+				continue
+			}
+			info.Kind = "layer"
+			info.Type = getOpName(span)
+			if info.Type == "" {
+				info.Type = "layer"
+			}
+		case strings.ToLower(span.OperationName) == "gpu_kernel":
+			info.Kind = "gpu_kernel"
+			info.Type = "gpu_kernel"
+			if name, err := getTagValueAsString(span, "kernel_name"); err == nil && name != "" {
+				info.Name = name
+			}
+		default:
+			continue
+		}
+		info.Row = timelineRow(span, info.Kind)
+		summary = append(summary, info)
+	}
+	if len(summary) == 0 {
+		return summary, errors.Errorf("no layer or gpu kernel is found in the predict step %v", iteration)
+	}
+	sort.SliceStable(summary, func(ii, jj int) bool {
+		return summary[ii].Start < summary[jj].Start
+	})
+	return summary, nil
+}
+
+func (o SummaryTimelineInformations) PlotName() string {
+	if len(o) == 0 {
+		return ""
+	}
+	return o[0].ModelName + " Predict Step Timeline"
+}
+
+// rows are the threads followed by the streams, each ordered by their number
+func (o SummaryTimelineInformations) rows() []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, elem := range o {
+		if !seen[elem.Row] {
+			seen[elem.Row] = true
+			res = append(res, elem.Row)
+		}
+	}
+	sort.SliceStable(res, func(ii, jj int) bool {
+		pi, pj := strings.HasPrefix(res[ii], "stream"), strings.HasPrefix(res[jj], "stream")
+		if pi != pj {
+			return !pi
+		}
+		fields := func(row string) (string, int64) {
+			idx := strings.LastIndex(row, " ")
+			if idx == -1 {
+				return row, -1
+			}
+			return row[:idx], cast.ToInt64(row[idx+1:])
+		}
+		ni, ii0 := fields(res[ii])
+		nj, jj0 := fields(res[jj])
+		if ni != nj {
+			return ni < nj
+		}
+		return ii0 < jj0
+	})
+	return res
+}
+
+type timelineSpan struct {
+	Row      int               `json:"row"`
+	Start    float64           `json:"start"`
+	End      float64           `json:"end"`
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Duration float64           `json:"duration"`
+	Tags     map[string]string `json:"tags"`
+}
+
+var timelinePlotTemplate = template.Must(template.New("timeline").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{html .Title}}</title>
+<script src="{{.AssetHost}}echarts.min.js"></script>
+</head>
+<body>
+<div id="timeline" style="width:{{.Width}}px;height:{{.Height}}px;"></div>
+<script type="text/javascript">
+var title = {{.TitleJSON}};
+var rows = {{.Rows}};
+var types = {{.Types}};
+var spans = {{.Spans}};
+var chart = echarts.init(document.getElementById('timeline'));
+var renderItem = function (params, api) {
+  var row = api.value(0);
+  var start = api.coord([api.value(1), row]);
+  var end = api.coord([api.value(2), row]);
+  var height = api.size([0, 1])[1] * 0.6;
+  var rect = echarts.graphic.clipRectByRect({
+    x: start[0], y: start[1] - height / 2, width: Math.max(end[0] - start[0], 1), height: height
+  }, {
+    x: params.coordSys.x, y: params.coordSys.y, width: params.coordSys.width, height: params.coordSys.height
+  });
+  return rect && {type: 'rect', shape: rect, style: api.style()};
+};
+chart.setOption({
+  title: {text: title, left: 'center', textStyle: {fontSize: {{.TitleFontSize}}}},
+  legend: {type: 'scroll', orient: 'vertical', right: 0, top: 'middle'},
+  tooltip: {
+    formatter: function (params) {
+      var span = params.data.span;
+      var lines = ['<b>' + echarts.format.encodeHTML(span.name) + '</b>', span.type, 'start: ' + span.start.toFixed(2) + ' us', 'duration: ' + span.duration.toFixed(2) + ' us'];
+      Object.keys(span.tags).sort().forEach(function (key) {
+        lines.push(echarts.format.encodeHTML(key + ': ' + span.tags[key]));
+      });
+      return lines.join('<br/>');
+    }
+  },
+  toolbox: {show: true, feature: {saveAsImage: {pixelRatio: 5}}},
+  dataZoom: [
+    {type: 'slider', filterMode: 'weakFilter', showDataShadow: false},
+    {type: 'inside', filterMode: 'weakFilter'}
+  ],
+  grid: {left: 120, right: 220},
+  xAxis: {name: 'Time (us)', type: 'value', min: 0, scale: true},
+  yAxis: {type: 'category', data: rows, inverse: true},
+  series: types.map(function (type) {
+    return {
+      name: type,
+      type: 'custom',
+      renderItem: renderItem,
+      encode: {x: [1, 2], y: 0},
+      data: spans.filter(function (span) { return span.type === type; }).map(function (span) {
+        return {value: [span.row, span.start, span.end], span: span};
+      })
+    };
+  })
+});
+</script>
+</body>
+</html>
+`))
+
+// renderTimelinePlot writes an html page with an echarts custom series, since the timeline is
+// not one of the charts of go-echarts. A row is a thread or a stream, and the bars are colored
+// by the layer type.
+func renderTimelinePlot(o SummaryTimelineInformations, w io.Writer) error {
+	rows := o.rows()
+	rowIndex := map[string]int{}
+	for ii, row := range rows {
+		rowIndex[row] = ii
+	}
+	types := []string{}
+	seenTypes := map[string]bool{}
+	spans := make([]timelineSpan, len(o))
+	for ii, elem := range o {
+		if !seenTypes[elem.Type] {
+			seenTypes[elem.Type] = true
+			types = append(types, elem.Type)
+		}
+		spans[ii] = timelineSpan{
+			Row:      rowIndex[elem.Row],
+			Start:    elem.Start,
+			End:      elem.Start + elem.Duration,
+			Name:     elem.Name,
+			Type:     elem.Type,
+			Duration: elem.Duration,
+			Tags:     elem.Tags,
+		}
+	}
+
+	// json escapes <, > and &, so the values cannot close the script
+	marshal := func(v interface{}) (string, error) {
+		bts, err := json.Marshal(v)
+		return string(bts), err
+	}
+	data := map[string]interface{}{
+		"Title":         o.PlotName(),
+		"AssetHost":     DefaultAssetHost,
+		"Width":         DefaultTimelinePlotWidth,
+		"Height":        DefaultTimelinePlotHeight + DefaultTimelinePlotRowHeight*len(rows),
+		"TitleFontSize": DefaultTitleFontSize,
+	}
+	if !DefaultShowTitle {
+		data["Title"] = ""
+	}
+	for key, value := range map[string]interface{}{
+		"TitleJSON": data["Title"],
+		"Rows":      rows,
+		"Types":     types,
+		"Spans":     spans,
+	} {
+		s, err := marshal(value)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the timeline")
+		}
+		data[key] = s
+	}
+	buf := new(bytes.Buffer)
+	if err := timelinePlotTemplate.Execute(buf, data); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func (o SummaryTimelineInformations) WriteTimelinePlot(path string) error {
+	if isStaticPlotPath(path) {
+		return errors.New("the timeline plot can only be rendered as html")
+	}
+	return writePlotPage(path, func(w io.Writer) error { return renderTimelinePlot(o, w) })
+}
+
+func (o SummaryTimelineInformations) OpenTimelinePlot() error {
+	filepath := TempFile("", "timelinePlot_*.html")
+	if filepath == "" {
+		return errors.New("failed to create temporary file")
+	}
+	err := o.WriteTimelinePlot(filepath)
+	if err != nil {
+		return err
+	}
+	if ok := browser.Open(filepath); !ok {
+		return errors.New("failed to open browser filepath")
+	}
+	return nil
+}
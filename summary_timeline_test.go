@@ -0,0 +1,87 @@
+package evaluation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rai-project/tracer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	model "github.com/uber/jaeger/model/json"
+)
+
+func testTimelineSpan(id, parent, operationName string, start uint64, tags map[string]interface{}) model.Span {
+	span := model.Span{
+		SpanID:        model.SpanID(id),
+		ParentSpanID:  model.SpanID(parent),
+		OperationName: operationName,
+		StartTime:     start,
+		Duration:      10,
+	}
+	for key, value := range tags {
+		span.Tags = append(span.Tags, model.KeyValue{Key: key, Value: value})
+	}
+	return span
+}
+
+func testTimelineSpans() Spans {
+	predict := map[string]interface{}{"evaluation_trace_level": tracer.FULL_TRACE.String()}
+	layer := func(threadID int) map[string]interface{} {
+		return map[string]interface{}{"trace_level": tracer.FRAMEWORK_TRACE.String(), "op_name": "Conv2D", "thread_id": threadID}
+	}
+	kernel := func(streamID int) map[string]interface{} {
+		return map[string]interface{}{"trace_level": tracer.SYSTEM_LIBRARY_TRACE.String(), "kernel_name": "sgemm", "stream_id": streamID}
+	}
+	// the predict steps are not in the order of their start
+	return Spans{
+		testTimelineSpan("p1", "", "c_predict", 1000, predict),
+		testTimelineSpan("p0", "", "c_predict", 100, predict),
+		testTimelineSpan("a", "p0", "conv0", 110, layer(1)),
+		testTimelineSpan("b", "p1", "conv2", 1030, layer(2)),
+		testTimelineSpan("c", "p1", "gpu_kernel", 1040, kernel(12)),
+		testTimelineSpan("d", "p1", "gpu_kernel", 1020, kernel(7)),
+		testTimelineSpan("e", "p1", "conv1", 1010, layer(1)),
+		testTimelineSpan("f", "p1", "_internal", 1015, layer(1)),
+		testTimelineSpan("g", "p1", "untraced", 1016, nil),
+	}
+}
+
+func TestTimelineInformations(t *testing.T) {
+	base := SummaryBase{ModelName: "ResNet50"}
+	summary, err := timelineInformations(base, testTimelineSpans(), tracer.FULL_TRACE, 1)
+	require.NoError(t, err)
+
+	names, rows, starts := []string{}, []string{}, []float64{}
+	for _, elem := range summary {
+		names = append(names, elem.Name)
+		rows = append(rows, elem.Row)
+		starts = append(starts, elem.Start)
+	}
+	assert.Equal(t, []string{"conv1", "sgemm", "conv2", "sgemm"}, names)
+	assert.Equal(t, []string{"thread 1", "stream 7", "thread 2", "stream 12"}, rows)
+	assert.Equal(t, []float64{10, 20, 30, 40}, starts)
+	assert.Equal(t, "Conv2D", summary[0].Type)
+	assert.Equal(t, "gpu_kernel", summary[1].Kind)
+	assert.Equal(t, "ResNet50", summary[0].ModelName)
+
+	// the threads are followed by the streams, each ordered by their number
+	assert.Equal(t, []string{"thread 1", "thread 2", "stream 7", "stream 12"}, summary.rows())
+	buf := new(bytes.Buffer)
+	require.NoError(t, renderTimelinePlot(summary, buf))
+	assert.Contains(t, buf.String(), `var rows = ["thread 1","thread 2","stream 7","stream 12"];`)
+	assert.Contains(t, buf.String(), `{"row":3,"start":40,"end":50,"name":"sgemm"`)
+
+	summary, err = timelineInformations(base, testTimelineSpans(), tracer.FULL_TRACE, 0)
+	require.NoError(t, err)
+	require.Len(t, summary, 1)
+	assert.Equal(t, "conv0", summary[0].Name)
+	assert.Equal(t, 10.0, summary[0].Start)
+
+	for _, iteration := range []int{-1, 2} {
+		_, err = timelineInformations(base, testTimelineSpans(), tracer.FULL_TRACE, iteration)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "out of range")
+	}
+	_, err = timelineInformations(base, testTimelineSpans(), tracer.MODEL_TRACE, 0)
+	assert.Error(t, err)
+}
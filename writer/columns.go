@@ -0,0 +1,188 @@
+package writer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The unit of a column is declared at the end of its header label, e.g. "layer_duration (us)".
+// Columns whose key ends with "_bytes", or whose unit is "bytes", are byte counts.
+
+var (
+	// timeUnits is the number of microseconds in each unit
+	timeUnits = map[string]float64{
+		"ns": 1e-3,
+		"us": 1,
+		"ms": 1e3,
+		"s":  1e6,
+	}
+	// bytesUnits is the number of bytes in each unit. KB, MB and GB are the powers of 1024 used in the headers.
+	bytesUnits = map[string]float64{
+		"B":   1,
+		"KB":  1 << 10,
+		"KiB": 1 << 10,
+		"MB":  1 << 20,
+		"MiB": 1 << 20,
+		"GB":  1 << 30,
+		"GiB": 1 << 30,
+	}
+	headerUnitRegexp = regexp.MustCompile(`^(.*?)\s*\(([^()]*)\)$`)
+	floatRegexp      = regexp.MustCompile(`^-?[0-9]+\.[0-9]+([eE][-+]?[0-9]+)?$`)
+	listValueRegexp  = regexp.MustCompile(`[^;,]+`)
+)
+
+// ValidateUnits returns an error if the time or bytes unit is unknown
+func (o Options) ValidateUnits() error {
+	if _, ok := timeUnits[o.TimeUnit]; o.TimeUnit != "" && !ok {
+		return errors.Errorf("invalid time unit %v, expecting one of ns, us, ms or s", o.TimeUnit)
+	}
+	if _, ok := bytesUnits[o.BytesUnit]; o.BytesUnit != "" && !ok {
+		return errors.Errorf("invalid bytes unit %v, expecting one of B, KiB, MiB or GiB", o.BytesUnit)
+	}
+	return nil
+}
+
+// ColumnKey is the header label without its unit
+func ColumnKey(label string) string {
+	key, _ := columnUnit(label)
+	return key
+}
+
+func columnUnit(label string) (string, string) {
+	if m := headerUnitRegexp.FindStringSubmatch(label); m != nil {
+		if m[2] == "bytes" {
+			return m[1], "B"
+		}
+		return m[1], m[2]
+	}
+	if strings.HasSuffix(label, "_bytes") || label == "bytes" {
+		return label, "B"
+	}
+	return label, ""
+}
+
+// columnConversion returns the scale of the column values and the unit of the header
+// once converted to the time or bytes unit of the options
+func (o Options) columnConversion(label string) (float64, string, bool) {
+	_, unit := columnUnit(label)
+	if from, ok := timeUnits[unit]; ok && o.TimeUnit != "" {
+		return from / timeUnits[o.TimeUnit], o.TimeUnit, true
+	}
+	if from, ok := bytesUnits[unit]; ok && o.BytesUnit != "" {
+		return from / bytesUnits[o.BytesUnit], o.BytesUnit, true
+	}
+	return 1, unit, false
+}
+
+// HasColumnTransforms returns true if the tabular output is transformed by the options
+func (o Options) HasColumnTransforms() bool {
+	return len(o.Columns) != 0 || o.TimeUnit != "" || o.BytesUnit != "" || o.Precision >= 0
+}
+
+// columnIndices returns the indices of the selected columns in the order of the selection
+func (o Options) columnIndices(header []string, numColumns int) []int {
+	if len(o.Columns) == 0 {
+		res := make([]int, numColumns)
+		for ii := range res {
+			res[ii] = ii
+		}
+		return res
+	}
+	res := []int{}
+	for _, column := range o.Columns {
+		if ii := columnIndex(header, column); ii != -1 {
+			res = append(res, ii)
+		}
+	}
+	return res
+}
+
+// columnIndex returns the index of the column in the header, matched by key or label, or -1
+func columnIndex(header []string, column string) int {
+	for ii, label := range header {
+		if strings.EqualFold(column, ColumnKey(label)) || strings.EqualFold(column, label) {
+			return ii
+		}
+	}
+	return -1
+}
+
+// MissingColumns returns the selected columns which are not in the header
+func (o Options) MissingColumns(header []string) []string {
+	res := []string{}
+	for _, column := range o.Columns {
+		if columnIndex(header, column) == -1 {
+			res = append(res, column)
+		}
+	}
+	return res
+}
+
+// TransformHeader selects the columns and relabels the units of the converted columns
+func (o Options) TransformHeader(header []string) []string {
+	if !o.HasColumnTransforms() {
+		return header
+	}
+	res := []string{}
+	for _, ii := range o.columnIndices(header, len(header)) {
+		label := header[ii]
+		if _, unit, ok := o.columnConversion(label); ok {
+			label = ColumnKey(label) + " (" + unit + ")"
+		}
+		res = append(res, label)
+	}
+	return res
+}
+
+// TransformRow selects the columns, converts the time and byte values and rounds the
+// floating point values to the precision. The header is the untransformed header of the row.
+func (o Options) TransformRow(header []string, row []string) []string {
+	if !o.HasColumnTransforms() {
+		return row
+	}
+	res := []string{}
+	for _, ii := range o.columnIndices(header, len(row)) {
+		if ii >= len(row) {
+			res = append(res, "")
+			continue
+		}
+		cell := row[ii]
+		label := ""
+		if ii < len(header) {
+			label = header[ii]
+		}
+		res = append(res, o.transformCell(label, cell))
+	}
+	return res
+}
+
+func (o Options) formatFloat(v float64, converted bool, s string) string {
+	if o.Precision >= 0 && (converted || floatRegexp.MatchString(s)) {
+		return strconv.FormatFloat(v, 'f', o.Precision, 64)
+	}
+	if converted {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return s
+}
+
+func (o Options) transformCell(label, cell string) string {
+	scale, _, converted := o.columnConversion(label)
+	if !converted {
+		if v, err := strconv.ParseFloat(cell, 64); err == nil {
+			return o.formatFloat(v, false, cell)
+		}
+		return cell
+	}
+	// lists of values are joined with ; or ,
+	return listValueRegexp.ReplaceAllStringFunc(cell, func(s string) string {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return s
+		}
+		return o.formatFloat(v*scale, true, s)
+	})
+}
@@ -0,0 +1,37 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformColumns(t *testing.T) {
+	header := []string{"layer_name", "layer_duration (us)", "layer_allocated_bytes", "durations (us)", "machine_memory (KB)", "ratio"}
+	row := []string{"conv,1.5", "1500", "2097152", "1000;2000", "1024", "0.123456"}
+
+	opts := NewOptions()
+	assert.False(t, opts.HasColumnTransforms())
+	assert.Equal(t, row, opts.TransformRow(header, row))
+
+	opts = NewOptions(TimeUnit("ms"), BytesUnit("MiB"))
+	assert.Equal(t, []string{"layer_name", "layer_duration (ms)", "layer_allocated_bytes (MiB)", "durations (ms)", "machine_memory (MiB)", "ratio"}, opts.TransformHeader(header))
+	assert.Equal(t, []string{"conv,1.5", "1.5", "2", "1;2", "1", "0.123456"}, opts.TransformRow(header, row))
+
+	opts = NewOptions(Columns([]string{"ratio", "layer_duration", "missing"}), TimeUnit("s"), Precision(2))
+	assert.Equal(t, []string{"ratio", "layer_duration (s)"}, opts.TransformHeader(header))
+	assert.Equal(t, []string{"0.12", "0.00"}, opts.TransformRow(header, row))
+	assert.Equal(t, []string{"missing"}, opts.MissingColumns(header))
+
+	// the columns in bytes are converted as the columns in B
+	header = []string{"allocated memory (bytes)", "peak_memory (B)"}
+	row = []string{"3145728", "1048576"}
+	opts = NewOptions(BytesUnit("MiB"))
+	assert.Equal(t, []string{"allocated memory (MiB)", "peak_memory (MiB)"}, opts.TransformHeader(header))
+	assert.Equal(t, []string{"3", "1"}, opts.TransformRow(header, row))
+	assert.Equal(t, "allocated memory", ColumnKey("allocated memory (bytes)"))
+
+	assert.NoError(t, NewOptions(TimeUnit("us"), BytesUnit("GiB")).ValidateUnits())
+	assert.Error(t, NewOptions(TimeUnit("minutes")).ValidateUnits())
+	assert.Error(t, NewOptions(BytesUnit("TB")).ValidateUnits())
+}
@@ -0,0 +1,234 @@
+package writer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BestKey selects the column whose best value is highlighted in the latex tables
+type BestKey struct {
+	Column string
+	Max    bool
+}
+
+// ParseBestKeys parses comma separated keys of the form <column>[:min|max], e.g.
+// "layer_duration:min,throughput:max". The smallest value is the best by default.
+func ParseBestKeys(s string) ([]BestKey, error) {
+	res := []BestKey{}
+	for _, spec := range strings.Split(s, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		key := BestKey{Column: spec}
+		if idx := strings.LastIndex(spec, ":"); idx != -1 {
+			key.Column = strings.TrimSpace(spec[:idx])
+			switch strings.ToLower(strings.TrimSpace(spec[idx+1:])) {
+			case "min":
+			case "max":
+				key.Max = true
+			default:
+				return nil, errors.Errorf("invalid best value in %v, expecting min or max", spec)
+			}
+		}
+		if key.Column == "" {
+			return nil, errors.Errorf("missing best column in %v", spec)
+		}
+		res = append(res, key)
+	}
+	return res, nil
+}
+
+var latexReplacer = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// LatexEscape escapes the characters which are special in latex
+func LatexEscape(s string) string {
+	return latexReplacer.Replace(s)
+}
+
+// LatexTable renders the rows as a booktabs table. The rows are grouped by the LatexGroupBy
+// column with a \midrule between the groups, and the best values of the LatexBest columns
+// are in bold within each group.
+type LatexTable struct {
+	opts   Options
+	header []string
+	rows   [][]string
+}
+
+func NewLatexTable(opts Options) *LatexTable {
+	return &LatexTable{opts: opts}
+}
+
+func (t *LatexTable) SetHeader(header []string) {
+	t.header = header
+}
+
+func (t *LatexTable) Append(row []string) {
+	t.rows = append(t.rows, row)
+}
+
+// groups splits the rows by the value of the group column, in the order of their first row
+func (t *LatexTable) groups() [][][]string {
+	groupIdx := -1
+	if t.opts.LatexGroupBy != "" {
+		groupIdx = columnIndex(t.header, t.opts.LatexGroupBy)
+	}
+	if groupIdx == -1 {
+		return [][][]string{t.rows}
+	}
+	res := [][][]string{}
+	byValue := map[string]int{}
+	for _, row := range t.rows {
+		value := ""
+		if groupIdx < len(row) {
+			value = row[groupIdx]
+		}
+		ii, ok := byValue[value]
+		if !ok {
+			ii = len(res)
+			byValue[value] = ii
+			res = append(res, [][]string{})
+		}
+		res[ii] = append(res[ii], row)
+	}
+	return res
+}
+
+// bestCells returns the positions of the best values of the group
+func (t *LatexTable) bestCells(rows [][]string) map[[2]int]bool {
+	res := map[[2]int]bool{}
+	for _, key := range t.opts.LatexBest {
+		col := columnIndex(t.header, key.Column)
+		if col == -1 {
+			continue
+		}
+		best := ""
+		for _, row := range rows {
+			if col >= len(row) {
+				continue
+			}
+			if _, err := strconv.ParseFloat(row[col], 64); err != nil {
+				continue
+			}
+			c := CompareCells(row[col], best)
+			if best == "" || (key.Max && c > 0) || (!key.Max && c < 0) {
+				best = row[col]
+			}
+		}
+		if best == "" {
+			continue
+		}
+		for ii, row := range rows {
+			if col < len(row) && CompareCells(row[col], best) == 0 {
+				res[[2]int{ii, col}] = true
+			}
+		}
+	}
+	return res
+}
+
+// alignment right aligns the numeric columns
+func (t *LatexTable) alignment(numColumns int) string {
+	res := ""
+	for col := 0; col < numColumns; col++ {
+		numeric := false
+		for _, row := range t.rows {
+			if col >= len(row) || row[col] == "" {
+				continue
+			}
+			if _, err := strconv.ParseFloat(row[col], 64); err != nil {
+				numeric = false
+				break
+			}
+			numeric = true
+		}
+		if numeric {
+			res += "r"
+		} else {
+			res += "l"
+		}
+	}
+	return res
+}
+
+func latexRow(cells []string) string {
+	return strings.Join(cells, " & ") + ` \\` + "\n"
+}
+
+// Render writes the table. The tabular is wrapped in a table float if there is a caption or a label.
+func (t *LatexTable) Render(w io.Writer) error {
+	numColumns := len(t.header)
+	for _, row := range t.rows {
+		if len(row) > numColumns {
+			numColumns = len(row)
+		}
+	}
+	groupIdx := -1
+	if t.opts.LatexGroupBy != "" {
+		groupIdx = columnIndex(t.header, t.opts.LatexGroupBy)
+	}
+
+	var b strings.Builder
+	float := t.opts.LatexCaption != "" || t.opts.LatexLabel != ""
+	if float {
+		b.WriteString("\\begin{table}[htbp]\n\\centering\n")
+		if t.opts.LatexCaption != "" {
+			fmt.Fprintf(&b, "\\caption{%s}\n", LatexEscape(t.opts.LatexCaption))
+		}
+		if t.opts.LatexLabel != "" {
+			fmt.Fprintf(&b, "\\label{%s}\n", t.opts.LatexLabel)
+		}
+	}
+	fmt.Fprintf(&b, "\\begin{tabular}{%s}\n\\toprule\n", t.alignment(numColumns))
+	if len(t.header) != 0 {
+		cells := make([]string, numColumns)
+		for ii, label := range t.header {
+			cells[ii] = LatexEscape(label)
+		}
+		b.WriteString(latexRow(cells))
+		b.WriteString("\\midrule\n")
+	}
+	for gg, rows := range t.groups() {
+		if gg != 0 {
+			b.WriteString("\\midrule\n")
+		}
+		best := t.bestCells(rows)
+		for ii, row := range rows {
+			cells := make([]string, numColumns)
+			for col, cell := range row {
+				switch {
+				case col == groupIdx && ii != 0:
+					// the group value is only shown on the first row of the group
+					cell = ""
+				case best[[2]int{ii, col}]:
+					cell = "\\textbf{" + LatexEscape(cell) + "}"
+				default:
+					cell = LatexEscape(cell)
+				}
+				cells[col] = cell
+			}
+			b.WriteString(latexRow(cells))
+		}
+	}
+	b.WriteString("\\bottomrule\n\\end{tabular}\n")
+	if float {
+		b.WriteString("\\end{table}\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,57 @@
+package writer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatexTable(t *testing.T) {
+	assert.Equal(t, `50\% \& a\_b \{\textasciitilde{}\}`, LatexEscape("50% & a_b {~}"))
+
+	best, err := ParseBestKeys("latency:min,throughput:max")
+	assert.NoError(t, err)
+	assert.Equal(t, []BestKey{{Column: "latency"}, {Column: "throughput", Max: true}}, best)
+	_, err = ParseBestKeys("latency:lowest")
+	assert.Error(t, err)
+
+	tbl := NewLatexTable(NewOptions(
+		LatexCaption("Latency of resnet_50"),
+		LatexLabel("tab:latency"),
+		LatexGroupBy("batch_size"),
+		LatexBest(best),
+	))
+	tbl.SetHeader([]string{"batch_size", "framework_name", "latency (ms)", "throughput"})
+	tbl.Append([]string{"1", "tensorflow", "5.5", "181"})
+	tbl.Append([]string{"2", "tensorflow", "9", "222"})
+	tbl.Append([]string{"1", "mxnet", "4.25", "235"})
+	tbl.Append([]string{"2", "mxnet", "9", "222"})
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, tbl.Render(buf))
+	assert.Equal(t, `\begin{table}[htbp]
+\centering
+\caption{Latency of resnet\_50}
+\label{tab:latency}
+\begin{tabular}{rlrr}
+\toprule
+batch\_size & framework\_name & latency (ms) & throughput \\
+\midrule
+1 & tensorflow & 5.5 & 181 \\
+ & mxnet & \textbf{4.25} & \textbf{235} \\
+\midrule
+2 & tensorflow & \textbf{9} & \textbf{222} \\
+ & mxnet & \textbf{9} & \textbf{222} \\
+\bottomrule
+\end{tabular}
+\end{table}
+`, buf.String())
+
+	tbl = NewLatexTable(NewOptions())
+	tbl.SetHeader([]string{"name"})
+	tbl.Append([]string{"conv"})
+	buf.Reset()
+	assert.NoError(t, tbl.Render(buf))
+	assert.Equal(t, "\\begin{tabular}{l}\n\\toprule\nname \\\\\n\\midrule\nconv \\\\\n\\bottomrule\n\\end{tabular}\n", buf.String())
+}
@@ -0,0 +1,180 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// NDJSONEncoder writes one json value per line (http://ndjson.org)
+type NDJSONEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	enc.SetEscapeHTML(false)
+	return &NDJSONEncoder{w: bw, enc: enc}
+}
+
+// Encode writes the value followed by a newline
+func (e *NDJSONEncoder) Encode(v interface{}) error {
+	return e.enc.Encode(v)
+}
+
+func (e *NDJSONEncoder) Flush() error {
+	return e.w.Flush()
+}
+
+// NDJSONDecoder reads the values of a newline delimited json stream. Blank lines are skipped.
+type NDJSONDecoder struct {
+	r    *bufio.Reader
+	line int
+}
+
+func NewNDJSONDecoder(r io.Reader) *NDJSONDecoder {
+	return &NDJSONDecoder{r: bufio.NewReader(r)}
+}
+
+// Next returns the next json value of the stream, or io.EOF at the end of the stream
+func (d *NDJSONDecoder) Next() (json.RawMessage, error) {
+	for {
+		line, err := d.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(line) == 0 && err == io.EOF {
+			return nil, io.EOF
+		}
+		d.line++
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			continue
+		}
+		if !json.Valid(line) {
+			return nil, errors.Errorf("invalid json value on line %v", d.line)
+		}
+		return json.RawMessage(line), nil
+	}
+}
+
+// Decode unmarshals the next json value of the stream into v
+func (d *NDJSONDecoder) Decode(v interface{}) error {
+	msg, err := d.Next()
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(msg, v); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal line %v", d.line)
+	}
+	return nil
+}
+
+// ReadNDJSON calls fn with each json value of the stream
+func ReadNDJSON(r io.Reader, fn func(json.RawMessage) error) error {
+	dec := NewNDJSONDecoder(r)
+	for {
+		msg, err := dec.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadNDJSONFile calls fn with each json value of the file
+func ReadNDJSONFile(path string, fn func(json.RawMessage) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ReadNDJSON(f, fn)
+}
+
+// AtomicFile is written to a temporary file in the directory of the destination, which is renamed
+// to the destination on Commit. An interrupted write leaves the destination untouched.
+type AtomicFile struct {
+	*os.File
+	path string
+}
+
+// CreateAtomicFile creates an atomic file for the path. If appendExisting is true, the current content
+// of the path is copied to the temporary file first.
+func CreateAtomicFile(path string, appendExisting bool) (*AtomicFile, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	res := &AtomicFile{File: f, path: path}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+		if appendExisting {
+			if err := res.copyFrom(path); err != nil {
+				res.Abort()
+				return nil, err
+			}
+		}
+	}
+	if err := f.Chmod(mode); err != nil {
+		res.Abort()
+		return nil, err
+	}
+	return res, nil
+}
+
+func (f *AtomicFile) copyFrom(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(f.File, src)
+	return err
+}
+
+// Commit closes the temporary file and renames it to the destination. The temporary file is
+// removed if the commit fails.
+func (f *AtomicFile) Commit() error {
+	if err := f.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort closes and removes the temporary file
+func (f *AtomicFile) Abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
@@ -0,0 +1,83 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ndjsonRow struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration"`
+}
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	buf := new(bytes.Buffer)
+	enc := NewNDJSONEncoder(buf)
+	assert.NoError(t, enc.Encode(ndjsonRow{Name: "conv<1>", Duration: 1.5}))
+	assert.NoError(t, enc.Encode(ndjsonRow{Name: "relu", Duration: 2}))
+	assert.NoError(t, enc.Flush())
+	assert.Equal(t, "{\"name\":\"conv<1>\",\"duration\":1.5}\n{\"name\":\"relu\",\"duration\":2}\n", buf.String())
+
+	dec := NewNDJSONDecoder(bytes.NewBufferString(buf.String() + "\n"))
+	var row ndjsonRow
+	assert.NoError(t, dec.Decode(&row))
+	assert.Equal(t, ndjsonRow{Name: "conv<1>", Duration: 1.5}, row)
+	assert.NoError(t, dec.Decode(&row))
+	assert.Equal(t, "relu", row.Name)
+	assert.Equal(t, io.EOF, dec.Decode(&row))
+
+	err := ReadNDJSON(bytes.NewBufferString("{\"name\":\"a\"}\n{\"name\":"), func(json.RawMessage) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestAtomicFileAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ndjson")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.ndjson")
+
+	write := func(name string, appendExisting bool) {
+		f, err := CreateAtomicFile(path, appendExisting)
+		assert.NoError(t, err)
+		enc := NewNDJSONEncoder(f)
+		assert.NoError(t, enc.Encode(ndjsonRow{Name: name}))
+		assert.NoError(t, enc.Flush())
+		assert.NoError(t, f.Commit())
+	}
+	write("first", false)
+	write("second", true)
+
+	// an aborted write leaves the file untouched
+	f, err := CreateAtomicFile(path, true)
+	assert.NoError(t, err)
+	f.WriteString("{\"name\":")
+	f.Abort()
+
+	names := []string{}
+	err = ReadNDJSONFile(path, func(msg json.RawMessage) error {
+		var row ndjsonRow
+		if err := json.Unmarshal(msg, &row); err != nil {
+			return err
+		}
+		names = append(names, row.Name)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, names)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	write("third", false)
+	bts, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"name\":\"third\",\"duration\":0}\n", string(bts))
+}
@@ -7,9 +7,20 @@ import (
 )
 
 type Options struct {
-	FilterKernelNames []string
-	ShowSummaryBase   bool
-	Formats           []string
+	FilterKernelNames   []string
+	ShowSummaryBase     bool
+	Formats             []string
+	ParquetRowGroupSize int64
+	Columns             []string
+	TimeUnit            string
+	BytesUnit           string
+	Precision           int
+	SortBy              []SortKey
+	Top                 int
+	LatexCaption        string
+	LatexLabel          string
+	LatexGroupBy        string
+	LatexBest           []BestKey
 }
 
 type Option func(*Options)
@@ -43,6 +54,89 @@ func Formats(f string) Option {
 	}
 }
 
+// HasFormat returns true if the output format is selected
+func (o Options) HasFormat(name string) bool {
+	name = strings.ToLower(name)
+	for _, f := range o.Formats {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func ParquetRowGroupSize(n int64) Option {
+	return func(w *Options) {
+		w.ParquetRowGroupSize = n
+	}
+}
+
+// Columns selects and orders the columns of the tabular output by their header keys
+func Columns(columns []string) Option {
+	return func(w *Options) {
+		w.Columns = columns
+	}
+}
+
+func TimeUnit(unit string) Option {
+	return func(w *Options) {
+		w.TimeUnit = unit
+	}
+}
+
+func BytesUnit(unit string) Option {
+	return func(w *Options) {
+		w.BytesUnit = unit
+	}
+}
+
+// Precision is the number of decimals of the floating point values. A negative precision keeps the values as is.
+func Precision(n int) Option {
+	return func(w *Options) {
+		w.Precision = n
+	}
+}
+
+// SortBy orders the output rows by the sort keys
+func SortBy(keys []SortKey) Option {
+	return func(w *Options) {
+		w.SortBy = keys
+	}
+}
+
+// Top keeps only the first n output rows once sorted. A non positive n keeps all the rows.
+func Top(n int) Option {
+	return func(w *Options) {
+		w.Top = n
+	}
+}
+
+func LatexCaption(caption string) Option {
+	return func(w *Options) {
+		w.LatexCaption = caption
+	}
+}
+
+func LatexLabel(label string) Option {
+	return func(w *Options) {
+		w.LatexLabel = label
+	}
+}
+
+// LatexGroupBy groups the rows of the latex tables by the values of the column
+func LatexGroupBy(column string) Option {
+	return func(w *Options) {
+		w.LatexGroupBy = column
+	}
+}
+
+// LatexBest highlights the best values of the columns in the latex tables
+func LatexBest(keys []BestKey) Option {
+	return func(w *Options) {
+		w.LatexBest = keys
+	}
+}
+
 func FromOptions(os Options) Option {
 	return func(w *Options) {
 		err := deepcopy.Copy(w, os)
@@ -53,7 +147,9 @@ func FromOptions(os Options) Option {
 }
 
 func NewOptions(opts ...Option) Options {
-	res := &Options{}
+	res := &Options{
+		Precision: -1,
+	}
 
 	for _, o := range opts {
 		o(res)
@@ -0,0 +1,86 @@
+package writer
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/source"
+	parquetwriter "github.com/xitongsys/parquet-go/writer"
+)
+
+var (
+	// DefaultParquetRowGroupSize is the size in bytes at which the buffered rows are flushed as a row group
+	DefaultParquetRowGroupSize int64 = 128 * 1024 * 1024
+	// DefaultParquetParallelism is the number of goroutines used to encode the rows
+	DefaultParquetParallelism int64 = 4
+)
+
+// ParquetWriter writes structs of a single type as the rows of a parquet file. The file is written
+// next to the destination and renamed on Close, so an interrupted write leaves the destination untouched.
+type ParquetWriter struct {
+	path    string
+	tmpPath string
+	file    source.ParquetFile
+	pw      *parquetwriter.JSONWriter
+}
+
+// NewParquetWriter creates a parquet file whose schema is derived from the prototype struct.
+// Row groups are flushed whenever the buffered rows exceed rowGroupSize bytes.
+func NewParquetWriter(path string, prototype interface{}, rowGroupSize int64) (*ParquetWriter, error) {
+	schema, err := ParquetSchema(prototype)
+	if err != nil {
+		return nil, err
+	}
+	tmpPath := path + ".tmp"
+	file, err := local.NewLocalFileWriter(tmpPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %v", tmpPath)
+	}
+	pw, err := parquetwriter.NewJSONWriter(schema, file, DefaultParquetParallelism)
+	if err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return nil, errors.Wrap(err, "failed to create the parquet writer")
+	}
+	if rowGroupSize <= 0 {
+		rowGroupSize = DefaultParquetRowGroupSize
+	}
+	pw.RowGroupSize = rowGroupSize
+	pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	return &ParquetWriter{
+		path:    path,
+		tmpPath: tmpPath,
+		file:    file,
+		pw:      pw,
+	}, nil
+}
+
+// Write appends the struct as a row. The struct must be of the prototype type.
+func (w *ParquetWriter) Write(v interface{}) error {
+	row, err := ParquetRow(v)
+	if err != nil {
+		return err
+	}
+	return w.pw.Write(row)
+}
+
+// FlushRowGroup writes the buffered rows as a row group
+func (w *ParquetWriter) FlushRowGroup() error {
+	return w.pw.Flush(true)
+}
+
+// Close writes the footer of the parquet file and renames it to the destination
+func (w *ParquetWriter) Close() error {
+	if err := w.pw.WriteStop(); err != nil {
+		w.file.Close()
+		os.Remove(w.tmpPath)
+		return errors.Wrap(err, "failed to write the parquet footer")
+	}
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.tmpPath)
+		return err
+	}
+	return os.Rename(w.tmpPath, w.path)
+}
@@ -0,0 +1,278 @@
+package writer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// parquetMaxDepth bounds the nesting of the groups derived from recursive types
+const parquetMaxDepth = 8
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	hexType  = reflect.TypeOf((*interface{ Hex() string })(nil)).Elem()
+)
+
+// parquetField is an exported struct field with its column name. Embedded structs are flattened
+// following the encoding/json rules, so the columns match the json output.
+type parquetField struct {
+	name  string
+	index []int
+	typ   reflect.Type
+	depth int
+}
+
+func parquetFields(t reflect.Type) []parquetField {
+	all := []parquetField{}
+	var collect func(t reflect.Type, index []int, depth int)
+	collect = func(t reflect.Type, index []int, depth int) {
+		for ii := 0; ii < t.NumField(); ii++ {
+			f := t.Field(ii)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name := strings.Split(tag, ",")[0]
+			fieldIndex := append(append([]int{}, index...), ii)
+			if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+				collect(f.Type, fieldIndex, depth+1)
+				continue
+			}
+			if f.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			all = append(all, parquetField{name: name, index: fieldIndex, typ: f.Type, depth: depth})
+		}
+	}
+	collect(t, nil, 0)
+
+	// the shallowest field wins and fields conflicting at the same depth are dropped
+	byName := map[string][]parquetField{}
+	for _, f := range all {
+		byName[f.name] = append(byName[f.name], f)
+	}
+	res := []parquetField{}
+	for _, f := range all {
+		dominant := true
+		for _, other := range byName[f.name] {
+			if other.depth < f.depth || (other.depth == f.depth && !reflect.DeepEqual(other.index, f.index)) {
+				dominant = false
+				break
+			}
+		}
+		if dominant {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+type parquetSchemaNode struct {
+	Tag    string               `json:"Tag"`
+	Fields []*parquetSchemaNode `json:"Fields,omitempty"`
+}
+
+func parquetTag(name string, kvs ...string) string {
+	return strings.Join(append([]string{"name=" + name}, kvs...), ", ")
+}
+
+func parquetSchemaOf(name string, t reflect.Type, depth int) (*parquetSchemaNode, error) {
+	if depth > parquetMaxDepth {
+		return nil, errors.Errorf("the type %v is nested too deeply", t)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	optional := "repetitiontype=OPTIONAL"
+	switch {
+	case t == timeType:
+		return &parquetSchemaNode{Tag: parquetTag(name, "type=INT64", "convertedtype=TIMESTAMP_MILLIS", optional)}, nil
+	case t.Implements(hexType) || reflect.PtrTo(t).Implements(hexType):
+		return &parquetSchemaNode{Tag: parquetTag(name, "type=BYTE_ARRAY", "convertedtype=UTF8", optional)}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &parquetSchemaNode{Tag: parquetTag(name, "type=BOOLEAN", optional)}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &parquetSchemaNode{Tag: parquetTag(name, "type=INT64", optional)}, nil
+	case reflect.Uint, reflect.Uint64:
+		// the values above the largest int64 are kept by reading the column as unsigned
+		return &parquetSchemaNode{Tag: parquetTag(name, "type=INT64", "convertedtype=UINT_64", optional)}, nil
+	case reflect.Float32, reflect.Float64:
+		return &parquetSchemaNode{Tag: parquetTag(name, "type=DOUBLE", optional)}, nil
+	case reflect.String, reflect.Map, reflect.Interface:
+		return &parquetSchemaNode{Tag: parquetTag(name, "type=BYTE_ARRAY", "convertedtype=UTF8", optional)}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// the bytes are written as base64 text, as in the json output
+			return &parquetSchemaNode{Tag: parquetTag(name, "type=BYTE_ARRAY", "convertedtype=UTF8", optional)}, nil
+		}
+		elem, err := parquetSchemaOf("element", t.Elem(), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return &parquetSchemaNode{
+			Tag:    parquetTag(name, "type=LIST", optional),
+			Fields: []*parquetSchemaNode{elem},
+		}, nil
+	case reflect.Struct:
+		node := &parquetSchemaNode{Tag: parquetTag(name, optional)}
+		for _, f := range parquetFields(t) {
+			child, err := parquetSchemaOf(f.name, f.typ, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			node.Fields = append(node.Fields, child)
+		}
+		if len(node.Fields) == 0 {
+			// parquet does not allow empty groups
+			return &parquetSchemaNode{Tag: parquetTag(name, "type=BYTE_ARRAY", "convertedtype=UTF8", optional)}, nil
+		}
+		return node, nil
+	}
+	return nil, errors.Errorf("the type %v cannot be written to parquet", t)
+}
+
+// ParquetSchema derives the json parquet schema of the struct type of the value. Embedded structs
+// such as the SummaryBase are flattened, slices become list columns and the other structs become
+// nested groups. Maps and interfaces are written as json strings and byte slices as base64 strings.
+func ParquetSchema(v interface{}) (string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", errors.Errorf("expecting a struct to derive the parquet schema but got %T", v)
+	}
+	root := &parquetSchemaNode{Tag: parquetTag("parquet_go_root", "repetitiontype=REQUIRED")}
+	for _, f := range parquetFields(t) {
+		child, err := parquetSchemaOf(f.name, f.typ, 1)
+		if err != nil {
+			return "", err
+		}
+		root.Fields = append(root.Fields, child)
+	}
+	bts, err := json.Marshal(root)
+	if err != nil {
+		return "", err
+	}
+	return string(bts), nil
+}
+
+func parquetValueOf(v reflect.Value) (interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Kind() == reflect.Interface {
+			bts, err := json.Marshal(v.Interface())
+			if err != nil {
+				return nil, err
+			}
+			return string(bts), nil
+		}
+		v = v.Elem()
+	}
+	t := v.Type()
+	switch {
+	case t == timeType:
+		tm := v.Interface().(time.Time)
+		if tm.IsZero() {
+			return nil, nil
+		}
+		return tm.UnixNano() / int64(time.Millisecond), nil
+	case t.Implements(hexType):
+		return v.Interface().(interface{ Hex() string }).Hex(), nil
+	case v.CanAddr() && reflect.PtrTo(t).Implements(hexType):
+		return v.Addr().Interface().(interface{ Hex() string }).Hex(), nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		// NaN and infinities cannot be represented in the json rows
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, nil
+		}
+		return f, nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		bts, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return string(bts), nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			bts := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(bts), v)
+			return base64.StdEncoding.EncodeToString(bts), nil
+		}
+		res := make([]interface{}, v.Len())
+		for ii := range res {
+			elem, err := parquetValueOf(v.Index(ii))
+			if err != nil {
+				return nil, err
+			}
+			res[ii] = elem
+		}
+		return res, nil
+	case reflect.Struct:
+		fields := parquetFields(t)
+		if len(fields) == 0 {
+			bts, err := json.Marshal(v.Interface())
+			if err != nil {
+				return nil, err
+			}
+			return string(bts), nil
+		}
+		res := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			elem, err := parquetValueOf(v.FieldByIndex(f.index))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to convert the %v field", f.name)
+			}
+			res[f.name] = elem
+		}
+		return res, nil
+	}
+	return nil, errors.Errorf("the type %v cannot be written to parquet", t)
+}
+
+// ParquetRow converts the struct value to the json row matching its ParquetSchema
+func ParquetRow(v interface{}) (string, error) {
+	value, err := parquetValueOf(reflect.ValueOf(v))
+	if err != nil {
+		return "", err
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return "", errors.Errorf("expecting a struct to write a parquet row but got %T", v)
+	}
+	bts, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(bts), nil
+}
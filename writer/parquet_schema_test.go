@@ -0,0 +1,100 @@
+package writer
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type parquetTestID string
+
+func (id parquetTestID) Hex() string {
+	return "6869"
+}
+
+type parquetTestBase struct {
+	ID        parquetTestID `json:"id"`
+	CreatedAt time.Time     `json:"created_at"`
+	ModelName string        `json:"model_name,omitempty"`
+	Driver    *string       `json:"gpu_driver,omitempty"`
+}
+
+type parquetTestModel struct {
+	parquetTestBase `json:",inline"`
+	Durations       []int64 `json:"durations,omitempty"`
+	Duration        float64 `json:"duration,omitempty"`
+}
+
+type parquetTestLayer struct {
+	parquetTestModel `json:",inline"`
+	Durations        []int64           `json:"durations,omitempty"`
+	Duration         float64           `json:"mean_duration,omitempty"`
+	Tags             map[string]string `json:"tags,omitempty"`
+	MemoryBound      bool
+	ignored          int
+}
+
+func TestParquetSchema(t *testing.T) {
+	schema, err := ParquetSchema(parquetTestLayer{})
+	assert.NoError(t, err)
+
+	var root parquetSchemaNode
+	assert.NoError(t, json.Unmarshal([]byte(schema), &root))
+	tags := []string{}
+	for _, f := range root.Fields {
+		tags = append(tags, f.Tag)
+	}
+	assert.Equal(t, []string{
+		"name=id, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL",
+		"name=created_at, type=INT64, convertedtype=TIMESTAMP_MILLIS, repetitiontype=OPTIONAL",
+		"name=model_name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL",
+		"name=gpu_driver, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL",
+		"name=duration, type=DOUBLE, repetitiontype=OPTIONAL",
+		"name=durations, type=LIST, repetitiontype=OPTIONAL",
+		"name=mean_duration, type=DOUBLE, repetitiontype=OPTIONAL",
+		"name=tags, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL",
+		"name=MemoryBound, type=BOOLEAN, repetitiontype=OPTIONAL",
+	}, tags)
+	assert.Equal(t, "name=element, type=INT64, repetitiontype=OPTIONAL", root.Fields[5].Fields[0].Tag)
+
+	// the unsigned integers are read as unsigned and the bytes as base64 text
+	schema, err = ParquetSchema(parquetTestRow{})
+	assert.NoError(t, err)
+	root = parquetSchemaNode{}
+	assert.NoError(t, json.Unmarshal([]byte(schema), &root))
+	assert.Equal(t, "name=count, type=INT64, convertedtype=UINT_64, repetitiontype=OPTIONAL", root.Fields[1].Tag)
+	assert.Equal(t, "name=data, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL", root.Fields[2].Tag)
+
+	_, err = ParquetSchema([]int{})
+	assert.Error(t, err)
+}
+
+func TestParquetRow(t *testing.T) {
+	layer := parquetTestLayer{
+		Durations:   []int64{1, 2},
+		Duration:    math.NaN(),
+		Tags:        map[string]string{"a": "b"},
+		MemoryBound: true,
+	}
+	layer.CreatedAt = time.Unix(1, 0)
+	layer.ModelName = "resnet"
+	layer.parquetTestModel.Durations = []int64{3}
+	layer.parquetTestModel.Duration = 4
+
+	row, err := ParquetRow(layer)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "6869",
+		"created_at": 1000,
+		"model_name": "resnet",
+		"gpu_driver": null,
+		"duration": 4,
+		"durations": [1, 2],
+		"mean_duration": null,
+		"tags": "{\"a\":\"b\"}",
+		"MemoryBound": true
+	}`, row)
+}
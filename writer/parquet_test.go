@@ -0,0 +1,95 @@
+package writer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+type parquetTestRow struct {
+	Name     string  `json:"name"`
+	Count    uint64  `json:"count"`
+	Data     []byte  `json:"data,omitempty"`
+	Duration float64 `json:"duration"`
+}
+
+// readParquetColumn reads all the values of the column and returns them with the number of row groups
+func readParquetColumn(t *testing.T, path, column string) ([]interface{}, int) {
+	file, err := local.NewLocalFileReader(path)
+	require.NoError(t, err)
+	defer file.Close()
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+	values, _, _, err := pr.ReadColumnByPath(common.ReformPathStr("parquet_go_root."+column), pr.GetNumRows())
+	require.NoError(t, err)
+	return values, len(pr.Footer.RowGroups)
+}
+
+func TestParquetWriterRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parquet")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rows := make([]parquetTestRow, 200)
+	for ii := range rows {
+		// the long names fill the pages, so that the rows are flushed several times
+		rows[ii] = parquetTestRow{
+			Name:     fmt.Sprintf("layer_%03d_%s", ii, strings.Repeat("x", 4096)),
+			Count:    uint64(ii),
+			Duration: float64(ii) / 2,
+		}
+	}
+	rows[0].Count = math.MaxUint64
+	rows[1].Data = []byte{0, 0xff}
+
+	write := func(path string, rowGroupSize int64) {
+		w, err := NewParquetWriter(path, parquetTestRow{}, rowGroupSize)
+		require.NoError(t, err)
+		for _, row := range rows {
+			require.NoError(t, w.Write(row))
+		}
+		require.NoError(t, w.Close())
+		_, err = os.Stat(path + ".tmp")
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	small := filepath.Join(dir, "small.parquet")
+	write(small, 1)
+	names, numRowGroups := readParquetColumn(t, small, "name")
+	assert.True(t, numRowGroups > 1, "the rows are written in several row groups")
+	require.Len(t, names, len(rows))
+	for ii, name := range names {
+		assert.Equal(t, rows[ii].Name, name)
+	}
+
+	// the unsigned values above the largest int64 are read back from their bits
+	counts, _ := readParquetColumn(t, small, "count")
+	require.Len(t, counts, len(rows))
+	assert.Equal(t, uint64(math.MaxUint64), uint64(counts[0].(int64)))
+	assert.Equal(t, int64(199), counts[199])
+
+	// the bytes are written as base64 text
+	data, _ := readParquetColumn(t, small, "data")
+	require.Len(t, data, len(rows))
+	assert.Nil(t, data[0])
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte{0, 0xff}), data[1])
+
+	large := filepath.Join(dir, "large.parquet")
+	write(large, 0)
+	durations, numRowGroups := readParquetColumn(t, large, "duration")
+	assert.Equal(t, 1, numRowGroups)
+	require.Len(t, durations, len(rows))
+	assert.Equal(t, 99.5, durations[199])
+}
@@ -0,0 +1,101 @@
+package writer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SortKey orders the rows by the values of a column
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+// ParseSortKeys parses comma separated sort keys of the form <column>[:asc|desc], e.g.
+// "layer_duration:desc,layer_index". The rows are ascending by default.
+func ParseSortKeys(s string) ([]SortKey, error) {
+	res := []SortKey{}
+	for _, spec := range strings.Split(s, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		key := SortKey{Column: spec}
+		if idx := strings.LastIndex(spec, ":"); idx != -1 {
+			key.Column = strings.TrimSpace(spec[:idx])
+			switch strings.ToLower(strings.TrimSpace(spec[idx+1:])) {
+			case "asc":
+			case "desc":
+				key.Descending = true
+			default:
+				return nil, errors.Errorf("invalid sort order in %v, expecting asc or desc", spec)
+			}
+		}
+		if key.Column == "" {
+			return nil, errors.Errorf("missing sort column in %v", spec)
+		}
+		res = append(res, key)
+	}
+	return res, nil
+}
+
+// SortsRows returns true if the rows are sorted or truncated by the options, in which case
+// they are buffered until the output is flushed
+func (o Options) SortsRows() bool {
+	return len(o.SortBy) != 0 || o.Top > 0
+}
+
+// MissingSortColumns returns the sort columns which are not in the header
+func (o Options) MissingSortColumns(header []string) []string {
+	res := []string{}
+	for _, key := range o.SortBy {
+		if columnIndex(header, key.Column) == -1 {
+			res = append(res, key.Column)
+		}
+	}
+	return res
+}
+
+// CompareCells compares the cells as numbers if both are numbers and as strings otherwise
+func CompareCells(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+// LessRows orders the rows by the sort keys. The header is the untransformed header of the rows,
+// so the rows can be sorted by columns which are not selected for the output.
+func (o Options) LessRows(header []string, a, b []string) bool {
+	for _, key := range o.SortBy {
+		ii := columnIndex(header, key.Column)
+		if ii == -1 {
+			continue
+		}
+		cellA, cellB := "", ""
+		if ii < len(a) {
+			cellA = a[ii]
+		}
+		if ii < len(b) {
+			cellB = b[ii]
+		}
+		c := CompareCells(cellA, cellB)
+		if key.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
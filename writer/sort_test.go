@@ -0,0 +1,45 @@
+package writer
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortRows(t *testing.T) {
+	keys, err := ParseSortKeys("layer_duration:desc, layer_name")
+	assert.NoError(t, err)
+	assert.Equal(t, []SortKey{{Column: "layer_duration", Descending: true}, {Column: "layer_name"}}, keys)
+
+	_, err = ParseSortKeys("layer_duration:up")
+	assert.Error(t, err)
+	_, err = ParseSortKeys(":desc")
+	assert.Error(t, err)
+
+	header := []string{"layer_name", "layer_duration (us)"}
+	rows := [][]string{
+		{"relu", "9"},
+		{"conv", "100"},
+		{"bn", "9"},
+		{"fc", "20.5"},
+	}
+	opts := NewOptions(SortBy(keys), Top(3))
+	assert.True(t, opts.SortsRows())
+	assert.Empty(t, opts.MissingSortColumns(header))
+	assert.Equal(t, []string{"missing"}, NewOptions(SortBy([]SortKey{{Column: "missing"}})).MissingSortColumns(header))
+
+	sort.SliceStable(rows, func(ii, jj int) bool {
+		return opts.LessRows(header, rows[ii], rows[jj])
+	})
+	assert.Equal(t, [][]string{
+		{"conv", "100"},
+		{"fc", "20.5"},
+		{"bn", "9"},
+		{"relu", "9"},
+	}, rows)
+
+	assert.Equal(t, -1, CompareCells("9", "10"))
+	assert.Equal(t, 1, CompareCells("b", "a"))
+	assert.False(t, NewOptions().SortsRows())
+}
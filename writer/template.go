@@ -0,0 +1,152 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+)
+
+// TemplateData is the value the output templates are executed over
+type TemplateData struct {
+	// Header is the header of the tabular output
+	Header []string
+	// Rows are the typed summaries, or the rows keyed by the header labels for the summaries
+	// output as several rows
+	Rows []interface{}
+	// Cells are the rows of the tabular output
+	Cells [][]string
+}
+
+// Template executes a text/template over the typed rows once they are all written
+type Template struct {
+	tmpl *template.Template
+	data TemplateData
+}
+
+// TemplateFuncs are the helper functions of the output templates
+//
+//	fixed v [precision]       formats the number with 2 decimals by default
+//	percent v                 formats the fraction as a percentage
+//	duration v [unit]         formats the microseconds in ns, us, ms or s, picked from the value by default
+//	bytes v [unit]            formats the bytes in B, KiB, MiB or GiB, picked from the value by default
+//	join sep list             joins the values of any list
+//	json v                    marshals the value
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"fixed":    templateFixed,
+		"percent":  templatePercent,
+		"duration": templateDuration,
+		"bytes":    templateBytes,
+		"join":     templateJoin,
+		"json":     templateJSON,
+	}
+}
+
+func templateFixed(v interface{}, precision ...int) string {
+	prec := 2
+	if len(precision) != 0 {
+		prec = precision[0]
+	}
+	return strconv.FormatFloat(cast.ToFloat64(v), 'f', prec, 64)
+}
+
+func templatePercent(v interface{}) string {
+	return fmt.Sprintf("%.2f%%", cast.ToFloat64(v)*100)
+}
+
+// templateUnit formats the value in the unit, or the largest unit in which it is at least one
+func templateUnit(v float64, units map[string]float64, order []string, unit []string) (string, error) {
+	u := order[0]
+	if len(unit) != 0 {
+		u = unit[0]
+		if _, ok := units[u]; !ok {
+			return "", errors.Errorf("invalid unit %v", u)
+		}
+	} else {
+		for _, candidate := range order {
+			if v >= units[candidate] || -v >= units[candidate] {
+				u = candidate
+			}
+		}
+	}
+	return strconv.FormatFloat(v/units[u], 'f', -1, 64) + " " + u, nil
+}
+
+func templateDuration(v interface{}, unit ...string) (string, error) {
+	return templateUnit(cast.ToFloat64(v), timeUnits, []string{"ns", "us", "ms", "s"}, unit)
+}
+
+func templateBytes(v interface{}, unit ...string) (string, error) {
+	return templateUnit(cast.ToFloat64(v), bytesUnits, []string{"B", "KiB", "MiB", "GiB"}, unit)
+}
+
+func templateJoin(sep string, list interface{}) string {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return cast.ToString(list)
+	}
+	res := make([]string, v.Len())
+	for ii := range res {
+		res[ii] = cast.ToString(v.Index(ii).Interface())
+	}
+	return strings.Join(res, sep)
+}
+
+func templateJSON(v interface{}) (string, error) {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(bts), nil
+}
+
+func ParseTemplate(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(TemplateFuncs()).Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the template %v", name)
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+func ReadTemplate(path string) (*Template, error) {
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the template %v", path)
+	}
+	return ParseTemplate(filepath.Base(path), string(bts))
+}
+
+// TemplateExtension is the extension of the output of the template file, e.g. "md" for "slack.md.tmpl"
+func TemplateExtension(path string) string {
+	base := filepath.Base(path)
+	for _, ext := range []string{".tmpl", ".tpl", ".gotmpl"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	if ext := filepath.Ext(base); ext != "" {
+		return strings.TrimPrefix(ext, ".")
+	}
+	return "txt"
+}
+
+func (t *Template) SetHeader(header []string) {
+	t.data.Header = header
+}
+
+// Append adds the typed value and the tabular row of a summary
+func (t *Template) Append(value interface{}, cells []string) {
+	t.data.Rows = append(t.data.Rows, value)
+	t.data.Cells = append(t.data.Cells, cells)
+}
+
+func (t *Template) Execute(w io.Writer) error {
+	return t.tmpl.Execute(w, t.data)
+}
@@ -0,0 +1,42 @@
+package writer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type templateSummary struct {
+	Name      string
+	Duration  float64
+	Bytes     int64
+	Occupancy float64
+	Durations []int64
+}
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("slack", `{{range .Rows}}*{{.Name}}* {{duration .Duration}} ({{duration .Duration "ms"}}) {{bytes .Bytes}} {{percent .Occupancy}} [{{join ", " .Durations}}] {{fixed .Duration 1}}
+{{end}}{{join "|" .Header}} {{len .Cells}}`)
+	assert.NoError(t, err)
+
+	tmpl.SetHeader([]string{"name", "duration (us)"})
+	tmpl.Append(templateSummary{Name: "conv", Duration: 1500, Bytes: 3 << 20, Occupancy: 0.5, Durations: []int64{1000, 2000}}, []string{"conv", "1500"})
+	tmpl.Append(templateSummary{Name: "relu", Duration: 0.25, Bytes: 512}, []string{"relu", "0.25"})
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, tmpl.Execute(buf))
+	assert.Equal(t, `*conv* 1.5 ms (1.5 ms) 3 MiB 50.00% [1000, 2000] 1500.0
+*relu* 250 ns (0.00025 ms) 512 B 0.00% [] 0.2
+name|duration (us) 2`, buf.String())
+
+	_, err = ParseTemplate("invalid", "{{.Rows")
+	assert.Error(t, err)
+
+	tmpl, err = ParseTemplate("unit", `{{duration 1 "minutes"}}`)
+	assert.NoError(t, err)
+	assert.Error(t, tmpl.Execute(buf))
+
+	assert.Equal(t, "md", TemplateExtension("/tmp/slack.md.tmpl"))
+	assert.Equal(t, "txt", TemplateExtension("report.tmpl"))
+}